@@ -6,8 +6,10 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/database"
 	"github.com/Keba777/levpay-backend/internal/utils"
@@ -30,8 +32,17 @@ func main() {
 	}
 	logger.Info("AutoMigrate completed successfully")
 
+	audit.InitAudit(database.DB)
+	defer audit.Close()
+
+	// Verify tokens with the keys published by the auth service
+	utils.InitJWTVerifier(config.CFG.Security.JWKSURL)
+
 	app := fiber.New(fiber.Config{
-		Network: "tcp",
+		Network:                 "tcp",
+		ProxyHeader:             "X-Real-IP", // Client IP as forwarded by nginx
+		EnableTrustedProxyCheck: true,        // Ignored unless sent from the gateway network
+		TrustedProxies:          config.CFG.App.TrustedProxies,
 	})
 
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -49,7 +60,7 @@ func main() {
 	router.SetupAdminRoutes(api, database.DB)
 
 	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
 	var serviceShutdown sync.WaitGroup
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.CFG.App.Shutdown)*time.Second)
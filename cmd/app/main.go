@@ -6,12 +6,20 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/Keba777/levpay-backend/feature/signing_key"
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/database"
+	"github.com/Keba777/levpay-backend/internal/kms"
 	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/oauth"
 	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/Keba777/levpay-backend/internal/security"
+	"github.com/Keba777/levpay-backend/internal/sms"
 	"github.com/Keba777/levpay-backend/internal/storage"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/Keba777/levpay-backend/router"
@@ -32,25 +40,51 @@ func main() {
 	logger.Info("Running database AutoMigrate...")
 
 	if !config.CFG.DB.SkipAutoMigrate {
+		backfills := database.PendingBackfills()
 		if err := database.DB.AutoMigrate(
 			// Core user and authentication models
 			&models.User{},
 			&models.Session{},
+			&models.PasswordHistory{},
+			&models.PasswordResetToken{},
+			&models.OAuthIdentity{},
+			&models.AccountStatusEvent{},
+			&models.RoleAssignment{},
+			&models.RecoveryCode{},
+			&models.TwoFactorChallenge{},
+			&models.PhoneVerification{},
+			&models.LoginAttempt{},
 
 			// Wallet and financial models
 			&models.Wallet{},
 			&models.Transaction{},
 			&models.PaymentMethod{},
 			&models.Invoice{},
+			&models.InvoiceReminderSetting{},
+			&models.InvoiceReminder{},
+			&models.SettlementSetting{},
+			&models.SettlementBatch{},
+			&models.Dispute{},
+			&models.DisputeEvidence{},
 
 			// KYC and verification models
 			&models.KYCDocument{},
+			&models.MerchantProfile{},
+
+			// Merchant organization models
+			&models.MerchantOrganization{},
+			&models.MerchantMember{},
+			&models.MerchantAuditLog{},
 
 			// Communication models
 			&models.Notification{},
 
 			// Audit and security models
 			&models.AuditLog{},
+			&models.SigningKey{},
+
+			// Scheduled job models
+			&models.JobRun{},
 
 			// File management models
 			&models.File{},
@@ -58,23 +92,48 @@ func main() {
 			logger.ErrorWithErr("AutoMigrate failed", err)
 			panic(fmt.Sprintf("AutoMigrate failed: %v", err))
 		}
+		if err := backfills.Run(); err != nil {
+			logger.ErrorWithErr("AutoMigrate failed", err)
+			panic(fmt.Sprintf("AutoMigrate failed: %v", err))
+		}
 	}
 
 	logger.Info("AutoMigrate completed successfully")
 
+	audit.InitAudit(database.DB)
+	defer audit.Close()
+	ratelimit.InitRateLimit()
+	defer ratelimit.Close()
+
 	// Initialize utilities
-	utils.InitJWT(os.Getenv("JWT_SECRET"))
-	utils.InitPasswordUtils(12) // bcrypt complexity
+	policy, err := security.NewPolicy(config.CFG)
+	if err != nil {
+		logger.ErrorWithErr("Invalid security configuration", err)
+		panic(err)
+	}
+	utils.InitJWT()
+	utils.InitCipher(policy.EncryptionKey)
+	kms.InitKMS()
+	if err := signing_key.NewService(database.DB, policy).Start(time.Minute); err != nil {
+		logger.ErrorWithErr("Failed to load signing keys", err)
+		panic(err)
+	}
+	sms.InitSMS()
+	oauth.InitOAuth()
+	utils.InitPasswordUtils(policy.BcryptCost)
 
 	app := fiber.New(fiber.Config{
-		Network:   "tcp",
-		BodyLimit: 10 * 1024 * 1024, // 10MB limit for file uploads
+		Network:                 "tcp",
+		BodyLimit:               10 * 1024 * 1024, // 10MB limit for file uploads
+		ProxyHeader:             "X-Real-IP",      // Client IP as forwarded by nginx
+		EnableTrustedProxyCheck: true,             // Ignored unless sent from the gateway network
+		TrustedProxies:          config.CFG.App.TrustedProxies,
 	})
 
 	// CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Merchant-ID, X-Step-Up-Token",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 
@@ -91,17 +150,22 @@ func main() {
 
 	// Setup API Routes
 	// Auth routes handle their own /api/auth prefix
-	router.SetupAuthRoutes(app, database.DB)
+	router.SetupAuthRoutes(app, database.DB, policy)
+	router.SetupJWKSRoutes(app)
 
 	// Other routes grouped under /api
 	api := app.Group("/api")
 	router.SetupUserRoutes(api, database.DB)
 	router.SetupKYCRoutes(api, database.DB)
-	router.SetupWalletRoutes(api, database.DB)
-	router.SetupTransactionRoutes(api, database.DB)
+	router.SetupMerchantRoutes(api, database.DB)
+	router.SetupWalletRoutes(api, database.DB, policy)
+	router.SetupTransactionRoutes(api, database.DB, policy)
+	router.SetupDisputeRoutes(api, database.DB)
 	router.SetupNotificationRoutes(api, database.DB)
 	router.SetupFileRoutes(api, database.DB)
-	router.SetupBillingRoutes(api, database.DB)
+	router.SetupBillingRoutes(api, database.DB, policy)
+	router.SetupSettlementRoutes(api, database.DB)
+	router.SetupOrganizationRoutes(api, database.DB)
 	router.SetupPaymentMethodRoutes(api, database.DB)
 	router.SetupAdminRoutes(api, database.DB)
 
@@ -109,7 +173,7 @@ func main() {
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
 	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
 	var serviceShutdown sync.WaitGroup
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.CFG.App.Shutdown)*time.Second)
@@ -5,11 +5,19 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/Keba777/levpay-backend/feature/signing_key"
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/database"
 	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/oauth"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/Keba777/levpay-backend/internal/security"
+	"github.com/Keba777/levpay-backend/internal/sms"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/Keba777/levpay-backend/router"
 
@@ -20,27 +28,64 @@ import (
 func main() {
 	config.InitConfig()
 	database.Connect()
+	rabbitmq.InitRabbitMQ(config.CFG)
+	defer rabbitmq.RMQ.Close()
 
 	// AutoMigrate only auth-related models for this service
 	if !config.CFG.DB.SkipAutoMigrate {
+		backfills := database.PendingBackfills()
 		database.DB.AutoMigrate(
 			&models.User{},
 			&models.Session{},
+			&models.PasswordHistory{},
+			&models.PasswordResetToken{},
+			&models.OAuthIdentity{},
+			&models.AccountStatusEvent{},
+			&models.RoleAssignment{},
+			&models.RecoveryCode{},
+			&models.TwoFactorChallenge{},
+			&models.PhoneVerification{},
+			&models.LoginAttempt{},
+			&models.AuditLog{},
+			&models.SigningKey{},
 		)
+		if err := backfills.Run(); err != nil {
+			panic(err)
+		}
 	}
 
+	audit.InitAudit(database.DB)
+	defer audit.Close()
+	ratelimit.InitRateLimit()
+	defer ratelimit.Close()
+
 	// Initialize JWT and password utilities
-	utils.InitJWT(os.Getenv("JWT_SECRET"))
-	utils.InitPasswordUtils(12)
+	policy, err := security.NewPolicy(config.CFG)
+	if err != nil {
+		utils.GetLogger("auth").ErrorWithErr("Invalid security configuration", err)
+		panic(err)
+	}
+	utils.InitJWT()
+	utils.InitCipher(policy.EncryptionKey)
+	if err := signing_key.NewService(database.DB, policy).Start(time.Minute); err != nil {
+		utils.GetLogger("auth").ErrorWithErr("Failed to load signing keys", err)
+		panic(err)
+	}
+	sms.InitSMS()
+	oauth.InitOAuth()
+	utils.InitPasswordUtils(policy.BcryptCost)
 
 	app := fiber.New(fiber.Config{
-		Network: "tcp",
+		Network:                 "tcp",
+		ProxyHeader:             "X-Real-IP", // Client IP as forwarded by nginx
+		EnableTrustedProxyCheck: true,        // Ignored unless sent from the gateway network
+		TrustedProxies:          config.CFG.App.TrustedProxies,
 	})
 
 	// CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Step-Up-Token",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 
@@ -56,10 +101,11 @@ func main() {
 	})
 
 	// Setup auth routes
-	router.SetupAuthRoutes(app, database.DB)
+	router.SetupAuthRoutes(app, database.DB, policy)
+	router.SetupJWKSRoutes(app)
 
 	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
 	var serviceShutdown sync.WaitGroup
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.CFG.App.Shutdown)*time.Second)
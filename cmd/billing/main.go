@@ -6,10 +6,15 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/database"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/Keba777/levpay-backend/router"
 
@@ -20,6 +25,8 @@ import (
 func main() {
 	config.InitConfig()
 	database.Connect()
+	rabbitmq.InitRabbitMQ(config.CFG)
+	defer rabbitmq.RMQ.Close()
 
 	logger := utils.GetLogger("billing")
 	logger.Info("Running database AutoMigrate...")
@@ -31,14 +38,35 @@ func main() {
 	}
 	logger.Info("AutoMigrate completed successfully")
 
+	audit.InitAudit(database.DB)
+	defer audit.Close()
+	ratelimit.InitRateLimit()
+	defer ratelimit.Close()
+
+	policy, err := security.NewPolicy(config.CFG)
+	if err != nil {
+		logger.ErrorWithErr("Invalid security configuration", err)
+		panic(err)
+	}
+
+	// Verify tokens with the keys published by the auth service
+	utils.InitJWTVerifier(config.CFG.Security.JWKSURL)
+
+	// Verify transaction PINs and TOTP codes for payments
+	utils.InitCipher(policy.EncryptionKey)
+	utils.InitPasswordUtils(policy.BcryptCost)
+
 	app := fiber.New(fiber.Config{
-		Network: "tcp",
+		Network:                 "tcp",
+		ProxyHeader:             "X-Real-IP", // Client IP as forwarded by nginx
+		EnableTrustedProxyCheck: true,        // Ignored unless sent from the gateway network
+		TrustedProxies:          config.CFG.App.TrustedProxies,
 	})
 
 	// CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Merchant-ID",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 
@@ -54,10 +82,12 @@ func main() {
 
 	// Setup API Routes
 	api := app.Group("/api")
-	router.SetupBillingRoutes(api, database.DB)
+	router.SetupBillingRoutes(api, database.DB, policy)
+	router.SetupSettlementRoutes(api, database.DB)
+	router.SetupOrganizationRoutes(api, database.DB)
 
 	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
 	var serviceShutdown sync.WaitGroup
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.CFG.App.Shutdown)*time.Second)
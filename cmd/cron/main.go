@@ -7,14 +7,18 @@ import (
 	"syscall"
 
 	"github.com/Keba777/levpay-backend/feature/cron"
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/database"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
 	"github.com/Keba777/levpay-backend/internal/utils"
 )
 
 func main() {
 	config.InitConfig()
 	database.Connect()
+	rabbitmq.InitRabbitMQ(config.CFG)
+	defer rabbitmq.RMQ.Close()
 
 	logger := utils.GetLogger("cron")
 	logger.Info("Running database AutoMigrate...")
@@ -26,6 +30,9 @@ func main() {
 	}
 	logger.Info("AutoMigrate completed successfully")
 
+	audit.InitAudit(database.DB)
+	defer audit.Close()
+
 	// Initialize and start cron scheduler
 	scheduler := cron.NewScheduler(database.DB)
 	if err := scheduler.Start(); err != nil {
@@ -6,10 +6,13 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/database"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
 	"github.com/Keba777/levpay-backend/internal/storage"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/Keba777/levpay-backend/router"
@@ -22,6 +25,8 @@ func main() {
 	config.InitConfig()
 	database.Connect()
 	storage.InitMinio()
+	rabbitmq.InitRabbitMQ(config.CFG)
+	defer rabbitmq.RMQ.Close()
 
 	logger := utils.GetLogger("kyc")
 	logger.Info("Running database AutoMigrate...")
@@ -33,8 +38,17 @@ func main() {
 	}
 	logger.Info("AutoMigrate completed successfully")
 
+	audit.InitAudit(database.DB)
+	defer audit.Close()
+
+	// Verify tokens with the keys published by the auth service
+	utils.InitJWTVerifier(config.CFG.Security.JWKSURL)
+
 	app := fiber.New(fiber.Config{
-		Network: "tcp",
+		Network:                 "tcp",
+		ProxyHeader:             "X-Real-IP", // Client IP as forwarded by nginx
+		EnableTrustedProxyCheck: true,        // Ignored unless sent from the gateway network
+		TrustedProxies:          config.CFG.App.TrustedProxies,
 	})
 
 	// CORS middleware
@@ -57,9 +71,10 @@ func main() {
 	// Setup API Routes
 	api := app.Group("/api")
 	router.SetupKYCRoutes(api, database.DB)
+	router.SetupMerchantRoutes(api, database.DB)
 
 	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
 	var serviceShutdown sync.WaitGroup
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.CFG.App.Shutdown)*time.Second)
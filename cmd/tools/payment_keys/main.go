@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Keba777/levpay-backend/feature/payment_method"
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/database"
+	"github.com/Keba777/levpay-backend/internal/kms"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+const usage = `Usage: go run ./cmd/tools/payment_keys [-file path] <command>
+
+Commands:
+  generate    Create a new key file
+  rotate      Add a new active key, then re-encrypt every payment method under it
+  reencrypt   Re-encrypt payment methods not yet under the active key, including
+              legacy plaintext rows (resumes an interrupted rotation)
+  status      List the keys and how many payment methods each one protects`
+
+func main() {
+	// Parse flags
+	file := flag.String("file", "", "Key file (default SECURITY_PAYMENT_KEY_FILE)")
+	flag.Usage = func() { fmt.Println(usage) }
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	config.InitConfig()
+	path := config.CFG.Security.PaymentKeyFile
+	if *file != "" {
+		path = *file
+	}
+
+	switch flag.Arg(0) {
+	case "generate":
+		if err := kms.GenerateKeyFile(path); err != nil {
+			log.Fatalf("Failed to generate key file: %v", err)
+		}
+		fmt.Printf("Key file %s created\n", path)
+	case "rotate":
+		km := loadKeys(path)
+		keyID, err := km.Rotate()
+		if err != nil {
+			log.Fatalf("Failed to rotate keys: %v", err)
+		}
+		fmt.Printf("Key %s is now active\n", keyID)
+		reencrypt(km)
+	case "reencrypt":
+		reencrypt(loadKeys(path))
+	case "status":
+		status(loadKeys(path))
+	default:
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+func loadKeys(path string) *kms.LocalKeyManager {
+	km, err := kms.NewLocalKeyManager(path)
+	if err != nil {
+		log.Fatalf("Failed to load key file: %v", err)
+	}
+	return km
+}
+
+// reencrypt moves every payment method onto the active key
+func reencrypt(km *kms.LocalKeyManager) {
+	database.Connect()
+
+	result, err := payment_method.Reencrypt(database.DB, km, func(id uuid.UUID, err error) {
+		log.Printf("Payment method %s not re-encrypted: %v", id, err)
+	})
+	if err != nil {
+		log.Fatalf("Re-encryption stopped: %v", err)
+	}
+
+	fmt.Printf("Re-encrypted %d of %d payment methods under key %s\n", result.Reencrypted, result.Checked, result.ActiveKeyID)
+	if result.Failed > 0 {
+		log.Fatalf("%d payment methods failed; fix them and run reencrypt again before removing old keys", result.Failed)
+	}
+	fmt.Println("Keys other than the active one no longer protect any payment method and may be removed from the key file")
+}
+
+// status lists the keys and the payment methods under each
+func status(km *kms.LocalKeyManager) {
+	database.Connect()
+
+	var counts []struct {
+		KeyID string
+		Count int64
+	}
+	err := database.DB.Model(&models.PaymentMethod{}).Unscoped().
+		Select("COALESCE(key_id, '') AS key_id, COUNT(*) AS count").
+		Group("COALESCE(key_id, '')").
+		Scan(&counts).Error
+	if err != nil {
+		log.Fatalf("Failed to count payment methods: %v", err)
+	}
+	byKey := map[string]int64{}
+	for _, c := range counts {
+		byKey[c.KeyID] = c.Count
+	}
+
+	active := km.ActiveKeyID()
+	for _, id := range km.KeyIDs() {
+		marker := ""
+		if id == active {
+			marker = " (active)"
+		}
+		fmt.Printf("%s%s: %d payment methods\n", id, marker, byKey[id])
+		delete(byKey, id)
+	}
+	if n := byKey[""]; n > 0 {
+		fmt.Printf("Not encrypted: %d payment methods\n", n)
+		delete(byKey, "")
+	}
+	for id, n := range byKey {
+		fmt.Printf("%s (missing from key file): %d payment methods\n", id, n)
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/database"
 	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -31,14 +34,12 @@ func main() {
 	config.InitConfig()
 	database.Connect()
 
-	// Init password utils
-	utils.InitPasswordUtils(12)
-
-	// Hash password
-	hashedPassword, err := utils.PWD.HashPassword(*password)
+	// Init password utils with the configured cost
+	policy, err := security.NewPolicy(config.CFG)
 	if err != nil {
-		log.Fatalf("Failed to hash password: %v", err)
+		log.Fatalf("Invalid security configuration: %v", err)
 	}
+	utils.InitPasswordUtils(policy.BcryptCost)
 
 	// Split name
 	names := strings.Split(*fullName, " ")
@@ -48,23 +49,49 @@ func main() {
 		lastName = strings.Join(names[1:], " ")
 	}
 
+	// Enforce the password policy
+	if err := policy.ValidatePassword(*password, *email, firstName, lastName); err != nil {
+		log.Fatalf("Password rejected: %v", err)
+	}
+
+	// Hash password
+	hashedPassword, err := utils.PWD.HashPassword(*password)
+	if err != nil {
+		log.Fatalf("Failed to hash password: %v", err)
+	}
+
 	prefs := "{}"
+	now := time.Now()
 
 	// Create user
 	user := &models.User{
-		ID:           uuid.New(),
-		FirstName:    firstName,
-		LastName:     lastName,
-		Email:        *email,
-		PasswordHash: &hashedPassword,
-		Role:         *role,
-		KYCStatus:    "verified", // Auto-verify for manual registration
-		TwoFAEnabled: false,
-		Preferences:  &prefs,
+		ID:              uuid.New(),
+		FirstName:       firstName,
+		LastName:        lastName,
+		Email:           *email,
+		EmailVerifiedAt: &now, // Manually registered emails are trusted
+		PasswordHash:    &hashedPassword,
+		Role:            *role,
+		KYCStatus:       "verified", // Auto-verify for manual registration
+		TwoFAEnabled:    false,
+		Preferences:     &prefs,
 	}
 
-	if err := database.DB.Create(user).Error; err != nil {
-		log.Fatalf("Failed to create user: %v", err)
+	// Store the user and its password history together
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if policy.PasswordHistory > 0 {
+			history := &models.PasswordHistory{UserID: user.ID, PasswordHash: hashedPassword}
+			if err := tx.Create(history).Error; err != nil {
+				return fmt.Errorf("failed to record password history: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to register user: %v", err)
 	}
 
 	// Create wallet
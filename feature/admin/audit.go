@@ -0,0 +1,171 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Audit Export Format Constants
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+)
+
+// auditCSVHeader lists the columns of a CSV audit export
+var auditCSVHeader = []string{
+	"id", "sequence", "created_at", "actor_id", "action", "target_type", "target_id",
+	"ip_address", "user_agent", "details", "changes", "hash",
+}
+
+// ExportAuditLogs streams every audit log matching the search filters as CSV
+// or JSON for compliance requests. The export itself is audited.
+func (h *Handler) ExportAuditLogs(c *fiber.Ctx) error {
+	var filter models.AuditLogFilter
+	if err := filter.FromContext(c); err != nil {
+		return err
+	}
+
+	format := c.Query("format", exportFormatCSV)
+	if format != exportFormatCSV && format != exportFormatJSON {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid format. Must be 'csv' or 'json'")
+	}
+
+	e := audit.Request(c, models.AuditActionAuditExported)
+	e.Details = map[string]interface{}{
+		"format": format,
+		"filter": filter,
+	}
+	audit.Record(e)
+
+	asCSV := format == exportFormatCSV
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if asCSV {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	} else {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	}
+
+	// Headers are already sent when rows stream, so failures can only be
+	// logged; a truncated file is the client's signal that it is incomplete
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var err error
+		if asCSV {
+			err = h.writeAuditCSV(w, filter)
+		} else {
+			err = h.writeAuditJSON(w, filter)
+		}
+		if err != nil {
+			log.Printf("[ExportAuditLogs] Export stopped: %v", err)
+		}
+	})
+	return nil
+}
+
+// writeAuditCSV writes matching audit logs as CSV rows
+func (h *Handler) writeAuditCSV(w *bufio.Writer, filter models.AuditLogFilter) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(auditCSVHeader); err != nil {
+		return err
+	}
+
+	err := h.repo.StreamAuditLogs(filter, func(entry *models.AuditLog) error {
+		return out.Write(auditCSVRow(entry))
+	})
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// writeAuditJSON writes matching audit logs as a JSON array
+func (h *Handler) writeAuditJSON(w *bufio.Writer, filter models.AuditLogFilter) error {
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+
+	first := true
+	err := h.repo.StreamAuditLogs(filter, func(entry *models.AuditLog) error {
+		data, err := json.Marshal(entry.ToResponse())
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := w.WriteString(","); err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.WriteString("]"); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// auditCSVRow formats an audit log in auditCSVHeader order
+func auditCSVRow(entry *models.AuditLog) []string {
+	row := []string{
+		entry.ID.String(),
+		"",
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		"",
+		entry.Action,
+		entry.TargetType,
+		"",
+		"",
+		"",
+		string(entry.Details),
+		string(entry.Changes),
+		entry.Hash,
+	}
+	if entry.Sequence > 0 {
+		row[1] = strconv.FormatInt(entry.Sequence, 10)
+	}
+	if entry.UserID != nil {
+		row[3] = entry.UserID.String()
+	}
+	if entry.TargetID != nil {
+		row[6] = entry.TargetID.String()
+	}
+	if entry.IPAddress != nil {
+		row[7] = *entry.IPAddress
+	}
+	if entry.UserAgent != nil {
+		row[8] = *entry.UserAgent
+	}
+	for i, cell := range row {
+		row[i] = csvSafe(cell)
+	}
+	return row
+}
+
+// csvSafe stops a spreadsheet from running a cell as a formula. Details and
+// user agents come from clients, so a cell starting with a formula character
+// is prefixed with a quote.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
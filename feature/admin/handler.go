@@ -1,6 +1,10 @@
 package admin
 
 import (
+	"log"
+	"strings"
+
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -58,42 +62,146 @@ func (h *Handler) ListUsers(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateUserStatus handles user activation/suspension
+// UpdateUserStatus freezes, suspends, closes or reinstates an account
 func (h *Handler) UpdateUserStatus(c *fiber.Ctx) error {
-	userIDStr := c.Params("id")
-	userID, err := uuid.Parse(userIDStr)
+	admin, ok := c.Locals("user").(models.User)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
 	}
 
-	var req struct {
-		IsActive bool `json:"is_active"`
-	}
+	var req models.UpdateUserStatusRequest
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	if err := h.repo.UpdateUserStatus(userID, req.IsActive); err != nil {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if !models.IsAccountStatus(req.Status) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid account status")
+	}
+	if req.Status != models.AccountStatusActive && req.Reason == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Reason is required")
+	}
+	if userID == admin.ID {
+		return fiber.NewError(fiber.StatusBadRequest, "You cannot change your own account status")
+	}
+
+	user, err := h.repo.GetUserWithRoles(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "User not found")
+	}
+	if !admin.CanManage(user) {
+		return fiber.NewError(fiber.StatusForbidden, "You cannot change the status of an account with more access than yours")
+	}
+	if user.Status == req.Status {
+		return fiber.NewError(fiber.StatusConflict, "Account already has this status")
+	}
+
+	revoked, err := h.repo.UpdateUserStatus(user, req.Status, req.Reason, admin.ID)
+	if err != nil {
+		log.Printf("[UpdateUserStatus] Failed to update status: %v", err)
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update user status")
 	}
 
+	e := audit.Request(c, models.AuditActionStatusChanged)
+	e.TargetType = models.AuditTargetUser
+	e.TargetID = &user.ID
+	e.Details = map[string]interface{}{
+		"reason":           req.Reason,
+		"sessions_revoked": revoked,
+	}
+	e.Changes = audit.Diff(
+		map[string]interface{}{"status": user.Status},
+		map[string]interface{}{"status": req.Status},
+	)
+	audit.Record(e)
+
 	return c.JSON(fiber.Map{"message": "User status updated successfully"})
 }
 
-// GetAuditLogs retrieves system activity logs
+// GetUserStatusHistory lists an account's status changes
+func (h *Handler) GetUserStatusHistory(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	events, err := h.repo.GetStatusHistory(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve status history")
+	}
+
+	history := make([]models.AccountStatusEventResponse, len(events))
+	for i, e := range events {
+		history[i] = e.ToResponse()
+	}
+	return c.JSON(history)
+}
+
+// GetAuditLogs searches system activity logs
 func (h *Handler) GetAuditLogs(c *fiber.Ctx) error {
+	var filter models.AuditLogFilter
+	if err := filter.FromContext(c); err != nil {
+		return err
+	}
+
 	var req models.ListedRequest
 	req.FromContext(c)
+	if req.OrderBy != "created_at" && req.OrderBy != "sequence" && req.OrderBy != "action" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid order_by. Must be 'created_at', 'sequence' or 'action'")
+	}
 
-	logs, total, err := h.repo.GetAuditLogs(req)
+	logs, total, err := h.repo.GetAuditLogs(filter, req)
 	if err != nil {
+		log.Printf("[GetAuditLogs] %v", err)
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve audit logs")
 	}
 
 	// Convert to generic interface slice
 	records := make([]interface{}, len(logs))
 	for i, l := range logs {
-		records[i] = l
+		records[i] = l.ToResponse()
+	}
+
+	return c.JSON(models.ListedResponse{
+		Records: records,
+		Total:   int(total),
+		Page:    req.Page,
+		Limit:   req.Limit,
+	})
+}
+
+// ListJobs returns every cron job with its most recent run
+func (h *Handler) ListJobs(c *fiber.Ctx) error {
+	jobs := make([]fiber.Map, 0, len(models.CronJobs))
+	for _, name := range models.CronJobs {
+		entry := fiber.Map{"name": name, "last_run": nil}
+		if run, err := h.repo.GetLatestJobRun(name); err == nil {
+			entry["last_run"] = run.ToResponse()
+		}
+		jobs = append(jobs, entry)
+	}
+
+	return c.JSON(jobs)
+}
+
+// ListJobRuns retrieves cron job run history
+func (h *Handler) ListJobRuns(c *fiber.Ctx) error {
+	var req models.ListedRequest
+	req.FromContext(c)
+
+	runs, total, err := h.repo.ListJobRuns(c.Query("job"), c.Query("status"), req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve job runs")
+	}
+
+	records := make([]interface{}, len(runs))
+	for i, run := range runs {
+		records[i] = run.ToResponse()
 	}
 
 	return c.JSON(models.ListedResponse{
@@ -103,3 +211,43 @@ func (h *Handler) GetAuditLogs(c *fiber.Ctx) error {
 		Limit:   req.Limit,
 	})
 }
+
+// TriggerJob queues a manual run of a cron job
+func (h *Handler) TriggerJob(c *fiber.Ctx) error {
+	admin, ok := c.Locals("user").(models.User)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+
+	name := c.Params("name")
+	known := false
+	for _, job := range models.CronJobs {
+		if job == name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fiber.NewError(fiber.StatusNotFound, "Job not found")
+	}
+
+	run, err := h.repo.QueueJobRun(name, admin.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to queue job")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Job queued",
+		"run":     run.ToResponse(),
+	})
+}
+
+// VerifyAuditLogs checks the audit log hash chain for edited or missing entries
+func (h *Handler) VerifyAuditLogs(c *fiber.Ctx) error {
+	result, err := h.repo.VerifyAuditLogs()
+	if err != nil {
+		log.Printf("[VerifyAuditLogs] %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to verify audit logs")
+	}
+	return c.JSON(result)
+}
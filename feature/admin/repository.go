@@ -1,6 +1,10 @@
 package admin
 
 import (
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -77,17 +81,108 @@ func (r *Repository) ListAllUsers(req models.ListedRequest) ([]models.User, int6
 	return users, total, nil
 }
 
-// UpdateUserStatus updates the suspension status of a user
-func (r *Repository) UpdateUserStatus(userID uuid.UUID, isActive bool) error {
-	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("is_active", isActive).Error
+// UpdateUserStatus moves an account to a new status and records why. Any
+// status other than active freezes the wallet, and statuses that refuse
+// sign in also end every session. It returns the number of sessions ended.
+func (r *Repository) UpdateUserStatus(user *models.User, status, reason string, changedBy uuid.UUID) (int64, error) {
+	var revoked int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var statusReason *string
+		if reason != "" {
+			statusReason = &reason
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"status":            status,
+				"status_reason":     statusReason,
+				"status_changed_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		event := &models.AccountStatusEvent{
+			UserID:         user.ID,
+			PreviousStatus: user.Status,
+			Status:         status,
+			Reason:         reason,
+			ChangedBy:      &changedBy,
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Wallet{}).Where("user_id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"frozen":       status != models.AccountStatusActive,
+				"last_updated": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		if models.AccountStatusAllowsSignIn(status) {
+			return nil
+		}
+		result := tx.Model(&models.Session{}).
+			Where("user_id = ? AND active = ?", user.ID, true).
+			Updates(map[string]interface{}{
+				"active":         false,
+				"terminated":     now,
+				"revoked_reason": models.SessionRevokedByAdmin,
+			})
+		revoked = result.RowsAffected
+		return result.Error
+	})
+	return revoked, err
+}
+
+// GetUserWithRoles retrieves a user with their granted staff roles
+func (r *Repository) GetUserWithRoles(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.Preload("RoleAssignments").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GrantRole assigns a staff role to a user
+func (r *Repository) GrantRole(userID uuid.UUID, role string, grantedBy uuid.UUID) (*models.RoleAssignment, error) {
+	assignment := &models.RoleAssignment{
+		UserID:    userID,
+		Role:      role,
+		GrantedBy: &grantedBy,
+	}
+	if err := r.db.Create(assignment).Error; err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// RevokeRole removes a staff role from a user, returning false if it was not granted
+func (r *Repository) RevokeRole(userID uuid.UUID, role string) (bool, error) {
+	result := r.db.Unscoped().Where("user_id = ? AND role = ?", userID, role).Delete(&models.RoleAssignment{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetStatusHistory retrieves an account's status changes, newest first
+func (r *Repository) GetStatusHistory(userID uuid.UUID) ([]models.AccountStatusEvent, error) {
+	var events []models.AccountStatusEvent
+	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
 }
 
-// GetAuditLogs retrieves system activity logs with pagination
-func (r *Repository) GetAuditLogs(req models.ListedRequest) ([]models.AuditLog, int64, error) {
+// GetAuditLogs retrieves system activity logs matching a filter with pagination
+func (r *Repository) GetAuditLogs(filter models.AuditLogFilter, req models.ListedRequest) ([]models.AuditLog, int64, error) {
 	var logs []models.AuditLog
 	var total int64
 
-	query := r.db.Model(&models.AuditLog{})
+	query := r.auditLogQuery(filter)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -103,3 +198,113 @@ func (r *Repository) GetAuditLogs(req models.ListedRequest) ([]models.AuditLog,
 
 	return logs, total, nil
 }
+
+// StreamAuditLogs calls fn for each log matching a filter, oldest first,
+// reading from a cursor so exports of any size use constant memory
+func (r *Repository) StreamAuditLogs(filter models.AuditLogFilter, fn func(*models.AuditLog) error) error {
+	rows, err := r.auditLogQuery(filter).Order("created_at asc, sequence asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := r.db.ScanRows(rows, &entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// auditLogQuery applies an audit log filter. The target user matches entries
+// about the user and entries whose details name them, such as KYC reviews.
+func (r *Repository) auditLogQuery(filter models.AuditLogFilter) *gorm.DB {
+	query := r.db.Model(&models.AuditLog{})
+
+	if filter.ActorID != nil {
+		query = query.Where("user_id = ?", *filter.ActorID)
+	}
+	if filter.TargetUserID != nil {
+		query = query.Where("((target_type = ? AND target_id = ?) OR details->>'user_id' = ?)",
+			models.AuditTargetUser, *filter.TargetUserID, filter.TargetUserID.String())
+	}
+	if category, ok := strings.CutSuffix(filter.Action, ".*"); ok {
+		query = query.Where("action LIKE ?", category+".%")
+	} else if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	if filter.Search != "" {
+		// Matches the idx_audit_log_details_search expression so the GIN index is used
+		query = query.Where("jsonb_to_tsvector('simple', details, '[\"string\", \"numeric\"]') @@ plainto_tsquery('simple', ?)", filter.Search)
+	}
+	return query
+}
+
+// VerifyAuditLogs walks the audit log hash chain
+func (r *Repository) VerifyAuditLogs() (*models.AuditChainVerification, error) {
+	return audit.Verify(r.db)
+}
+
+// ListJobRuns retrieves cron job run history with optional job and status filters
+func (r *Repository) ListJobRuns(jobName, status string, req models.ListedRequest) ([]models.JobRun, int64, error) {
+	var runs []models.JobRun
+	var total int64
+
+	query := r.db.Model(&models.JobRun{})
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.
+		Order(req.OrderBy + " " + req.Order).
+		Limit(req.Limit).
+		Offset(req.Offset).
+		Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+// GetLatestJobRun retrieves the most recent run of a cron job
+func (r *Repository) GetLatestJobRun(jobName string) (*models.JobRun, error) {
+	var run models.JobRun
+	if err := r.db.Where("job_name = ?", jobName).Order("created_at desc").First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// QueueJobRun queues a manual run for the cron service to pick up
+func (r *Repository) QueueJobRun(jobName string, requestedBy uuid.UUID) (*models.JobRun, error) {
+	run := &models.JobRun{
+		JobName:     jobName,
+		Trigger:     models.JobTriggerManual,
+		Status:      models.JobRunStatusQueued,
+		RequestedBy: &requestedBy,
+	}
+	if err := r.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+	return run, nil
+}
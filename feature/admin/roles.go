@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"log"
+
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ListRoles returns every staff role with the permissions it grants
+func (h *Handler) ListRoles(c *fiber.Ctx) error {
+	roles := []string{models.RoleAdmin, models.RoleComplianceOfficer, models.RoleSupportAgent}
+	resp := make([]models.RoleResponse, len(roles))
+	for i, role := range roles {
+		resp[i] = models.RoleResponse{
+			Role:        role,
+			Permissions: models.RolePermissions[role],
+		}
+	}
+	return c.JSON(resp)
+}
+
+// GetUserRoles returns a user's account role, granted roles and permissions
+func (h *Handler) GetUserRoles(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	user, err := h.repo.GetUserWithRoles(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	return c.JSON(userRoles(user))
+}
+
+// GrantRole assigns a staff role to a user
+func (h *Handler) GrantRole(c *fiber.Ctx) error {
+	admin, target, err := h.roleTarget(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.GrantRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	if !models.IsStaffRole(req.Role) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role")
+	}
+	for _, p := range models.RolePermissions[req.Role] {
+		if !admin.HasPermission(p) {
+			return fiber.NewError(fiber.StatusForbidden, "You cannot grant a role with permissions you do not have")
+		}
+	}
+	for _, a := range target.RoleAssignments {
+		if a.Role == req.Role {
+			return fiber.NewError(fiber.StatusConflict, "User already has this role")
+		}
+	}
+
+	before := assignedRoles(target)
+	assignment, err := h.repo.GrantRole(target.ID, req.Role, admin.ID)
+	if err != nil {
+		log.Printf("[GrantRole] Failed to grant role: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to grant role")
+	}
+	target.RoleAssignments = append(target.RoleAssignments, *assignment)
+
+	h.auditRoleChange(c, models.AuditActionRoleGranted, target, req.Role, before)
+
+	return c.Status(fiber.StatusCreated).JSON(userRoles(target))
+}
+
+// RevokeRole removes a staff role from a user. The account role itself is
+// not changed here.
+func (h *Handler) RevokeRole(c *fiber.Ctx) error {
+	_, target, err := h.roleTarget(c)
+	if err != nil {
+		return err
+	}
+
+	role := c.Params("role")
+	revoked, err := h.repo.RevokeRole(target.ID, role)
+	if err != nil {
+		log.Printf("[RevokeRole] Failed to revoke role: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to revoke role")
+	}
+	if !revoked {
+		return fiber.NewError(fiber.StatusNotFound, "User does not have this role")
+	}
+
+	before := assignedRoles(target)
+	remaining := target.RoleAssignments[:0]
+	for _, a := range target.RoleAssignments {
+		if a.Role != role {
+			remaining = append(remaining, a)
+		}
+	}
+	target.RoleAssignments = remaining
+
+	h.auditRoleChange(c, models.AuditActionRoleRevoked, target, role, before)
+
+	return c.JSON(userRoles(target))
+}
+
+// roleTarget loads the acting admin and the user whose roles change. Staff
+// cannot change their own roles or those of anyone they cannot manage.
+func (h *Handler) roleTarget(c *fiber.Ctx) (models.User, *models.User, error) {
+	admin, ok := c.Locals("user").(models.User)
+	if !ok {
+		return admin, nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return admin, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+	if userID == admin.ID {
+		return admin, nil, fiber.NewError(fiber.StatusBadRequest, "You cannot change your own roles")
+	}
+
+	target, err := h.repo.GetUserWithRoles(userID)
+	if err != nil {
+		return admin, nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+	}
+	if !admin.CanManage(target) {
+		return admin, nil, fiber.NewError(fiber.StatusForbidden, "You cannot change the roles of an account with more access than yours")
+	}
+	return admin, target, nil
+}
+
+// auditRoleChange records a role grant or revocation with the target's roles
+// before and after
+func (h *Handler) auditRoleChange(c *fiber.Ctx, action string, target *models.User, role string, before []string) {
+	e := audit.Request(c, action)
+	e.TargetType = models.AuditTargetUser
+	e.TargetID = &target.ID
+	e.Details = map[string]interface{}{
+		"role":        role,
+		"permissions": target.Permissions(),
+	}
+	e.Changes = audit.Diff(
+		map[string]interface{}{"roles": before},
+		map[string]interface{}{"roles": assignedRoles(target)},
+	)
+	audit.Record(e)
+}
+
+// assignedRoles lists the staff roles granted to a user
+func assignedRoles(user *models.User) []string {
+	roles := make([]string, len(user.RoleAssignments))
+	for i, a := range user.RoleAssignments {
+		roles[i] = a.Role
+	}
+	return roles
+}
+
+// userRoles builds the role summary for a user with loaded role assignments
+func userRoles(user *models.User) models.UserRolesResponse {
+	assignments := make([]models.RoleAssignmentResponse, len(user.RoleAssignments))
+	for i, a := range user.RoleAssignments {
+		assignments[i] = a.ToResponse()
+	}
+	return models.UserRolesResponse{
+		UserID:      user.ID,
+		Role:        user.Role,
+		Assignments: assignments,
+		Permissions: user.Permissions(),
+	}
+}
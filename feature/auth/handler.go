@@ -1,40 +1,32 @@
 package auth
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
-	"google.golang.org/api/idtoken"
 	"gorm.io/gorm"
 )
 
 // Handler handles authentication HTTP requests
 type Handler struct {
-	repo          *Repository
-	accessExpiry  int
-	refreshExpiry int
-	forgotExpiry  int
+	repo   *Repository
+	policy *security.Policy
 }
 
 // NewHandler creates a new auth handler
-func NewHandler(db *gorm.DB) *Handler {
-	// Get token expiry times from environment (with defaults)
-	accessExpiry := 900     // 15 minutes
-	refreshExpiry := 604800 // 7 days
-	forgotExpiry := 172800  // 48 hours
-
+func NewHandler(db *gorm.DB, policy *security.Policy) *Handler {
 	return &Handler{
-		repo:          NewRepository(db),
-		accessExpiry:  accessExpiry,
-		refreshExpiry: refreshExpiry,
-		forgotExpiry:  forgotExpiry,
+		repo:   NewRepository(db),
+		policy: policy,
 	}
 }
 
@@ -54,6 +46,11 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// Enforce the password policy
+	if err := h.validateNewPassword(&models.User{Email: req.Email, FirstName: req.FirstName, LastName: req.LastName}, req.Password); err != nil {
+		return h.passwordRejected(c, "Register", err)
+	}
+
 	// Check if user already exists
 	if _, err := h.repo.GetUserByEmail(req.Email); err == nil {
 		return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
@@ -62,7 +59,7 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 	}
 
 	// Create user
-	user, err := h.repo.CreateUser(req)
+	user, err := h.repo.CreateUser(req, h.policy.PasswordHistory)
 	if err != nil {
 		log.Printf("[Register] Failed to create user: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
@@ -70,21 +67,9 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate forgot password token for email verification/password setup
-	forgotToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.forgotExpiry, models.JWTForgot)
-	if err != nil {
-		log.Printf("[Register] Failed to generate forgot token: %v", err)
-	} else {
-		setupLink := fmt.Sprintf("%s/auth/reset-password?token=%s", os.Getenv("FRONTEND_URL"), forgotToken)
-
-		// Publish welcome email message
-		msg := models.Message{
-			From:    os.Getenv("MSG_FROM"),
-			To:      []string{user.Email},
-			Subject: "Welcome to LevPay - Verify your account",
-			Body:    fmt.Sprintf("Welcome %s,\n\nPlease verify your account by setting your password using the link below:\n\n%s\n\nIf you did not create this account, please ignore this email.", user.FirstName, setupLink),
-		}
-		rabbitmq.RMQ.Publish(msg)
+	// Send email verification link
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Printf("[Register] Failed to send verification email: %v", err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(models.InfoResponse{
@@ -101,24 +86,55 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate credentials
-	user, err := h.repo.ValidateCredentials(req.Email, req.Password)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
-			Message: "Invalid email or password",
-		})
+	attemptKey := strings.ToLower(strings.TrimSpace(req.Email))
+	ip := c.IP()
+
+	// Slow down or refuse guessing from this email or IP
+	recentFailures, throttled, err := h.throttleLogin(c, attemptKey, ip)
+	if throttled {
+		return err
 	}
 
-	// Generate tokens
-	accessToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.accessExpiry, models.JWTAccess)
+	// Validate credentials. Unknown emails are answered exactly like wrong
+	// passwords, down to the bcrypt time and the lockout.
+	user, err := h.repo.GetUserByEmail(req.Email)
 	if err != nil {
-		log.Printf("[Login] Failed to generate access token: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
-			Message: "Failed to generate token",
-		})
+		user = nil
+	}
+	if until, locked := h.lockedUntil(user, attemptKey); locked {
+		return h.lockedLogin(c, user, attemptKey, ip, until)
+	}
+	if user == nil || user.PasswordHash == nil {
+		utils.PWD.CheckDummyPassword(req.Password)
+		return h.failedLogin(c, user, attemptKey, ip, recentFailures)
+	}
+	if !utils.PWD.CheckPasswordHash(req.Password, *user.PasswordHash) {
+		return h.failedLogin(c, user, attemptKey, ip, recentFailures)
+	}
+
+	if !user.CanSignIn() {
+		h.clearLoginFailures(user, attemptKey)
+		return h.disabledLogin(c, user)
+	}
+
+	// Require the second factor before issuing a session. Failures are only
+	// cleared once it checks out, so wrong codes count towards the lockout.
+	if user.TwoFAEnabled {
+		return h.twoFactorChallenge(c, user)
 	}
 
-	refreshToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.refreshExpiry, models.JWTRefresh)
+	h.clearLoginFailures(user, attemptKey)
+	return h.completeLogin(c, user, req.Fingerprint)
+}
+
+// completeLogin issues tokens and a session for an authenticated user
+func (h *Handler) completeLogin(c *fiber.Ctx, user *models.User, fingerprint string) error {
+	if !user.CanSignIn() {
+		return h.disabledLogin(c, user)
+	}
+
+	// Generate refresh token
+	refreshToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.policy.RefreshExpiry, models.JWTRefresh)
 	if err != nil {
 		log.Printf("[Login] Failed to generate refresh token: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
@@ -127,18 +143,32 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 	}
 
 	// Create session
-	_, err = h.repo.CreateSession(user.ID, refreshToken, req.Fingerprint)
-	if err != nil {
+	session := newSession(c, user.ID, fingerprint)
+	if err := h.repo.CreateSession(session, refreshToken); err != nil {
 		log.Printf("[Login] Failed to create session: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
 			Message: "Failed to create session",
 		})
 	}
 
+	// Generate access token bound to the session
+	accessToken, err := utils.JWT.GenerateSessionToken(user.ID, user.Role, session.FamilyID, h.policy.AccessExpiry, models.JWTAccess)
+	if err != nil {
+		log.Printf("[Login] Failed to generate access token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to generate token",
+		})
+	}
+
 	// Update last login time
 	now := time.Now()
 	user.UpdatedAt = now
 
+	h.audit(c, &user.ID, models.AuditActionLogin, map[string]interface{}{
+		"session_id":  session.ID,
+		"device_name": session.DeviceName,
+	})
+
 	return c.JSON(models.LoggedInUserResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -164,13 +194,23 @@ func (h *Handler) Refresh(c *fiber.Ctx) error {
 	}
 
 	// Check session exists and is active
-	session, err := h.repo.GetSessionByToken(req.RefreshToken)
+	session, err := h.repo.FindSessionByToken(req.RefreshToken)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
 			Message: "Invalid session",
 		})
 	}
 
+	// A rotated token being replayed means it was stolen: revoke the whole family
+	if !session.Active {
+		if session.RotatedAt != nil {
+			h.revokeReusedFamily(c, session)
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid session",
+		})
+	}
+
 	// Verify fingerprint matches
 	if session.Fingerprint != req.Fingerprint {
 		log.Printf("[Refresh] Fingerprint mismatch for user %s", decodedToken.UserID)
@@ -186,32 +226,30 @@ func (h *Handler) Refresh(c *fiber.Ctx) error {
 			Message: "User not found",
 		})
 	}
+	if !user.CanSignIn() {
+		return h.disabledLogin(c, user)
+	}
 
 	// Generate new tokens (token rotation)
-	newAccessToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.accessExpiry, models.JWTAccess)
+	newAccessToken, err := utils.JWT.GenerateSessionToken(user.ID, user.Role, session.FamilyID, h.policy.AccessExpiry, models.JWTAccess)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
 			Message: "Failed to generate token",
 		})
 	}
 
-	newRefreshToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.refreshExpiry, models.JWTRefresh)
+	newRefreshToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.policy.RefreshExpiry, models.JWTRefresh)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
 			Message: "Failed to generate token",
 		})
 	}
 
-	// Terminate old session
-	if err := h.repo.TerminateSession(session.ID); err != nil {
-		log.Printf("[Refresh] Failed to terminate old session: %v", err)
-	}
-
-	// Create new session
-	_, err = h.repo.CreateSession(user.ID, newRefreshToken, req.Fingerprint)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
-			Message: "Failed to create session",
+	// Replace the old session with a new one in the same family
+	if err := h.repo.RotateSession(session, newRefreshToken, newSession(c, user.ID, req.Fingerprint)); err != nil {
+		log.Printf("[Refresh] Failed to rotate session: %v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid session",
 		})
 	}
 
@@ -253,6 +291,10 @@ func (h *Handler) Logout(c *fiber.Ctx) error {
 		log.Printf("[Logout] Failed to terminate session: %v", err)
 	}
 
+	h.audit(c, &session.UserID, models.AuditActionLogout, map[string]interface{}{
+		"session_id": session.ID,
+	})
+
 	return c.JSON(models.InfoResponse{
 		Message: "Logged out successfully",
 	})
@@ -277,7 +319,7 @@ func (h *Handler) ForgotPassword(c *fiber.Ctx) error {
 	}
 
 	// Generate forgot password token
-	forgotToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.forgotExpiry, models.JWTForgot)
+	forgotToken, err := utils.JWT.GenerateToken(user.ID, user.Role, h.policy.ForgotExpiry, models.JWTForgot)
 	if err != nil {
 		log.Printf("[ForgotPassword] Failed to generate forgot token: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
@@ -285,6 +327,14 @@ func (h *Handler) ForgotPassword(c *fiber.Ctx) error {
 		})
 	}
 
+	// Store the token so it can only be used once
+	if err := h.repo.CreateResetToken(user, forgotToken, time.Now().Add(time.Duration(h.policy.ForgotExpiry)*time.Second)); err != nil {
+		log.Printf("[ForgotPassword] Failed to store reset token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to process request",
+		})
+	}
+
 	// Send password reset email
 	resetLink := fmt.Sprintf("%s/auth/reset-password?token=%s", os.Getenv("FRONTEND_URL"), forgotToken)
 
@@ -293,7 +343,7 @@ func (h *Handler) ForgotPassword(c *fiber.Ctx) error {
 		From:    os.Getenv("MSG_FROM"),
 		To:      []string{user.Email},
 		Subject: "Reset your LevPay password",
-		Body:    fmt.Sprintf("Hello %s,\n\nYou requested a password reset. Click the link below to reset your password:\n\n%s\n\nLink expires in %d minutes.\n\nIf you did not request this, please ignore this email.", user.FirstName, resetLink, h.forgotExpiry/60),
+		Body:    fmt.Sprintf("Hello %s,\n\nYou requested a password reset. Click the link below to reset your password:\n\n%s\n\nLink expires in %d minutes.\n\nIf you did not request this, please ignore this email.", user.FirstName, resetLink, h.policy.ForgotExpiry/60),
 	}
 	rabbitmq.RMQ.Publish(msg)
 
@@ -334,8 +384,33 @@ func (h *Handler) ResetPassword(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update password
-	if err := h.repo.UpdatePassword(decodedToken.UserID, req.NewPassword); err != nil {
+	// The token must be unused and issued for the current password
+	reset, err := h.repo.FindResetToken(token)
+	if err != nil || reset.UserID != decodedToken.UserID || reset.UsedAt != nil || reset.RevokedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired reset token",
+		})
+	}
+
+	user, err := h.repo.GetUserByID(decodedToken.UserID)
+	if err != nil || user.PasswordVersion != reset.PasswordVersion {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired reset token",
+		})
+	}
+
+	// Enforce the password policy and history
+	if err := h.validateNewPassword(user, req.NewPassword); err != nil {
+		return h.passwordRejected(c, "ResetPassword", err)
+	}
+
+	// Consume the token and update the password together
+	if err := h.repo.ResetPassword(reset, req.NewPassword, h.policy.PasswordHistory); err != nil {
+		if errors.Is(err, errResetTokenInvalid) {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+				Message: "Invalid or expired reset token",
+			})
+		}
 		log.Printf("[ResetPassword] Failed to update password: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
 			Message: "Failed to reset password",
@@ -347,6 +422,13 @@ func (h *Handler) ResetPassword(c *fiber.Ctx) error {
 		log.Printf("[ResetPassword] Failed to terminate sessions: %v", err)
 	}
 
+	// A reset proves ownership, so lift any brute-force lockout
+	if err := h.repo.ResetLoginFailures(decodedToken.UserID); err != nil {
+		log.Printf("[ResetPassword] Failed to reset login failures: %v", err)
+	}
+
+	h.auditPasswordChange(c, user, models.AuditActionPasswordReset)
+
 	return c.JSON(models.InfoResponse{
 		Message: "Password reset successfully",
 	})
@@ -388,8 +470,13 @@ func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
+	// Enforce the password policy and history
+	if err := h.validateNewPassword(&user, req.NewPassword); err != nil {
+		return h.passwordRejected(c, "ChangePassword", err)
+	}
+
 	// Update password
-	if err := h.repo.UpdatePassword(user.ID, req.NewPassword); err != nil {
+	if err := h.repo.UpdatePassword(user.ID, req.NewPassword, h.policy.PasswordHistory); err != nil {
 		log.Printf("[ChangePassword] Failed to update password: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
 			Message: "Failed to change password",
@@ -401,18 +488,10 @@ func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 		log.Printf("[ChangePassword] Failed to terminate sessions: %v", err)
 	}
 
-	// Generate new tokens for current session
-	newAccessToken, _ := utils.JWT.GenerateToken(user.ID, user.Role, h.accessExpiry, models.JWTAccess)
-	newRefreshToken, _ := utils.JWT.GenerateToken(user.ID, user.Role, h.refreshExpiry, models.JWTRefresh)
-
-	// Create new session
-	h.repo.CreateSession(user.ID, newRefreshToken, req.Fingerprint)
+	h.auditPasswordChange(c, &user, models.AuditActionPasswordChanged)
 
-	return c.JSON(models.LoggedInUserResponse{
-		AccessToken:  newAccessToken,
-		RefreshToken: newRefreshToken,
-		User:         user.PrepareResponse(),
-	})
+	// Start a new session for the current device
+	return h.completeLogin(c, &user, req.Fingerprint)
 }
 
 // GetMe returns current authenticated user
@@ -424,88 +503,13 @@ func (h *Handler) GetMe(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(user.PrepareResponse())
-}
-
-// GoogleAuth handles Google OAuth login
-func (h *Handler) GoogleAuth(c *fiber.Ctx) error {
-	var req struct {
-		Token string `json:"token"`
-	}
-
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
-			Message: "Invalid request body",
-		})
-	}
-
-	if req.Token == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
-			Message: "Token is required",
-		})
-	}
-
-	// Validate Google ID Token using library
-	// clientID := os.Getenv("GOOGLE_CLIENT_ID") // Optional: validate audience
-	payload, err := idtoken.Validate(context.Background(), req.Token, "")
-	if err != nil {
-		log.Printf("[GoogleAuth] Invalid token: %v", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
-			Message: "Invalid Google token",
-		})
-	}
+	resp := user.PrepareResponse()
 
-	// Extract user info
-	email := payload.Claims["email"].(string)
-	googleID := payload.Subject
-	firstName := payload.Claims["given_name"].(string)
-	lastName := payload.Claims["family_name"].(string)
-	// picture := payload.Claims["picture"].(string) // Optional
-
-	// Check if user exists by Google ID
-	user, err := h.repo.GetUserByGoogleID(googleID)
-	if err != nil {
-		// Not found by Google ID, check by email
-		user, err = h.repo.GetUserByEmail(email)
-		if err == nil {
-			// User exists with email, link account
-			// We need to update user with Google ID
-			// TODO: Add UpdateGoogleID method to repo, for now using direct update if possible or ignore
-			// Actually we should link it.
-			// Since I can't easily add UpdateGoogleID right now without another tool call, I'll assume we proceed.
-			// Wait, I should link it. I'll add UpdateGoogleID logic here if I can, or use Gorm directly if I had access.
-			// But I only have Repo access.
-			// Let's assume for now we just log them in, but linking is better.
-			// If I don't link, next time they login with Google it won't find by GoogleID and will find by email again.
-			// Optimization: Add LinkGoogleAccount to Repo?
-			// For simplicity in this turn, I'll just skip linking field update or do it if I have time.
-			// I'll leave a TODO or assume it's fine for now.
-			// Actually, if I created `CreateOAuthUser` in repo, I can probably add `LinkGoogleAccount` easily.
-			// But for now let's just use found user.
-		} else {
-			// User doesn't exist, create new one
-			user, err = h.repo.CreateOAuthUser(email, firstName, lastName, googleID)
-			if err != nil {
-				log.Printf("[GoogleAuth] Failed to create user: %v", err)
-				return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
-					Message: "Failed to create user",
-				})
-			}
-		}
+	// Flag the response so clients can show an impersonation banner
+	if decodedToken, ok := c.Locals("decoded_token").(models.DecodedToken); ok && decodedToken.IsImpersonation() {
+		resp.Impersonated = true
+		resp.ImpersonatedBy = &decodedToken.ImpersonatorID
 	}
 
-	// Generate tokens
-	accessToken, _ := utils.JWT.GenerateToken(user.ID, user.Role, h.accessExpiry, models.JWTAccess)
-	refreshToken, _ := utils.JWT.GenerateToken(user.ID, user.Role, h.refreshExpiry, models.JWTRefresh)
-
-	// Create session (fingerprint? optional or from header)
-	// We don't have fingerprint in this req struct, maybe header?
-	fingerprint := c.Get("X-Fingerprint", "unknown")
-	h.repo.CreateSession(user.ID, refreshToken, fingerprint)
-
-	return c.JSON(models.LoggedInUserResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         user.PrepareResponse(),
-	})
+	return c.JSON(resp)
 }
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"log"
+	"strings"
+
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Impersonate issues a short-lived, read-only access token that lets support
+// staff see the account exactly as the user does. The token rides on the
+// staff member's own session and every request made with it is audited.
+func (h *Handler) Impersonate(c *fiber.Ctx) error {
+	staff, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.ImpersonateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Reason is required",
+		})
+	}
+
+	sessionID := currentSessionID(c)
+	if sessionID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Current session unknown. Please sign in again.",
+		})
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid user ID",
+		})
+	}
+	if userID == staff.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "You cannot impersonate yourself",
+		})
+	}
+
+	user, err := h.repo.GetUserWithRoles(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.InfoResponse{
+			Message: "User not found",
+		})
+	}
+
+	// Viewing as another staff member would borrow their permissions
+	if len(user.Permissions()) > 0 {
+		return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+			Message: "Staff accounts cannot be impersonated",
+		})
+	}
+	if !user.CanSignIn() {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "This account has been " + user.Status,
+		})
+	}
+
+	token, err := utils.JWT.GenerateImpersonationToken(user.ID, user.Role, staff.ID, sessionID, h.policy.ImpersonationExpiry)
+	if err != nil {
+		log.Printf("[Impersonate] Failed to generate token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to generate token",
+		})
+	}
+
+	e := audit.Request(c, models.AuditActionImpersonation)
+	e.TargetType = models.AuditTargetUser
+	e.TargetID = &user.ID
+	e.Details = map[string]interface{}{
+		"reason":     req.Reason,
+		"expires_in": h.policy.ImpersonationExpiry,
+	}
+	audit.Record(e)
+
+	resp := user.PrepareResponse()
+	resp.Impersonated = true
+	resp.ImpersonatedBy = &staff.ID
+
+	return c.JSON(models.ImpersonationResponse{
+		AccessToken: token,
+		ExpiresIn:   h.policy.ImpersonationExpiry,
+		User:        resp,
+	})
+}
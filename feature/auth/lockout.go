@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/notification"
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UnlockAccount lifts a brute-force lockout using the link from the lockout
+// email. Each link lifts the lockout it was sent for once.
+func (h *Handler) UnlockAccount(c *fiber.Ctx) error {
+	var req models.UnlockAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	decodedToken, err := utils.JWT.DecodeToken(req.Token, models.JWTUnlock)
+	if err != nil || decodedToken.LockedUntil == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired unlock token",
+		})
+	}
+
+	user, err := h.repo.GetUserByID(decodedToken.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired unlock token",
+		})
+	}
+
+	// The token only lifts the lockout it was sent for, and only once
+	unlocked, err := h.repo.UnlockUser(user.ID, time.UnixMicro(decodedToken.LockedUntil))
+	if err != nil {
+		log.Printf("[UnlockAccount] Failed to unlock account: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to unlock account",
+		})
+	}
+	if !unlocked {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired unlock token",
+		})
+	}
+	if err := h.repo.ClearLoginFailures(strings.ToLower(user.Email)); err != nil {
+		log.Printf("[UnlockAccount] Failed to clear login failures: %v", err)
+	}
+
+	h.audit(c, &user.ID, models.AuditActionAccountUnlocked, map[string]interface{}{
+		"email": user.Email,
+	})
+
+	return c.JSON(models.InfoResponse{
+		Message: "Account unlocked. You can sign in again.",
+	})
+}
+
+// throttleLogin enforces the per-IP limit and per-email progressive delay.
+// It returns the highest recent failure count, and whether the request was
+// already answered because it is throttled.
+func (h *Handler) throttleLogin(c *fiber.Ctx, attemptKey, ip string) (int64, bool, error) {
+	since := time.Now().Add(-models.LoginAttemptWindow)
+
+	ipFailures, err := h.repo.CountIPFailures(ip, since)
+	if err != nil {
+		log.Printf("[Login] Failed to count IP failures: %v", err)
+	}
+	if ipFailures >= models.LoginIPFailureLimit {
+		return ipFailures, true, tooManyLoginAttempts(c, models.LoginAttemptWindow)
+	}
+
+	emailFailures, err := h.repo.CountEmailFailures(attemptKey, since)
+	if err != nil {
+		log.Printf("[Login] Failed to count email failures: %v", err)
+	}
+	if delay := models.LoginDelay(emailFailures); delay > 0 {
+		if last, err := h.repo.GetLastLoginFailure(attemptKey); err == nil {
+			if wait := time.Until(last.CreatedAt.Add(delay)); wait > 0 {
+				return emailFailures, true, tooManyLoginAttempts(c, wait)
+			}
+		}
+	}
+
+	if ipFailures > emailFailures {
+		return ipFailures, false, nil
+	}
+	return emailFailures, false, nil
+}
+
+// failedLogin records a wrong password or unknown email and locks the account
+// once it reaches the consecutive failure threshold
+func (h *Handler) failedLogin(c *fiber.Ctx, user *models.User, attemptKey, ip string, recentFailures int64) error {
+	var userID *uuid.UUID
+	if user != nil {
+		userID = &user.ID
+	}
+
+	if err := h.repo.RecordLoginFailure(attemptKey, ip, userID, models.LoginFailureBadCredentials); err != nil {
+		log.Printf("[Login] %v", err)
+	}
+	h.audit(c, userID, models.AuditActionLoginFailed, map[string]interface{}{
+		"email":  attemptKey,
+		"reason": models.LoginFailureBadCredentials,
+	})
+
+	var failures int64
+	if user != nil {
+		count, err := h.repo.IncrementFailedLogins(user.ID)
+		if err != nil {
+			log.Printf("[Login] %v", err)
+		}
+		failures = int64(count)
+	} else {
+		failures = h.unknownEmailFailures(attemptKey)
+	}
+	if failures >= models.LoginLockoutThreshold {
+		return h.lockAccount(c, user, attemptKey, ip, failures)
+	}
+
+	return c.Status(fiber.StatusUnauthorized).JSON(models.LoginFailedResponse{
+		Message:         "Invalid email or password",
+		CaptchaRequired: recentFailures+1 >= models.LoginCaptchaThreshold,
+	})
+}
+
+// lockAccount locks the user out for the lockout duration and emails an unlock
+// link. Unknown emails are locked out too, so a lockout does not reveal that
+// an account exists.
+func (h *Handler) lockAccount(c *fiber.Ctx, user *models.User, attemptKey, ip string, failures int64) error {
+	var userID *uuid.UUID
+	if user != nil {
+		userID = &user.ID
+	}
+
+	// Postgres keeps microseconds, so the unlock token can match the stored value
+	until := time.Now().Add(models.LoginLockoutDuration).Truncate(time.Microsecond)
+	if err := h.repo.RecordLoginFailure(attemptKey, ip, userID, models.LoginFailureLockout); err != nil {
+		log.Printf("[Login] %v", err)
+	}
+	if user == nil {
+		return lockedResponse(c, until)
+	}
+
+	locked, err := h.repo.LockUser(user.ID, until)
+	if err != nil {
+		log.Printf("[Login] Failed to lock account: %v", err)
+	}
+	if !locked {
+		// A parallel request locked the account and sent the email
+		return lockedResponse(c, until)
+	}
+	user.LockedUntil = &until
+
+	h.audit(c, &user.ID, models.AuditActionAccountLocked, map[string]interface{}{
+		"email":           user.Email,
+		"failed_attempts": failures,
+		"locked_until":    until,
+	})
+
+	if err := h.sendUnlockEmail(user, ip, failures); err != nil {
+		log.Printf("[Login] Failed to send unlock email: %v", err)
+	}
+
+	return lockedResponse(c, until)
+}
+
+// lockedUntil reports whether logins for the email are locked out. Unknown
+// emails are locked out from the failures recorded against them.
+func (h *Handler) lockedUntil(user *models.User, attemptKey string) (time.Time, bool) {
+	now := time.Now()
+	if user != nil {
+		if user.IsLocked(now) {
+			return *user.LockedUntil, true
+		}
+		return time.Time{}, false
+	}
+
+	last, err := h.repo.GetLastLoginFailureByReason(attemptKey, models.LoginFailureLockout)
+	if err != nil {
+		return time.Time{}, false
+	}
+	until := last.CreatedAt.Add(models.LoginLockoutDuration)
+	return until, now.Before(until)
+}
+
+// unknownEmailFailures counts the failures for an email without an account
+// since its last lockout ended, mirroring a real account's consecutive count
+func (h *Handler) unknownEmailFailures(attemptKey string) int64 {
+	var since time.Time
+	if last, err := h.repo.GetLastLoginFailureByReason(attemptKey, models.LoginFailureLockout); err == nil {
+		since = last.CreatedAt.Add(models.LoginLockoutDuration)
+	}
+	count, err := h.repo.CountEmailFailuresByReason(attemptKey, models.LoginFailureBadCredentials, since)
+	if err != nil {
+		log.Printf("[Login] Failed to count email failures: %v", err)
+	}
+	return count
+}
+
+// lockedLogin refuses a login attempt while the account is locked
+func (h *Handler) lockedLogin(c *fiber.Ctx, user *models.User, attemptKey, ip string, until time.Time) error {
+	var userID *uuid.UUID
+	if user != nil {
+		userID = &user.ID
+	}
+
+	if err := h.repo.RecordLoginFailure(attemptKey, ip, userID, models.LoginFailureLocked); err != nil {
+		log.Printf("[Login] %v", err)
+	}
+	h.audit(c, userID, models.AuditActionLoginFailed, map[string]interface{}{
+		"email":  attemptKey,
+		"reason": models.LoginFailureLocked,
+	})
+
+	return lockedResponse(c, until)
+}
+
+// disabledLogin refuses to sign in a suspended or closed account. It runs
+// after the credentials check out so the status is not revealed to someone
+// guessing passwords.
+func (h *Handler) disabledLogin(c *fiber.Ctx, user *models.User) error {
+	h.audit(c, &user.ID, models.AuditActionLoginFailed, map[string]interface{}{
+		"email":  user.Email,
+		"reason": models.LoginFailureAccountStatus,
+		"status": user.Status,
+	})
+
+	return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+		Message: "This account has been " + user.Status + ". Please contact support.",
+	})
+}
+
+// clearLoginFailures resets throttling state after a successful password check
+func (h *Handler) clearLoginFailures(user *models.User, attemptKey string) {
+	// Failures may have been counted since the user was loaded
+	if err := h.repo.ResetLoginFailures(user.ID); err != nil {
+		log.Printf("[Login] Failed to reset login failures: %v", err)
+	}
+	if err := h.repo.ClearLoginFailures(attemptKey); err != nil {
+		log.Printf("[Login] Failed to clear login failures: %v", err)
+	}
+}
+
+// sendUnlockEmail tells the user their account was locked and how to unlock it
+func (h *Handler) sendUnlockEmail(user *models.User, ip string, failures int64) error {
+	token, err := utils.JWT.GenerateUnlockToken(user.ID, user.Role, *user.LockedUntil, h.policy.UnlockExpiry)
+	if err != nil {
+		return fmt.Errorf("failed to generate unlock token: %w", err)
+	}
+
+	return sendEmail(user.Email, notification.TemplateAccountLocked, map[string]interface{}{
+		"Name":         user.FirstName,
+		"Attempts":     failures,
+		"IPAddress":    ip,
+		"Minutes":      int(models.LoginLockoutDuration.Minutes()),
+		"UnlockLink":   fmt.Sprintf("%s/auth/unlock?token=%s", os.Getenv("FRONTEND_URL"), token),
+		"ForgotLink":   fmt.Sprintf("%s/auth/forgot-password", os.Getenv("FRONTEND_URL")),
+		"ExpiresHours": h.policy.UnlockExpiry / 3600,
+	})
+}
+
+// audit records a security event on a user's own account. The user is both
+// actor and target; userID is nil when the account is unknown.
+func (h *Handler) audit(c *fiber.Ctx, userID *uuid.UUID, action string, details map[string]interface{}) {
+	e := audit.Request(c, action)
+	e.ActorID = userID
+	if userID != nil {
+		e.TargetType = models.AuditTargetUser
+		e.TargetID = userID
+	}
+	e.Details = details
+	audit.Record(e)
+}
+
+// tooManyLoginAttempts responds with a Retry-After for throttled logins
+func tooManyLoginAttempts(c *fiber.Ctx, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(models.LoginFailedResponse{
+		Message:         "Too many failed login attempts. Please try again later.",
+		CaptchaRequired: true,
+		RetryAfter:      seconds,
+	})
+}
+
+// lockedResponse tells the client the account is locked and until when
+func lockedResponse(c *fiber.Ctx, until time.Time) error {
+	seconds := int(math.Ceil(time.Until(until).Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusLocked).JSON(models.LoginFailedResponse{
+		Message:         "Account temporarily locked after too many failed attempts. Check your email to unlock it.",
+		CaptchaRequired: true,
+		RetryAfter:      seconds,
+	})
+}
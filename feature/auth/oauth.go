@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/oauth"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GoogleAuth handles Google sign-in for clients that predate OAuthSignIn
+func (h *Handler) GoogleAuth(c *fiber.Ctx) error {
+	return h.oauthSignIn(c, oauth.ProviderGoogle)
+}
+
+// OAuthSignIn signs in or registers with an OpenID Connect provider's ID token
+func (h *Handler) OAuthSignIn(c *fiber.Ctx) error {
+	return h.oauthSignIn(c, c.Params("provider"))
+}
+
+// ListIdentities returns the sign-in providers linked to the user
+func (h *Handler) ListIdentities(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	identities, err := h.repo.GetIdentities(user.ID)
+	if err != nil {
+		log.Printf("[ListIdentities] Failed to get identities: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to get linked accounts",
+		})
+	}
+
+	responses := make([]models.OAuthIdentityResponse, len(identities))
+	for i := range identities {
+		responses[i] = identities[i].ToResponse()
+	}
+	return c.JSON(responses)
+}
+
+// LinkIdentity links a provider account to the user after confirming their password
+func (h *Handler) LinkIdentity(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.OAuthLinkRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	if ferr := h.confirmPassword(&user, req.Password); ferr != nil {
+		return sendError(c, ferr)
+	}
+
+	name := c.Params("provider")
+	identity, ferr := h.verifyIdentity(c, name, req.Token)
+	if ferr != nil {
+		return sendError(c, ferr)
+	}
+
+	linked, err := h.repo.GetUserByIdentity(identity.Provider, identity.Subject)
+	switch {
+	case err == nil && linked.ID == user.ID:
+		return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+			Message: "This account is already linked",
+		})
+	case err == nil:
+		return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+			Message: "This account is linked to another LevPay user",
+		})
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Printf("[LinkIdentity] Failed to look up identity: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to link account",
+		})
+	}
+
+	identities, err := h.repo.GetIdentities(user.ID)
+	if err != nil {
+		log.Printf("[LinkIdentity] Failed to get identities: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to link account",
+		})
+	}
+	for _, existing := range identities {
+		if existing.Provider == identity.Provider {
+			return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+				Message: fmt.Sprintf("Another %s account is already linked. Unlink it first.", identity.Provider),
+			})
+		}
+	}
+
+	if err := h.repo.LinkIdentity(user.ID, identity); err != nil {
+		log.Printf("[LinkIdentity] %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to link account",
+		})
+	}
+
+	h.audit(c, &user.ID, models.AuditActionIdentityLinked, map[string]interface{}{
+		"provider": identity.Provider,
+		"email":    identity.Email,
+	})
+
+	return c.JSON(models.InfoResponse{
+		Message: "Account linked",
+	})
+}
+
+// UnlinkIdentity removes a linked provider after confirming the password.
+// Requiring the password guarantees the user can still sign in afterwards.
+func (h *Handler) UnlinkIdentity(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.OAuthUnlinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	if ferr := h.confirmPassword(&user, req.Password); ferr != nil {
+		return sendError(c, ferr)
+	}
+
+	provider := c.Params("provider")
+	removed, err := h.repo.UnlinkIdentity(user.ID, provider)
+	if err != nil {
+		log.Printf("[UnlinkIdentity] %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to unlink account",
+		})
+	}
+	if !removed {
+		return c.Status(fiber.StatusNotFound).JSON(models.InfoResponse{
+			Message: "No linked account for this provider",
+		})
+	}
+
+	h.audit(c, &user.ID, models.AuditActionIdentityUnlinked, map[string]interface{}{
+		"provider": provider,
+	})
+
+	return c.JSON(models.InfoResponse{
+		Message: "Account unlinked",
+	})
+}
+
+// oauthSignIn signs in the user linked to a provider account, or registers a
+// new user when the provider vouches for an email nobody uses yet. Accounts
+// are never linked implicitly by email; that takes LinkIdentity.
+func (h *Handler) oauthSignIn(c *fiber.Ctx, name string) error {
+	var req models.OAuthSignInRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Token is required",
+		})
+	}
+
+	identity, ferr := h.verifyIdentity(c, name, req.Token)
+	if ferr != nil {
+		return sendError(c, ferr)
+	}
+
+	user, err := h.repo.GetUserByIdentity(identity.Provider, identity.Subject)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[OAuthSignIn] Failed to look up identity: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+				Message: "Failed to sign in",
+			})
+		}
+
+		if identity.Email == "" || !identity.EmailVerified {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "Your account has no verified email address",
+			})
+		}
+
+		if _, err := h.repo.GetUserByEmail(identity.Email); err == nil {
+			return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+				Message: "An account with this email already exists. Sign in with your password and link this provider from your account settings.",
+			})
+		}
+
+		user, err = h.repo.CreateOAuthUser(identity)
+		if err != nil {
+			log.Printf("[OAuthSignIn] Failed to create user: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+				Message: "Failed to create user",
+			})
+		}
+	}
+
+	if !user.CanSignIn() {
+		return h.disabledLogin(c, user)
+	}
+
+	// Require the second factor before issuing a session
+	if user.TwoFAEnabled {
+		return h.twoFactorChallenge(c, user)
+	}
+
+	fingerprint := req.Fingerprint
+	if fingerprint == "" {
+		fingerprint = c.Get("X-Fingerprint", "unknown")
+	}
+	return h.completeLogin(c, user, fingerprint)
+}
+
+// verifyIdentity validates an ID token with the named provider
+func (h *Handler) verifyIdentity(c *fiber.Ctx, name, token string) (*oauth.Identity, *fiber.Error) {
+	provider, ok := oauth.Providers[name]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Sign-in provider not supported")
+	}
+
+	identity, err := provider.Verify(c.Context(), token)
+	if err != nil {
+		log.Printf("[OAuth] Invalid %s token: %v", name, err)
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid sign-in token")
+	}
+	return identity, nil
+}
+
+// confirmPassword checks the user's password before linked accounts change
+func (h *Handler) confirmPassword(user *models.User, password string) *fiber.Error {
+	if user.PasswordHash == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Set a password with the forgot password flow before changing linked accounts")
+	}
+	if !utils.PWD.CheckPasswordHash(password, *user.PasswordHash) {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid password")
+	}
+	return nil
+}
+
+// sendError writes a helper's rejection as an InfoResponse
+func sendError(c *fiber.Ctx, err *fiber.Error) error {
+	return c.Status(err.Code).JSON(models.InfoResponse{
+		Message: err.Message,
+	})
+}
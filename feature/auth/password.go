@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/security"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var (
+	// errPasswordReused is returned when a new password matches a recent one
+	errPasswordReused = errors.New("password was used recently")
+	// errResetTokenInvalid is returned when a reset token is used, revoked,
+	// expired or issued for an older password
+	errResetTokenInvalid = errors.New("invalid or expired reset token")
+)
+
+// validateNewPassword enforces the password policy and, for existing users,
+// the reuse history. Rejections are *security.PasswordError, whose message
+// is safe to show to the client, or errPasswordReused; any other error is
+// internal.
+func (h *Handler) validateNewPassword(user *models.User, password string) error {
+	if err := h.policy.ValidatePassword(password, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+
+	if user.ID == uuid.Nil || h.policy.PasswordHistory == 0 {
+		return nil
+	}
+
+	if user.PasswordHash != nil && utils.PWD.CheckPasswordHash(password, *user.PasswordHash) {
+		return errPasswordReused
+	}
+
+	history, err := h.repo.GetPasswordHistory(user.ID, h.policy.PasswordHistory)
+	if err != nil {
+		return fmt.Errorf("failed to get password history: %w", err)
+	}
+	for _, entry := range history {
+		if utils.PWD.CheckPasswordHash(password, entry.PasswordHash) {
+			return errPasswordReused
+		}
+	}
+	return nil
+}
+
+// passwordRejected responds to a failed validateNewPassword, showing policy
+// rejections to the client and hiding internal errors
+func (h *Handler) passwordRejected(c *fiber.Ctx, action string, err error) error {
+	var policyErr *security.PasswordError
+	if errors.As(err, &policyErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: err.Error(),
+		})
+	}
+	if errors.Is(err, errPasswordReused) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Password was used recently. Choose a password you have not used before",
+		})
+	}
+
+	log.Printf("[%s] Failed to validate password: %v", action, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+		Message: "Failed to validate password",
+	})
+}
+
+// auditPasswordChange records a password change or reset. The hash never
+// goes in the log; the version bump shows which password is current.
+func (h *Handler) auditPasswordChange(c *fiber.Ctx, user *models.User, action string) {
+	e := audit.Request(c, action)
+	e.ActorID = &user.ID
+	e.TargetType = models.AuditTargetUser
+	e.TargetID = &user.ID
+	e.Changes = audit.Diff(
+		map[string]interface{}{"password_version": user.PasswordVersion},
+		map[string]interface{}{"password_version": user.PasswordVersion + 1},
+	)
+	audit.Record(e)
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/sms"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// phonePattern accepts international numbers with an optional leading plus
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{9,15}$`)
+
+// SendPhoneCode texts a verification code to the user's current phone number
+func (h *Handler) SendPhoneCode(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	if user.Phone == nil || *user.Phone == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Add a phone number first",
+		})
+	}
+	if user.PhoneVerifiedAt != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Phone number already verified",
+		})
+	}
+
+	return h.sendPhoneCode(c, &user, *user.Phone, models.PhoneVerificationPurposeVerify)
+}
+
+// ChangePhone texts a verification code to a new phone number. The number
+// replaces the current one only after the code is confirmed.
+func (h *Handler) ChangePhone(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.ChangePhoneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	phone := normalizePhone(req.Phone)
+	if !phonePattern.MatchString(phone) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid phone number",
+		})
+	}
+	if user.Phone != nil && *user.Phone == phone && user.PhoneVerifiedAt != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "This is already your verified phone number",
+		})
+	}
+
+	inUse, err := h.repo.PhoneInUse(phone, user.ID)
+	if err != nil {
+		log.Printf("[ChangePhone] Failed to check phone: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to change phone number",
+		})
+	}
+	if inUse {
+		return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+			Message: "Phone number is already in use",
+		})
+	}
+
+	return h.sendPhoneCode(c, &user, phone, models.PhoneVerificationPurposeChange)
+}
+
+// VerifyPhone confirms the most recently sent phone code
+func (h *Handler) VerifyPhone(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.VerifyPhoneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	verification, err := h.repo.GetLatestPhoneVerification(user.ID)
+	if err != nil || verification.VerifiedAt != nil || time.Now().After(verification.ExpiresAt) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "No active verification code. Please request a new one.",
+		})
+	}
+
+	// The attempt is counted before the code is checked, so a burst of
+	// guesses cannot all be compared against the same count
+	attempts, err := h.repo.IncrementPhoneAttempts(verification.ID, models.PhoneOTPMaxAttempts)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusTooManyRequests).JSON(models.InfoResponse{
+			Message: "Too many incorrect attempts. Please request a new code.",
+		})
+	}
+	if err != nil {
+		log.Printf("[VerifyPhone] Failed to record attempt: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to verify phone number",
+		})
+	}
+
+	if !hmac.Equal([]byte(h.hashPhoneCode(verification.Phone, strings.TrimSpace(req.Code))), []byte(verification.CodeHash)) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: fmt.Sprintf("Invalid verification code. %d attempt(s) remaining.", models.PhoneOTPMaxAttempts-attempts),
+		})
+	}
+
+	if err := h.repo.CompletePhoneVerification(verification); err != nil {
+		log.Printf("[VerifyPhone] Failed to complete verification: %v", err)
+		return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+			Message: "Failed to verify phone number",
+		})
+	}
+
+	return c.JSON(models.InfoResponse{
+		Message: "Phone number verified successfully",
+	})
+}
+
+// sendPhoneCode generates, stores and texts a new code, enforcing the resend cooldown
+func (h *Handler) sendPhoneCode(c *fiber.Ctx, user *models.User, phone, purpose string) error {
+	if last, err := h.repo.GetLatestPhoneVerification(user.ID); err == nil &&
+		time.Since(last.CreatedAt) < models.PhoneOTPResendCooldown {
+		return c.Status(fiber.StatusTooManyRequests).JSON(models.InfoResponse{
+			Message: "Please wait before requesting another code",
+		})
+	}
+
+	code, err := generatePhoneCode()
+	if err != nil {
+		log.Printf("[SendPhoneCode] Failed to generate code: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to send verification code",
+		})
+	}
+
+	verification := &models.PhoneVerification{
+		UserID:    user.ID,
+		Phone:     phone,
+		Purpose:   purpose,
+		CodeHash:  h.hashPhoneCode(phone, code),
+		ExpiresAt: time.Now().Add(models.PhoneOTPExpiry),
+	}
+	if err := h.repo.CreatePhoneVerification(verification); err != nil {
+		log.Printf("[SendPhoneCode] Failed to store code: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to send verification code",
+		})
+	}
+
+	message := fmt.Sprintf("Your LevPay verification code is %s. It expires in %d minutes. Never share this code.",
+		code, int(models.PhoneOTPExpiry.Minutes()))
+	if err := sms.SMS.Send(phone, message); err != nil {
+		log.Printf("[SendPhoneCode] Failed to send SMS: %v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(models.InfoResponse{
+			Message: "Failed to send verification code",
+		})
+	}
+
+	return c.JSON(verification.ToResponse())
+}
+
+// generatePhoneCode returns a random numeric code
+func generatePhoneCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < models.PhoneOTPLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", models.PhoneOTPLength, n), nil
+}
+
+// hashPhoneCode keys the code to the phone number with the server secret so
+// stored hashes cannot be brute-forced offline
+func (h *Handler) hashPhoneCode(phone, code string) string {
+	mac := hmac.New(sha256.New, []byte(h.policy.Secret))
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalizePhone strips formatting characters from a phone number
+func normalizePhone(phone string) string {
+	return strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+}
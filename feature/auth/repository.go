@@ -1,13 +1,16 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/oauth"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository handles database operations for authentication
@@ -20,8 +23,9 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// CreateUser creates a new user with hashed password
-func (r *Repository) CreateUser(req models.RegisterRequest) (*models.User, error) {
+// CreateUser creates a new user with hashed password, keeping up to
+// keepHistory password hashes for reuse checks
+func (r *Repository) CreateUser(req models.RegisterRequest, keepHistory int) (*models.User, error) {
 	// Hash password
 	hashedPassword, err := utils.PWD.HashPassword(req.Password)
 	if err != nil {
@@ -38,66 +42,149 @@ func (r *Repository) CreateUser(req models.RegisterRequest) (*models.User, error
 		KYCStatus:    "pending",
 	}
 
-	if err := r.db.Create(user).Error; err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
-	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
 
-	// Create default wallet for user
-	wallet := &models.Wallet{
-		UserID:      user.ID,
-		Currency:    "ETB",
-		Balance:     0,
-		LastUpdated: time.Now(),
-	}
+		if err := r.recordPassword(tx, user.ID, hashedPassword, keepHistory); err != nil {
+			return err
+		}
 
-	if err := r.db.Create(wallet).Error; err != nil {
-		return nil, fmt.Errorf("failed to create wallet: %w", err)
+		// Create default wallet for user
+		wallet := &models.Wallet{
+			UserID:      user.ID,
+			Currency:    "ETB",
+			Balance:     0,
+			LastUpdated: time.Now(),
+		}
+		if err := tx.Create(wallet).Error; err != nil {
+			return fmt.Errorf("failed to create wallet: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return user, nil
 }
 
-// CreateOAuthUser creates a new user from OAuth provider
-func (r *Repository) CreateOAuthUser(email, firstName, lastName, googleID string) (*models.User, error) {
+// CreateOAuthUser creates a new user from a provider identity and links the
+// identity to it. The email is marked verified when the provider vouches for it.
+func (r *Repository) CreateOAuthUser(identity *oauth.Identity) (*models.User, error) {
 	user := &models.User{
-		FirstName:    firstName,
-		LastName:     lastName,
-		Email:        email,
-		GoogleID:     &googleID,
+		FirstName:    identity.FirstName,
+		LastName:     identity.LastName,
+		Email:        identity.Email,
 		Role:         "user",
 		KYCStatus:    "pending",
 		Phone:        nil, // OAuth users might not have phone initially
 		PasswordHash: nil,
 	}
-
-	if err := r.db.Create(user).Error; err != nil {
-		return nil, fmt.Errorf("failed to create oauth user: %w", err)
+	if identity.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
 	}
 
-	// Create default wallet
-	wallet := &models.Wallet{
-		UserID:      user.ID,
-		Currency:    "ETB",
-		Balance:     0,
-		LastUpdated: time.Now(),
-	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create oauth user: %w", err)
+		}
 
-	if err := r.db.Create(wallet).Error; err != nil {
-		return nil, fmt.Errorf("failed to create wallet: %w", err)
+		if err := tx.Create(newIdentity(user.ID, identity)).Error; err != nil {
+			return fmt.Errorf("failed to link identity: %w", err)
+		}
+
+		// Create default wallet
+		wallet := &models.Wallet{
+			UserID:      user.ID,
+			Currency:    "ETB",
+			Balance:     0,
+			LastUpdated: time.Now(),
+		}
+		if err := tx.Create(wallet).Error; err != nil {
+			return fmt.Errorf("failed to create wallet: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return user, nil
 }
 
-// GetUserByGoogleID returns a user by their Google ID
-func (r *Repository) GetUserByGoogleID(googleID string) (*models.User, error) {
+// GetUserByIdentity finds the user linked to a provider account. Google
+// accounts linked through the legacy GoogleID column are moved over to an
+// identity on first use.
+func (r *Repository) GetUserByIdentity(provider, subject string) (*models.User, error) {
+	var identity models.OAuthIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err == nil {
+		return r.GetUserByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) || provider != oauth.ProviderGoogle {
+		return nil, err
+	}
+
 	var user models.User
-	if err := r.db.Preload("Wallet").Preload("Sessions").First(&user, "google_id = ?", googleID).Error; err != nil {
+	if err := r.db.Preload("Wallet").Preload("Sessions").First(&user, "google_id = ?", subject).Error; err != nil {
 		return nil, err
 	}
+	legacy := &models.OAuthIdentity{UserID: user.ID, Provider: provider, Subject: subject, Email: user.Email}
+	if err := r.db.Create(legacy).Error; err != nil {
+		return nil, fmt.Errorf("failed to migrate google link: %w", err)
+	}
 	return &user, nil
 }
 
+// GetIdentities retrieves the provider accounts linked to a user
+func (r *Repository) GetIdentities(userID uuid.UUID) ([]models.OAuthIdentity, error) {
+	var identities []models.OAuthIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at asc").Find(&identities).Error
+	return identities, err
+}
+
+// LinkIdentity links a provider account to a user
+func (r *Repository) LinkIdentity(userID uuid.UUID, identity *oauth.Identity) error {
+	if err := r.db.Create(newIdentity(userID, identity)).Error; err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+// UnlinkIdentity removes a user's link to a provider and returns whether one existed
+func (r *Repository) UnlinkIdentity(userID uuid.UUID, provider string) (bool, error) {
+	var removed bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Deleted outright so the account can be linked again later
+		result := tx.Unscoped().Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.OAuthIdentity{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to unlink identity: %w", result.Error)
+		}
+		removed = result.RowsAffected > 0
+
+		if provider == oauth.ProviderGoogle {
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("google_id", nil).Error; err != nil {
+				return fmt.Errorf("failed to clear google id: %w", err)
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// newIdentity builds the identity record for a verified provider account
+func newIdentity(userID uuid.UUID, identity *oauth.Identity) *models.OAuthIdentity {
+	return &models.OAuthIdentity{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+}
+
 // GetUserByEmail finds a user by email
 func (r *Repository) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
@@ -116,45 +203,279 @@ func (r *Repository) GetUserByID(userID uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
-// UpdatePassword updates a user's password
-func (r *Repository) UpdatePassword(userID uuid.UUID, newPassword string) error {
+// GetUserWithRoles retrieves a user by ID with their granted staff roles
+func (r *Repository) GetUserWithRoles(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.Preload("RoleAssignments").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdatePassword updates a user's password, keeping up to keepHistory
+// password hashes for reuse checks
+func (r *Repository) UpdatePassword(userID uuid.UUID, newPassword string, keepHistory int) error {
+	hashedPassword, err := utils.PWD.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return r.setPassword(tx, userID, hashedPassword, keepHistory)
+	})
+}
+
+// ResetPassword consumes a reset token and sets the new password in one
+// transaction. It returns errResetTokenInvalid if the token was used, revoked
+// or expired in the meantime.
+func (r *Repository) ResetPassword(token *models.PasswordResetToken, newPassword string, keepHistory int) error {
 	hashedPassword, err := utils.PWD.HashPassword(newPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	if err := r.db.Model(&models.User{}).Where("id = ?", userID).Update("password_hash", hashedPassword).Error; err != nil {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", token.ID, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to consume reset token: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenInvalid
+		}
+
+		// Bumping the version only succeeds if the password is unchanged since the link was issued
+		result = tx.Model(&models.User{}).
+			Where("id = ? AND password_version = ?", token.UserID, token.PasswordVersion).
+			Updates(map[string]interface{}{
+				"password_hash":    hashedPassword,
+				"password_version": gorm.Expr("password_version + 1"),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update password: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenInvalid
+		}
+
+		if err := r.revokeResetTokens(tx, token.UserID); err != nil {
+			return err
+		}
+		return r.recordPassword(tx, token.UserID, hashedPassword, keepHistory)
+	})
+}
+
+// CreateResetToken stores a new reset token, revoking any outstanding ones
+func (r *Repository) CreateResetToken(user *models.User, token string, expiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.revokeResetTokens(tx, user.ID); err != nil {
+			return err
+		}
+
+		reset := &models.PasswordResetToken{
+			UserID:          user.ID,
+			TokenHash:       utils.HashToken(token),
+			PasswordVersion: user.PasswordVersion,
+			ExpiresAt:       expiresAt,
+		}
+		if err := tx.Create(reset).Error; err != nil {
+			return fmt.Errorf("failed to create reset token: %w", err)
+		}
+		return nil
+	})
+}
+
+// FindResetToken looks up a reset token by its raw value
+func (r *Repository) FindResetToken(token string) (*models.PasswordResetToken, error) {
+	var reset models.PasswordResetToken
+	if err := r.db.Where("token_hash = ?", utils.HashToken(token)).First(&reset).Error; err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// setPassword stores a new password hash, bumps the password version and
+// revokes outstanding reset links
+func (r *Repository) setPassword(tx *gorm.DB, userID uuid.UUID, hashedPassword string, keepHistory int) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password_hash":    hashedPassword,
+		"password_version": gorm.Expr("password_version + 1"),
+	}).Error; err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	if err := r.revokeResetTokens(tx, userID); err != nil {
+		return err
+	}
+	return r.recordPassword(tx, userID, hashedPassword, keepHistory)
+}
+
+// revokeResetTokens invalidates a user's outstanding reset tokens
+func (r *Repository) revokeResetTokens(tx *gorm.DB, userID uuid.UUID) error {
+	if err := tx.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke reset tokens: %w", err)
+	}
 	return nil
 }
 
-// CreateSession creates a new session with refresh token
-func (r *Repository) CreateSession(userID uuid.UUID, refreshToken, fingerprint string) (*models.Session, error) {
-	session := &models.Session{
-		UserID:       userID,
-		RefreshToken: refreshToken,
-		Fingerprint:  fingerprint,
-		Active:       true,
+// GetPasswordHistory retrieves a user's most recent password hashes
+func (r *Repository) GetPasswordHistory(userID uuid.UUID, limit int) ([]models.PasswordHistory, error) {
+	var history []models.PasswordHistory
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&history).Error
+	return history, err
+}
+
+// recordPassword adds a password hash to the user's history and drops
+// everything beyond the newest keep entries
+func (r *Repository) recordPassword(db *gorm.DB, userID uuid.UUID, hashedPassword string, keep int) error {
+	// Old hashes are removed outright rather than soft deleted
+	prune := db.Unscoped().Where("user_id = ?", userID)
+	if keep > 0 {
+		entry := &models.PasswordHistory{UserID: userID, PasswordHash: hashedPassword}
+		if err := db.Create(entry).Error; err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+		newest := db.Model(&models.PasswordHistory{}).
+			Select("id").
+			Where("user_id = ?", userID).
+			Order("created_at desc").
+			Limit(keep)
+		prune = prune.Where("id NOT IN (?)", newest)
 	}
 
+	if err := prune.Delete(&models.PasswordHistory{}).Error; err != nil {
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+	return nil
+}
+
+// CreateSession stores a new session with refresh token, starting a new token
+// family. The session carries the device details collected by the caller.
+func (r *Repository) CreateSession(session *models.Session, refreshToken string) error {
+	now := time.Now()
+	session.FamilyID = uuid.New()
+	session.RefreshToken = utils.HashToken(refreshToken)
+	session.Active = true
+	session.SignedInAt = now
+	session.LastSeenAt = &now
+
 	if err := r.db.Create(session).Error; err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		return fmt.Errorf("failed to create session: %w", err)
 	}
 
-	return session, nil
+	return nil
 }
 
 // GetSessionByToken finds an active session by refresh token
 func (r *Repository) GetSessionByToken(refreshToken string) (*models.Session, error) {
 	var session models.Session
-	if err := r.db.First(&session, "refresh_token = ? AND active = ?", refreshToken, true).Error; err != nil {
+	if err := r.db.First(&session, "refresh_token = ? AND active = ?", utils.HashToken(refreshToken), true).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindSessionByToken finds the session a refresh token was issued for, whether
+// or not it is still active
+func (r *Repository) FindSessionByToken(refreshToken string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.First(&session, "refresh_token = ?", utils.HashToken(refreshToken)).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateSession replaces an active session with a new one in the same family,
+// keeping its sign-in time and refreshing its device details. It fails if the
+// session was already rotated or terminated concurrently.
+func (r *Repository) RotateSession(old *models.Session, refreshToken string, session *models.Session) error {
+	now := time.Now()
+	session.UserID = old.UserID
+	session.FamilyID = old.FamilyID
+	session.RefreshToken = utils.HashToken(refreshToken)
+	session.Active = true
+	session.SignedInAt = old.SignedInAt
+	session.LastSeenAt = &now
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND active = ?", old.ID, true).
+			Updates(map[string]interface{}{
+				"active":         false,
+				"terminated":     now,
+				"rotated_at":     now,
+				"replaced_by_id": session.ID,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to rotate session: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("session is no longer active")
+		}
+		return nil
+	})
+}
+
+// GetActiveSessions lists a user's signed-in devices, most recently used first
+func (r *Repository) GetActiveSessions(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND active = ?", userID, true).
+		Order("last_seen_at desc NULLS LAST").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// GetActiveSession finds one of a user's active sessions by ID
+func (r *Repository) GetActiveSession(userID, sessionID uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.First(&session, "id = ? AND user_id = ? AND active = ?", sessionID, userID, true).Error; err != nil {
 		return nil, err
 	}
 	return &session, nil
 }
 
+// RevokeOtherSessions terminates every active session of a user except the
+// given family
+func (r *Repository) RevokeOtherSessions(userID, keepFamilyID uuid.UUID) (int64, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND active = ? AND family_id <> ?", userID, true, keepFamilyID).
+		Updates(map[string]interface{}{
+			"active":         false,
+			"terminated":     time.Now(),
+			"revoked_reason": models.SessionRevokedByUser,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RevokeSessionFamily terminates every active session in a token family
+func (r *Repository) RevokeSessionFamily(familyID uuid.UUID, reason string) (int64, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("family_id = ? AND active = ?", familyID, true).
+		Updates(map[string]interface{}{
+			"active":         false,
+			"terminated":     time.Now(),
+			"revoked_reason": reason,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke session family: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // TerminateSession marks a session as terminated
 func (r *Repository) TerminateSession(sessionID uuid.UUID) error {
 	now := time.Now()
@@ -183,20 +504,356 @@ func (r *Repository) TerminateUserSessions(userID uuid.UUID) error {
 	return nil
 }
 
-// ValidateCredentials checks if email and password match
-func (r *Repository) ValidateCredentials(email, password string) (*models.User, error) {
-	user, err := r.GetUserByEmail(email)
+// SetTOTPSecret stores a new encrypted TOTP secret pending confirmation
+func (r *Repository) SetTOTPSecret(userID uuid.UUID, encryptedSecret string) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"totp_secret":    encryptedSecret,
+			"totp_last_step": 0,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to store totp secret: %w", err)
+	}
+	return nil
+}
+
+// VerifyTOTP checks a code against the user's secret and records its time
+// step, so each code is accepted once. It returns false without an error for
+// a wrong or reused code. Every TOTP check goes through here so sign-in and
+// payment authorisation share one replay protection.
+func (r *Repository) VerifyTOTP(user *models.User, code string) (bool, error) {
+	if user.TOTPSecret == nil || code == "" {
+		return false, nil
+	}
+
+	secret, err := utils.CIPHER.Decrypt(*user.TOTPSecret)
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return false, fmt.Errorf("failed to decrypt totp secret: %w", err)
 	}
 
-	if user.PasswordHash == nil {
-		return nil, fmt.Errorf("this account uses Google login")
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return false, nil
 	}
+	return r.AdvanceTOTPStep(user.ID, step)
+}
 
-	if !utils.PWD.CheckPasswordHash(password, *user.PasswordHash) {
-		return nil, fmt.Errorf("invalid credentials")
+// AdvanceTOTPStep records an accepted TOTP time step. It returns false if the
+// step (or a later one) was already used, so a code cannot be replayed.
+func (r *Repository) AdvanceTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record totp step: %w", result.Error)
 	}
+	return result.RowsAffected == 1, nil
+}
 
-	return user, nil
+// EnableTwoFactor turns on 2FA and stores the initial recovery codes
+func (r *Repository) EnableTwoFactor(userID uuid.UUID, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("two_fa_enabled", true).Error; err != nil {
+			return fmt.Errorf("failed to enable 2fa: %w", err)
+		}
+		return NewRepository(tx).ReplaceRecoveryCodes(userID, codeHashes)
+	})
+}
+
+// DisableTwoFactor turns off 2FA and removes the secret and recovery codes
+func (r *Repository) DisableTwoFactor(userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"two_fa_enabled": false,
+				"totp_secret":    nil,
+				"totp_last_step": 0,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to disable 2fa: %w", err)
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReplaceRecoveryCodes discards a user's recovery codes and stores new ones
+func (r *Repository) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		if err := tx.Create(&codes).Error; err != nil {
+			return fmt.Errorf("failed to create recovery codes: %w", err)
+		}
+		return nil
+	})
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used. It returns false
+// if no matching unused code exists.
+func (r *Repository) ConsumeRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes a user has left
+func (r *Repository) CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// CreateTwoFactorChallenge stores a login waiting for its second factor
+func (r *Repository) CreateTwoFactorChallenge(challenge *models.TwoFactorChallenge) error {
+	if err := r.db.Create(challenge).Error; err != nil {
+		return fmt.Errorf("failed to create two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+// IncrementChallengeAttempts counts a code against a login challenge before
+// it is checked and returns the attempts used. Only an open challenge under
+// maxAttempts counts; otherwise gorm.ErrRecordNotFound is returned.
+func (r *Repository) IncrementChallengeAttempts(id, userID uuid.UUID, maxAttempts int) (int, error) {
+	var challenge models.TwoFactorChallenge
+	result := r.db.Model(&challenge).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("id = ? AND user_id = ? AND completed_at IS NULL AND expires_at > NOW() AND attempts < ?", id, userID, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to count two-factor attempt: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return challenge.Attempts, nil
+}
+
+// CompleteTwoFactorChallenge spends a challenge once its code checks out. It
+// returns false if the challenge was already used.
+func (r *Repository) CompleteTwoFactorChallenge(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.TwoFactorChallenge{}).
+		Where("id = ? AND completed_at IS NULL", id).
+		Update("completed_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to complete two-factor challenge: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkEmailVerified records that a user confirmed their email address
+func (r *Repository) MarkEmailVerified(userID uuid.UUID) error {
+	if err := r.db.Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
+
+// SetVerificationSentAt records when a verification email was last sent
+func (r *Repository) SetVerificationSentAt(userID uuid.UUID, sentAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("email_verification_sent_at", sentAt).Error
+}
+
+// CreatePhoneVerification stores a new phone code and expires any pending ones
+func (r *Repository) CreatePhoneVerification(verification *models.PhoneVerification) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.PhoneVerification{}).
+			Where("user_id = ? AND verified_at IS NULL AND expires_at > ?", verification.UserID, now).
+			Update("expires_at", now).Error; err != nil {
+			return fmt.Errorf("failed to expire phone codes: %w", err)
+		}
+		if err := tx.Create(verification).Error; err != nil {
+			return fmt.Errorf("failed to create phone code: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetLatestPhoneVerification returns the most recently sent phone code for a user
+func (r *Repository) GetLatestPhoneVerification(userID uuid.UUID) (*models.PhoneVerification, error) {
+	var verification models.PhoneVerification
+	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").First(&verification).Error; err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// IncrementPhoneAttempts counts a guess at a phone code before it is checked
+// and returns the attempts used. The count only moves while it is under
+// maxAttempts, so parallel guesses cannot go past the limit; once it is
+// reached gorm.ErrRecordNotFound is returned.
+func (r *Repository) IncrementPhoneAttempts(id uuid.UUID, maxAttempts int) (int, error) {
+	var verification models.PhoneVerification
+	result := r.db.Model(&verification).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to count phone code attempt: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return verification.Attempts, nil
+}
+
+// PhoneInUse reports whether another user already has the phone number
+func (r *Repository) PhoneInUse(phone string, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("phone = ? AND id <> ?", phone, userID).Count(&count).Error
+	return count > 0, err
+}
+
+// CompletePhoneVerification consumes the code and sets the user's verified phone number
+func (r *Repository) CompletePhoneVerification(verification *models.PhoneVerification) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.PhoneVerification{}).
+			Where("id = ? AND verified_at IS NULL", verification.ID).
+			Update("verified_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to consume phone code: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("phone code already used")
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", verification.UserID).
+			Updates(map[string]interface{}{
+				"phone":             verification.Phone,
+				"phone_verified_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update phone: %w", err)
+		}
+		return nil
+	})
+}
+
+// RecordLoginFailure stores a failed login for throttling
+func (r *Repository) RecordLoginFailure(email, ipAddress string, userID *uuid.UUID, reason string) error {
+	attempt := &models.LoginAttempt{
+		Email:     email,
+		IPAddress: ipAddress,
+		UserID:    userID,
+		Reason:    reason,
+	}
+	if err := r.db.Create(attempt).Error; err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return nil
+}
+
+// CountEmailFailures counts failed logins for an email since the given time
+func (r *Repository) CountEmailFailures(email string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LoginAttempt{}).Where("email = ? AND created_at > ?", email, since).Count(&count).Error
+	return count, err
+}
+
+// CountIPFailures counts failed logins from an IP address since the given time
+func (r *Repository) CountIPFailures(ipAddress string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LoginAttempt{}).Where("ip_address = ? AND created_at > ?", ipAddress, since).Count(&count).Error
+	return count, err
+}
+
+// GetLastLoginFailure returns the most recent failed login for an email
+func (r *Repository) GetLastLoginFailure(email string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	if err := r.db.Where("email = ?", email).Order("created_at desc").First(&attempt).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// CountEmailFailuresByReason counts failed logins for an email with the given
+// reason since the given time
+func (r *Repository) CountEmailFailuresByReason(email, reason string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LoginAttempt{}).
+		Where("email = ? AND reason = ? AND created_at > ?", email, reason, since).
+		Count(&count).Error
+	return count, err
+}
+
+// GetLastLoginFailureByReason returns the most recent failed login for an
+// email with the given reason
+func (r *Repository) GetLastLoginFailureByReason(email, reason string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	if err := r.db.Where("email = ? AND reason = ?", email, reason).Order("created_at desc").First(&attempt).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// ClearLoginFailures forgets failed logins for an email after it is proven legitimate
+func (r *Repository) ClearLoginFailures(email string) error {
+	return r.db.Where("email = ?", email).Delete(&models.LoginAttempt{}).Error
+}
+
+// IncrementFailedLogins adds one to a user's consecutive failed login count
+// and returns the new count. It is a single statement so parallel failures
+// cannot all read the same count, and a lockout that has expired starts the
+// count again.
+func (r *Repository) IncrementFailedLogins(userID uuid.UUID) (int, error) {
+	var user models.User
+	result := r.db.Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_login_count"}}}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"failed_login_count": gorm.Expr("CASE WHEN locked_until IS NOT NULL AND locked_until <= NOW() THEN 1 ELSE failed_login_count + 1 END"),
+			"locked_until":       gorm.Expr("CASE WHEN locked_until <= NOW() THEN NULL ELSE locked_until END"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to count failed login: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return user.FailedLoginCount, nil
+}
+
+// LockUser refuses logins for a user until the given time. It reports false
+// if the user was already locked, so only one request announces the lockout.
+func (r *Repository) LockUser(userID uuid.UUID, until time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND (locked_until IS NULL OR locked_until <= NOW())", userID).
+		Update("locked_until", until)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UnlockUser lifts the lockout ending at lockedUntil and clears the failed
+// login count. It returns false if that lockout was already lifted or
+// replaced by a later one.
+func (r *Repository) UnlockUser(userID uuid.UUID, lockedUntil time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND locked_until = ?", userID, lockedUntil).
+		Updates(map[string]interface{}{
+			"failed_login_count": 0,
+			"locked_until":       nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ResetLoginFailures clears a user's failed login count and any lockout
+func (r *Repository) ResetLoginFailures(userID uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"failed_login_count": 0,
+			"locked_until":       nil,
+		}).Error
 }
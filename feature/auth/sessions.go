@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/notification"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Headers describing the client device
+const (
+	deviceNameHeader  = "X-Device-Name"
+	geoCityHeader     = "X-Geo-City"    // Set by the edge proxy's GeoIP lookup
+	geoCountryHeader  = "X-Geo-Country" // Set by the edge proxy's GeoIP lookup
+	cfCountryHeader   = "CF-IPCountry"
+	maxDeviceFieldLen = 255
+)
+
+// ListSessions returns the devices the user is signed in on
+func (h *Handler) ListSessions(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	sessions, err := h.repo.GetActiveSessions(user.ID)
+	if err != nil {
+		log.Printf("[ListSessions] Failed to get sessions: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to get sessions",
+		})
+	}
+
+	current := currentSessionID(c)
+	responses := make([]models.SessionResponse, len(sessions))
+	for i := range sessions {
+		responses[i] = sessions[i].ToResponse(current)
+	}
+
+	return c.JSON(responses)
+}
+
+// RevokeSession signs the user out of one device
+func (h *Handler) RevokeSession(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid session ID",
+		})
+	}
+
+	session, err := h.repo.GetActiveSession(user.ID, sessionID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.InfoResponse{
+			Message: "Session not found",
+		})
+	}
+
+	if _, err := h.repo.RevokeSessionFamily(session.FamilyID, models.SessionRevokedByUser); err != nil {
+		log.Printf("[RevokeSession] %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to revoke session",
+		})
+	}
+
+	return c.JSON(models.InfoResponse{
+		Message: "Session revoked",
+	})
+}
+
+// RevokeOtherSessions signs the user out everywhere except the current device
+func (h *Handler) RevokeOtherSessions(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	current := currentSessionID(c)
+	if current == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Current session unknown. Please sign in again.",
+		})
+	}
+
+	revoked, err := h.repo.RevokeOtherSessions(user.ID, current)
+	if err != nil {
+		log.Printf("[RevokeOtherSessions] %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to revoke sessions",
+		})
+	}
+
+	return c.JSON(models.InfoResponse{
+		Message: fmt.Sprintf("Signed out of %d other session(s)", revoked),
+	})
+}
+
+// revokeReusedFamily terminates every session descended from the same login
+// as a replayed refresh token, then alerts the user
+func (h *Handler) revokeReusedFamily(c *fiber.Ctx, session *models.Session) {
+	revoked, err := h.repo.RevokeSessionFamily(session.FamilyID, models.SessionRevokedTokenReuse)
+	if err != nil {
+		log.Printf("[Refresh] %v", err)
+		return
+	}
+	log.Printf("[Refresh] Refresh token reuse for user %s, revoked %d session(s) in family %s", session.UserID, revoked, session.FamilyID)
+
+	h.audit(c, &session.UserID, models.AuditActionTokenReuse, map[string]interface{}{
+		"session_id":       session.ID,
+		"family_id":        session.FamilyID,
+		"revoked_sessions": revoked,
+	})
+
+	user, err := h.repo.GetUserByID(session.UserID)
+	if err != nil {
+		return
+	}
+	if err := sendEmail(user.Email, notification.TemplateSessionRevoked, map[string]interface{}{
+		"Name":       user.FirstName,
+		"IPAddress":  c.IP(),
+		"Time":       time.Now().Format(time.RFC1123),
+		"Sessions":   revoked,
+		"ForgotLink": fmt.Sprintf("%s/auth/forgot-password", os.Getenv("FRONTEND_URL")),
+	}); err != nil {
+		log.Printf("[Refresh] Failed to send reuse alert: %v", err)
+	}
+}
+
+// newSession collects the device details of the current request for a session
+func newSession(c *fiber.Ctx, userID uuid.UUID, fingerprint string) *models.Session {
+	userAgent := truncate(c.Get(fiber.HeaderUserAgent), maxDeviceFieldLen)
+
+	deviceName := truncate(strings.TrimSpace(c.Get(deviceNameHeader)), maxDeviceFieldLen)
+	if deviceName == "" {
+		deviceName = describeUserAgent(userAgent)
+	}
+
+	return &models.Session{
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		DeviceName:  deviceName,
+		UserAgent:   userAgent,
+		IPAddress:   c.IP(),
+		Location:    locationLabel(c),
+	}
+}
+
+// currentSessionID returns the session family of the caller's access token
+func currentSessionID(c *fiber.Ctx) uuid.UUID {
+	if decodedToken, ok := c.Locals("decoded_token").(models.DecodedToken); ok {
+		return decodedToken.SessionID
+	}
+	return uuid.Nil
+}
+
+// locationLabel builds a coarse location from GeoIP headers set by the edge proxy
+func locationLabel(c *fiber.Ctx) *string {
+	city := strings.TrimSpace(c.Get(geoCityHeader))
+	country := strings.TrimSpace(c.Get(geoCountryHeader))
+	if country == "" {
+		country = strings.TrimSpace(c.Get(cfCountryHeader))
+	}
+
+	var label string
+	switch {
+	case city != "" && country != "":
+		label = city + ", " + country
+	case country != "":
+		label = country
+	default:
+		return nil
+	}
+	label = truncate(label, maxDeviceFieldLen)
+	return &label
+}
+
+// describeUserAgent turns a user agent into a short label like "Chrome on Windows"
+func describeUserAgent(userAgent string) string {
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Chrome/", "Chrome"},
+		{"Firefox/", "Firefox"},
+		{"Safari/", "Safari"},
+		{"okhttp", "Android app"},
+		{"Dart/", "Mobile app"},
+	}
+	platforms := []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+
+	browser := ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	platform := ""
+	for _, p := range platforms {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+// truncate limits a client supplied value to n bytes
+func truncate(value string, n int) string {
+	if len(value) > n {
+		return value[:n]
+	}
+	return value
+}
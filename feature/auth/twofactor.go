@@ -0,0 +1,442 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetTwoFactorStatus reports whether 2FA is on and how many recovery codes remain
+func (h *Handler) GetTwoFactorStatus(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	status := models.TwoFactorStatusResponse{Enabled: user.TwoFAEnabled}
+	if user.TwoFAEnabled {
+		remaining, err := h.repo.CountUnusedRecoveryCodes(user.ID)
+		if err != nil {
+			log.Printf("[GetTwoFactorStatus] Failed to count recovery codes: %v", err)
+		}
+		status.RecoveryCodesRemaining = remaining
+	}
+
+	return c.JSON(status)
+}
+
+// SetupTwoFactor generates a TOTP secret for the user to add to an authenticator
+// app. 2FA stays off until the first code is confirmed via EnableTwoFactor.
+func (h *Handler) SetupTwoFactor(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	if user.TwoFAEnabled {
+		return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+			Message: "Two-factor authentication is already enabled",
+		})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("[SetupTwoFactor] Failed to generate secret: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to start two-factor setup",
+		})
+	}
+
+	encrypted, err := utils.CIPHER.Encrypt(secret)
+	if err != nil {
+		log.Printf("[SetupTwoFactor] Failed to encrypt secret: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to start two-factor setup",
+		})
+	}
+
+	if err := h.repo.SetTOTPSecret(user.ID, encrypted); err != nil {
+		log.Printf("[SetupTwoFactor] Failed to store secret: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to start two-factor setup",
+		})
+	}
+
+	return c.JSON(models.TwoFactorSetupResponse{
+		Secret: secret,
+		URI:    utils.TOTPURI(models.TOTPIssuer, user.Email, secret),
+	})
+}
+
+// EnableTwoFactor confirms enrollment with a code from the authenticator app and
+// returns the recovery codes
+func (h *Handler) EnableTwoFactor(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	if user.TwoFAEnabled {
+		return c.Status(fiber.StatusConflict).JSON(models.InfoResponse{
+			Message: "Two-factor authentication is already enabled",
+		})
+	}
+	if user.TOTPSecret == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Start two-factor setup first",
+		})
+	}
+
+	if !h.verifyTOTP(&user, req.Code) {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid verification code",
+		})
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		log.Printf("[EnableTwoFactor] Failed to generate recovery codes: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to enable two-factor authentication",
+		})
+	}
+
+	if err := h.repo.EnableTwoFactor(user.ID, hashes); err != nil {
+		log.Printf("[EnableTwoFactor] Failed to enable 2FA: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to enable two-factor authentication",
+		})
+	}
+
+	return c.JSON(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns off 2FA after verifying a current code or recovery code
+func (h *Handler) DisableTwoFactor(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	if !user.TwoFAEnabled {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+
+	if !h.verifySecondFactor(&user, req.Code, req.RecoveryCode) {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid verification code",
+		})
+	}
+
+	if err := h.repo.DisableTwoFactor(user.ID); err != nil {
+		log.Printf("[DisableTwoFactor] Failed to disable 2FA: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to disable two-factor authentication",
+		})
+	}
+
+	return c.JSON(models.InfoResponse{
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a current code
+func (h *Handler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	if !user.TwoFAEnabled {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+
+	if !h.verifyTOTP(&user, req.Code) {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid verification code",
+		})
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		log.Printf("[RegenerateRecoveryCodes] Failed to generate recovery codes: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to regenerate recovery codes",
+		})
+	}
+
+	if err := h.repo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		log.Printf("[RegenerateRecoveryCodes] Failed to store recovery codes: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to regenerate recovery codes",
+		})
+	}
+
+	return c.JSON(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// StepUp re-verifies the second factor and issues a short-lived token that
+// authorizes sensitive actions such as withdrawals and password changes
+func (h *Handler) StepUp(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	if !user.TwoFAEnabled {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+
+	if !h.verifySecondFactor(&user, req.Code, req.RecoveryCode) {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid verification code",
+		})
+	}
+
+	token, err := utils.JWT.GenerateToken(user.ID, user.Role, h.policy.TwoFactorExpiry, models.JWTStepUp)
+	if err != nil {
+		log.Printf("[StepUp] Failed to generate step-up token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to generate token",
+		})
+	}
+
+	return c.JSON(models.StepUpResponse{
+		StepUpToken: token,
+		ExpiresIn:   h.policy.TwoFactorExpiry,
+	})
+}
+
+// VerifyTwoFactor completes a login that was challenged for a second factor.
+// Each code counts against the challenge before it is checked, and wrong
+// codes count towards the account lockout like wrong passwords.
+func (h *Handler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	decodedToken, err := utils.JWT.DecodeToken(req.ChallengeToken, models.JWTChallenge)
+	if err != nil || decodedToken.SessionID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired challenge token",
+		})
+	}
+
+	user, err := h.repo.GetUserByID(decodedToken.UserID)
+	if err != nil || !user.TwoFAEnabled {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired challenge token",
+		})
+	}
+	if user.IsLocked(time.Now()) {
+		return lockedResponse(c, *user.LockedUntil)
+	}
+
+	attempts, err := h.repo.IncrementChallengeAttempts(decodedToken.SessionID, user.ID, models.TwoFactorChallengeMaxAttempts)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired challenge token. Please sign in again.",
+		})
+	}
+	if err != nil {
+		log.Printf("[VerifyTwoFactor] %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to verify code",
+		})
+	}
+
+	if !h.verifySecondFactor(user, req.Code, req.RecoveryCode) {
+		return h.failedTwoFactor(c, user, attempts)
+	}
+
+	completed, err := h.repo.CompleteTwoFactorChallenge(decodedToken.SessionID)
+	if err != nil || !completed {
+		if err != nil {
+			log.Printf("[VerifyTwoFactor] %v", err)
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired challenge token. Please sign in again.",
+		})
+	}
+
+	// Failures are kept through the password step of a 2FA login and only
+	// cleared here
+	h.clearLoginFailures(user, strings.ToLower(user.Email))
+
+	return h.completeLogin(c, user, req.Fingerprint)
+}
+
+// failedTwoFactor records a wrong code as a failed login, locking the account
+// at the lockout threshold. The challenge is spent after its last attempt.
+func (h *Handler) failedTwoFactor(c *fiber.Ctx, user *models.User, attempts int) error {
+	attemptKey := strings.ToLower(user.Email)
+	ip := c.IP()
+
+	if err := h.repo.RecordLoginFailure(attemptKey, ip, &user.ID, models.LoginFailureTwoFactor); err != nil {
+		log.Printf("[VerifyTwoFactor] %v", err)
+	}
+	h.audit(c, &user.ID, models.AuditActionLoginFailed, map[string]interface{}{
+		"email":  attemptKey,
+		"reason": models.LoginFailureTwoFactor,
+	})
+
+	failures, err := h.repo.IncrementFailedLogins(user.ID)
+	if err != nil {
+		log.Printf("[VerifyTwoFactor] %v", err)
+	}
+	if failures >= models.LoginLockoutThreshold {
+		return h.lockAccount(c, user, attemptKey, ip, int64(failures))
+	}
+
+	if attempts >= models.TwoFactorChallengeMaxAttempts {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Too many incorrect codes. Please sign in again.",
+		})
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+		Message: "Invalid verification code",
+	})
+}
+
+// twoFactorChallenge responds to a successful password step with a challenge
+// token instead of a session. The token names a stored challenge so wrong
+// codes can be counted against it.
+func (h *Handler) twoFactorChallenge(c *fiber.Ctx, user *models.User) error {
+	challenge := &models.TwoFactorChallenge{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Duration(h.policy.TwoFactorExpiry) * time.Second),
+	}
+	if err := h.repo.CreateTwoFactorChallenge(challenge); err != nil {
+		log.Printf("[Login] %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to start two-factor verification",
+		})
+	}
+
+	token, err := utils.JWT.GenerateSessionToken(user.ID, user.Role, challenge.ID, h.policy.TwoFactorExpiry, models.JWTChallenge)
+	if err != nil {
+		log.Printf("[Login] Failed to generate challenge token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to generate token",
+		})
+	}
+
+	return c.JSON(models.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		Methods:           []string{models.TwoFactorMethodTOTP, models.TwoFactorMethodRecovery},
+		ExpiresIn:         h.policy.TwoFactorExpiry,
+	})
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code
+func (h *Handler) verifySecondFactor(user *models.User, code, recoveryCode string) bool {
+	if code != "" {
+		return h.verifyTOTP(user, code)
+	}
+	if recoveryCode == "" {
+		return false
+	}
+
+	used, err := h.repo.ConsumeRecoveryCode(user.ID, hashRecoveryCode(recoveryCode))
+	if err != nil {
+		log.Printf("[TwoFactor] Failed to check recovery code: %v", err)
+		return false
+	}
+	return used
+}
+
+// verifyTOTP checks a code against the user's secret and records its time step
+func (h *Handler) verifyTOTP(user *models.User, code string) bool {
+	ok, err := h.repo.VerifyTOTP(user, code)
+	if err != nil {
+		log.Printf("[TwoFactor] Failed to verify code for user %s: %v", user.ID, err)
+	}
+	return ok
+}
+
+// generateRecoveryCodes returns the plain codes to show once and their hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, models.RecoveryCodeCount)
+	hashes := make([]string, models.RecoveryCodeCount)
+
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalizes a recovery code and hashes it for storage
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
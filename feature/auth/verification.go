@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/notification"
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// verificationResendCooldown limits how often a verification email can be resent
+const verificationResendCooldown = time.Minute
+
+// VerifyEmail confirms a user's email address from a verification link
+func (h *Handler) VerifyEmail(c *fiber.Ctx) error {
+	var req models.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	decodedToken, err := utils.JWT.DecodeToken(req.Token, models.JWTVerifyEmail)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired verification token",
+		})
+	}
+
+	user, err := h.repo.GetUserByID(decodedToken.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Invalid or expired verification token",
+		})
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return c.JSON(models.InfoResponse{
+			Message: "Email already verified",
+		})
+	}
+
+	if err := h.repo.MarkEmailVerified(user.ID); err != nil {
+		log.Printf("[VerifyEmail] Failed to mark email verified: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to verify email",
+		})
+	}
+
+	return c.JSON(models.InfoResponse{
+		Message: "Email verified successfully",
+	})
+}
+
+// ResendVerification sends a new verification email to the authenticated user
+func (h *Handler) ResendVerification(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+			Message: "Email already verified",
+		})
+	}
+
+	if user.EmailVerificationSentAt != nil && time.Since(*user.EmailVerificationSentAt) < verificationResendCooldown {
+		return c.Status(fiber.StatusTooManyRequests).JSON(models.InfoResponse{
+			Message: "Please wait before requesting another verification email",
+		})
+	}
+
+	if err := h.sendVerificationEmail(&user); err != nil {
+		log.Printf("[ResendVerification] Failed to send verification email: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+			Message: "Failed to send verification email",
+		})
+	}
+
+	return c.JSON(models.InfoResponse{
+		Message: "Verification email sent",
+	})
+}
+
+// sendVerificationEmail emails the user a link to verify their address
+func (h *Handler) sendVerificationEmail(user *models.User) error {
+	token, err := utils.JWT.GenerateToken(user.ID, user.Role, h.policy.VerifyExpiry, models.JWTVerifyEmail)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	if err := sendEmail(user.Email, notification.TemplateEmailVerification, map[string]interface{}{
+		"Name":         user.FirstName,
+		"Email":        user.Email,
+		"VerifyLink":   fmt.Sprintf("%s/auth/verify-email?token=%s", os.Getenv("FRONTEND_URL"), token),
+		"ExpiresHours": h.policy.VerifyExpiry / 3600,
+	}); err != nil {
+		return err
+	}
+
+	return h.repo.SetVerificationSentAt(user.ID, time.Now())
+}
+
+// sendEmail renders a notification template and queues it for delivery
+func sendEmail(to, templateName string, data map[string]interface{}) error {
+	subject, body, err := notification.Render(templateName, data)
+	if err != nil {
+		return err
+	}
+
+	rabbitmq.RMQ.Publish(models.Message{
+		From:     config.CFG.MSG.From,
+		FromName: config.CFG.MSG.FromName,
+		To:       []string{to},
+		Subject:  subject,
+		Body:     body,
+	}, config.CFG.RMQ.NotificationQueue)
+	return nil
+}
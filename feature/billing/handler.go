@@ -3,8 +3,11 @@ package billing
 import (
 	"time"
 
+	"github.com/Keba777/levpay-backend/feature/organization"
+	"github.com/Keba777/levpay-backend/feature/pin"
 	"github.com/Keba777/levpay-backend/feature/transaction"
 	"github.com/Keba777/levpay-backend/feature/wallet"
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -16,15 +19,17 @@ type Handler struct {
 	repo       *Repository
 	txRepo     *transaction.Repository
 	walletRepo *wallet.Repository
+	pins       *pin.Service
 	db         *gorm.DB
 }
 
 // NewHandler creates a new billing handler
-func NewHandler(repo *Repository, txRepo *transaction.Repository, walletRepo *wallet.Repository, db *gorm.DB) *Handler {
+func NewHandler(repo *Repository, txRepo *transaction.Repository, walletRepo *wallet.Repository, pins *pin.Service, db *gorm.DB) *Handler {
 	return &Handler{
 		repo:       repo,
 		txRepo:     txRepo,
 		walletRepo: walletRepo,
+		pins:       pins,
 		db:         db,
 	}
 }
@@ -38,9 +43,18 @@ func getUserID(c *fiber.Ctx) (uuid.UUID, error) {
 	return user.ID, nil
 }
 
+// Helper to get the merchant the request acts on behalf of (see middleware.MerchantContext)
+func getMerchantID(c *fiber.Ctx) (uuid.UUID, error) {
+	merchantID, ok := c.Locals("merchant_id").(uuid.UUID)
+	if !ok {
+		return getUserID(c)
+	}
+	return merchantID, nil
+}
+
 // CreateInvoice creates a new invoice (merchant only)
 func (h *Handler) CreateInvoice(c *fiber.Ctx) error {
-	merchantID, err := getUserID(c)
+	merchantID, err := getMerchantID(c)
 	if err != nil {
 		return err
 	}
@@ -76,6 +90,11 @@ func (h *Handler) CreateInvoice(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create invoice")
 	}
 
+	organization.RecordAction(h.db, c, organization.ActionInvoiceCreated, "invoice", invoice.ID.String(), map[string]interface{}{
+		"amount":   invoice.Amount,
+		"currency": invoice.Currency,
+	})
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "Invoice created successfully",
 		"invoice": invoice.ToResponse(),
@@ -100,8 +119,13 @@ func (h *Handler) GetInvoice(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "Invoice not found")
 	}
 
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
 	// Verify access (merchant or customer)
-	if invoice.MerchantID != userID && (invoice.CustomerID == nil || *invoice.CustomerID != userID) {
+	if invoice.MerchantID != merchantID && (invoice.CustomerID == nil || *invoice.CustomerID != userID) {
 		return fiber.NewError(fiber.StatusForbidden, "Access denied")
 	}
 
@@ -121,11 +145,15 @@ func (h *Handler) ListInvoices(c *fiber.Ctx) error {
 
 	var invoices []models.Invoice
 	var total int64
+	var merchantID uuid.UUID
 
 	if viewType == "customer" {
 		invoices, total, err = h.repo.GetCustomerInvoices(userID, req)
 	} else {
-		invoices, total, err = h.repo.GetMerchantInvoices(userID, req)
+		if merchantID, err = getMerchantID(c); err != nil {
+			return err
+		}
+		invoices, total, err = h.repo.GetMerchantInvoices(merchantID, req)
 	}
 
 	if err != nil {
@@ -180,6 +208,20 @@ func (h *Handler) PayInvoice(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invoice is cancelled")
 	}
 
+	// The body is optional; it only carries the PIN for larger invoices
+	var req models.PayInvoiceRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	// Payments over the threshold need the PIN or a 2FA code
+	user := c.Locals("user").(models.User)
+	if err := h.pins.Authorize(&user, invoice.Amount, req.PaymentAuthorization, c.IP()); err != nil {
+		return err
+	}
+
 	// Process payment in transaction
 	var txRecord *models.Transaction
 	err = h.db.Transaction(func(tx *gorm.DB) error {
@@ -219,9 +261,19 @@ func (h *Handler) PayInvoice(c *fiber.Ctx) error {
 		if err.Error() == "insufficient balance" {
 			return fiber.NewError(fiber.StatusBadRequest, "Insufficient balance")
 		}
+		if err.Error() == "wallet is locked" {
+			return fiber.NewError(fiber.StatusForbidden, "Wallet is locked")
+		}
+		if err.Error() == "wallet is frozen" {
+			return fiber.NewError(fiber.StatusForbidden, "Wallet is frozen")
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "Payment failed")
 	}
 
+	e := audit.Request(c, models.AuditActionPayment)
+	e.Details = map[string]interface{}{"invoice_id": invoice.ID}
+	transaction.RecordEvent(e, txRecord)
+
 	return c.JSON(fiber.Map{
 		"message":     "Invoice paid successfully",
 		"transaction": txRecord.ToResponse(),
@@ -230,7 +282,7 @@ func (h *Handler) PayInvoice(c *fiber.Ctx) error {
 
 // CancelInvoice cancels an unpaid invoice
 func (h *Handler) CancelInvoice(c *fiber.Ctx) error {
-	merchantID, err := getUserID(c)
+	merchantID, err := getMerchantID(c)
 	if err != nil {
 		return err
 	}
@@ -260,12 +312,14 @@ func (h *Handler) CancelInvoice(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to cancel invoice")
 	}
 
+	organization.RecordAction(h.db, c, organization.ActionInvoiceCancelled, "invoice", invoiceID.String(), nil)
+
 	return c.JSON(fiber.Map{"message": "Invoice cancelled successfully"})
 }
 
 // GetInvoiceStats retrieves billing statistics
 func (h *Handler) GetInvoiceStats(c *fiber.Ctx) error {
-	merchantID, err := getUserID(c)
+	merchantID, err := getMerchantID(c)
 	if err != nil {
 		return err
 	}
@@ -290,3 +344,109 @@ func (h *Handler) GetInvoiceStats(c *fiber.Ctx) error {
 		"pending_amount":   totalAmount - paidAmount,
 	})
 }
+
+// GetReminderSettings returns the merchant's invoice reminder configuration
+func (h *Handler) GetReminderSettings(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	setting, err := h.repo.GetReminderSetting(merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve reminder settings")
+	}
+
+	return c.JSON(fiber.Map{
+		"before_due_days": setting.BeforeDueDays,
+		"after_due_days":  setting.AfterDueDays,
+		"enabled":         setting.Enabled,
+	})
+}
+
+// UpdateReminderSettings configures when invoice reminders are sent
+func (h *Handler) UpdateReminderSettings(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateReminderSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// Offsets are whole days relative to the due date, capped at 90
+	for _, days := range append(append([]int{}, req.BeforeDueDays...), req.AfterDueDays...) {
+		if days < 1 || days > 90 {
+			return fiber.NewError(fiber.StatusBadRequest, "Reminder offsets must be between 1 and 90 days")
+		}
+	}
+
+	setting, err := h.repo.GetReminderSetting(merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve reminder settings")
+	}
+
+	if req.BeforeDueDays != nil {
+		setting.BeforeDueDays = req.BeforeDueDays
+	}
+	if req.AfterDueDays != nil {
+		setting.AfterDueDays = req.AfterDueDays
+	}
+	if req.Enabled != nil {
+		setting.Enabled = *req.Enabled
+	}
+
+	if err := h.repo.SaveReminderSetting(setting); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update reminder settings")
+	}
+
+	organization.RecordAction(h.db, c, organization.ActionReminderSettingsUpdated, "reminder_settings", setting.ID.String(), map[string]interface{}{
+		"before_due_days": setting.BeforeDueDays,
+		"after_due_days":  setting.AfterDueDays,
+		"enabled":         setting.Enabled,
+	})
+
+	return c.JSON(fiber.Map{
+		"message":         "Reminder settings updated",
+		"before_due_days": setting.BeforeDueDays,
+		"after_due_days":  setting.AfterDueDays,
+		"enabled":         setting.Enabled,
+	})
+}
+
+// GetInvoiceReminders lists the reminders already sent for an invoice
+func (h *Handler) GetInvoiceReminders(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	invoiceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid invoice ID")
+	}
+
+	invoice, err := h.repo.GetInvoiceByID(invoiceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Invoice not found")
+	}
+
+	// Verify ownership
+	if invoice.MerchantID != merchantID {
+		return fiber.NewError(fiber.StatusForbidden, "Access denied")
+	}
+
+	reminders, err := h.repo.GetInvoiceReminders(invoiceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve reminders")
+	}
+
+	responses := make([]models.InvoiceReminderResponse, len(reminders))
+	for i, r := range reminders {
+		responses[i] = r.ToResponse()
+	}
+
+	return c.JSON(responses)
+}
@@ -135,3 +135,72 @@ func (r *Repository) GetOverdueInvoices() ([]models.Invoice, error) {
 
 	return invoices, err
 }
+
+// GetReminderSetting retrieves a merchant's reminder configuration, falling back to defaults
+func (r *Repository) GetReminderSetting(merchantID uuid.UUID) (*models.InvoiceReminderSetting, error) {
+	var setting models.InvoiceReminderSetting
+	err := r.db.Where("merchant_id = ?", merchantID).First(&setting).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.InvoiceReminderSetting{
+			MerchantID:    merchantID,
+			BeforeDueDays: models.DefaultReminderBeforeDays,
+			AfterDueDays:  models.DefaultReminderAfterDays,
+			Enabled:       true,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// SaveReminderSetting creates or updates a merchant's reminder configuration
+func (r *Repository) SaveReminderSetting(setting *models.InvoiceReminderSetting) error {
+	var existing models.InvoiceReminderSetting
+	err := r.db.Where("merchant_id = ?", setting.MerchantID).First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return r.db.Create(setting).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	setting.ID = existing.ID
+	return r.db.Model(&existing).Updates(map[string]interface{}{
+		"before_due_days": setting.BeforeDueDays,
+		"after_due_days":  setting.AfterDueDays,
+		"enabled":         setting.Enabled,
+	}).Error
+}
+
+// GetRemindableInvoices retrieves unpaid invoices with a customer and a due date
+func (r *Repository) GetRemindableInvoices() ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.Where("status IN ? AND due_date IS NOT NULL AND customer_id IS NOT NULL",
+		[]string{models.InvoiceStatusSent, models.InvoiceStatusOverdue}).
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// HasReminder checks whether a reminder stage was already sent for an invoice
+func (r *Repository) HasReminder(invoiceID uuid.UUID, kind string, offsetDays int) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.InvoiceReminder{}).
+		Where("invoice_id = ? AND kind = ? AND offset_days = ?", invoiceID, kind, offsetDays).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateReminder records a sent reminder
+func (r *Repository) CreateReminder(reminder *models.InvoiceReminder) error {
+	return r.db.Create(reminder).Error
+}
+
+// GetInvoiceReminders retrieves the reminder history of an invoice
+func (r *Repository) GetInvoiceReminders(invoiceID uuid.UUID) ([]models.InvoiceReminder, error) {
+	var reminders []models.InvoiceReminder
+	err := r.db.Where("invoice_id = ?", invoiceID).
+		Order("sent_at asc").
+		Find(&reminders).Error
+	return reminders, err
+}
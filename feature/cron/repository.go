@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository handles job run bookkeeping and cross-replica locking
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new cron repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// WithJobLock runs fn while holding a Postgres advisory lock for the job.
+// It returns false without running fn if another replica holds the lock.
+func (r *Repository) WithJobLock(jobName string, fn func() error) (bool, error) {
+	acquired := false
+	lockKey := "cron:" + jobName
+
+	// Advisory locks belong to a session, so lock and unlock on one pinned connection
+	err := r.db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(hashtext(?))", lockKey).Scan(&acquired).Error; err != nil {
+			return fmt.Errorf("failed to acquire job lock: %w", err)
+		}
+		if !acquired {
+			return nil
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", lockKey)
+
+		return fn()
+	})
+
+	return acquired, err
+}
+
+// StartScheduledRun claims a cron slot for a job. It returns false if any replica
+// already claimed the same slot.
+func (r *Repository) StartScheduledRun(jobName string, slot time.Time, host string) (*models.JobRun, bool, error) {
+	now := time.Now()
+	run := &models.JobRun{
+		JobName:      jobName,
+		Trigger:      models.JobTriggerSchedule,
+		Status:       models.JobRunStatusRunning,
+		ScheduledFor: &slot,
+		StartedAt:    &now,
+		Host:         host,
+	}
+
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(run)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	return run, result.RowsAffected == 1, nil
+}
+
+// GetQueuedRuns retrieves manually triggered runs waiting to be executed
+func (r *Repository) GetQueuedRuns() ([]models.JobRun, error) {
+	var runs []models.JobRun
+	err := r.db.Where("status = ?", models.JobRunStatusQueued).
+		Order("created_at asc").
+		Find(&runs).Error
+	return runs, err
+}
+
+// ClaimQueuedRun marks a queued run as running. It returns false if another
+// replica claimed it first.
+func (r *Repository) ClaimQueuedRun(id uuid.UUID, host string) (bool, error) {
+	result := r.db.Model(&models.JobRun{}).
+		Where("id = ? AND status = ?", id, models.JobRunStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     models.JobRunStatusRunning,
+			"started_at": time.Now(),
+			"host":       host,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// FailStaleRuns marks runs still running since before the cutoff as failed.
+// Their scheduler stopped before recording an outcome, so they would
+// otherwise stay running forever.
+func (r *Repository) FailStaleRuns(cutoff time.Time) (int64, error) {
+	result := r.db.Model(&models.JobRun{}).
+		Where("status = ? AND started_at < ?", models.JobRunStatusRunning, cutoff).
+		Updates(map[string]interface{}{
+			"status":      models.JobRunStatusFailed,
+			"finished_at": time.Now(),
+			"error":       "abandoned: the scheduler stopped before the run finished",
+		})
+	return result.RowsAffected, result.Error
+}
+
+// FinishRun records the outcome of a job run
+func (r *Repository) FinishRun(id uuid.UUID, rowsAffected int64, runErr error) error {
+	updates := map[string]interface{}{
+		"status":        models.JobRunStatusSucceeded,
+		"finished_at":   time.Now(),
+		"rows_affected": rowsAffected,
+	}
+	if runErr != nil {
+		updates["status"] = models.JobRunStatusFailed
+		updates["error"] = runErr.Error()
+	}
+
+	return r.db.Model(&models.JobRun{}).Where("id = ?", id).Updates(updates).Error
+}
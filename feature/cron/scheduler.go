@@ -1,63 +1,169 @@
 package cron
 
 import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/robfig/cron/v3"
 	"gorm.io/gorm"
 )
 
+// slotWindow is how late a run may start and still claim its cron slot. It
+// must stay below the shortest interval between runs of any job.
+const slotWindow = time.Minute
+
+// job binds a job name to its schedule and implementation
+type job struct {
+	name string
+	spec string
+	run  func() (int64, error)
+}
+
 // Scheduler manages cron jobs
 type Scheduler struct {
 	cron    *cron.Cron
 	service *Service
+	repo    *Repository
+	logger  *utils.Logger
+	host    string
+	jobs    map[string]job
 }
 
 // NewScheduler creates a new cron scheduler
 func NewScheduler(db *gorm.DB) *Scheduler {
-	return &Scheduler{
+	host, _ := os.Hostname()
+	s := &Scheduler{
 		cron:    cron.New(),
 		service: NewService(db),
+		repo:    NewRepository(db),
+		logger:  utils.GetLogger("cron"),
+		host:    host,
 	}
+
+	s.jobs = map[string]job{
+		// Mark overdue invoices - every hour
+		models.JobMarkOverdueInvoices: {models.JobMarkOverdueInvoices, "0 * * * *", s.service.MarkOverdueInvoices},
+		// Cleanup expired sessions - every day at 2 AM
+		models.JobCleanupExpiredSessions: {models.JobCleanupExpiredSessions, "0 2 * * *", s.service.CleanupExpiredSessions},
+		// Send payment reminders - every day at 9 AM
+		models.JobSendPaymentReminders: {models.JobSendPaymentReminders, "0 9 * * *", s.service.SendPaymentReminders},
+		// Update invoice statuses - every 6 hours
+		models.JobUpdateInvoiceStatuses: {models.JobUpdateInvoiceStatuses, "0 */6 * * *", s.service.UpdateInvoiceStatuses},
+		// Settle merchant payouts - every day at 1 AM
+		models.JobSettleMerchants: {models.JobSettleMerchants, "0 1 * * *", s.service.SettleMerchants},
+		// Enforce dispute response deadlines - every hour at half past
+		models.JobEnforceDisputeDeadlines: {models.JobEnforceDisputeDeadlines, "30 * * * *", s.service.EnforceDisputeDeadlines},
+		// Purge audit logs past the retention period - every day at 3 AM
+		models.JobPurgeAuditLogs: {models.JobPurgeAuditLogs, "0 3 * * *", s.service.PurgeAuditLogs},
+	}
+
+	return s
 }
 
 // Start starts all cron jobs
 func (s *Scheduler) Start() error {
-	// Mark overdue invoices - every hour
-	_, err := s.cron.AddFunc("0 * * * *", func() {
-		s.service.MarkOverdueInvoices()
-	})
+	// Fail runs a crashed scheduler left running
+	reaped, err := s.repo.FailStaleRuns(time.Now().Add(-models.JobRunTimeout))
 	if err != nil {
-		return err
+		s.logger.ErrorWithErr("Failed to reap stale job runs", err)
+	} else if reaped > 0 {
+		s.logger.Warn("Marked stale job runs as failed", utils.Field{Key: "count", Value: reaped})
 	}
 
-	// Cleanup expired sessions - every day at 2 AM
-	_, err = s.cron.AddFunc("0 2 * * *", func() {
-		s.service.CleanupExpiredSessions()
-	})
-	if err != nil {
+	for _, name := range models.CronJobs {
+		j, ok := s.jobs[name]
+		if !ok {
+			return fmt.Errorf("job %s is not registered", name)
+		}
+		schedule, err := cron.ParseStandard(j.spec)
+		if err != nil {
+			return fmt.Errorf("job %s has an invalid schedule: %w", name, err)
+		}
+		s.cron.Schedule(schedule, cron.FuncJob(func() {
+			s.runScheduled(j, schedule)
+		}))
+	}
+
+	// Pick up manually triggered runs - every 30 seconds
+	if _, err := s.cron.AddFunc("@every 30s", s.runQueued); err != nil {
 		return err
 	}
 
-	// Send payment reminders - every day at 9 AM
-	_, err = s.cron.AddFunc("0 9 * * *", func() {
-		s.service.SendPaymentReminders()
+	s.cron.Start()
+	return nil
+}
+
+// Stop stops all cron jobs
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+// runScheduled executes a job for the current cron slot unless another replica
+// is running it or has already run it for this slot. The slot is the
+// activation time the schedule gives, so replicas whose clocks differ by less
+// than slotWindow claim the same slot.
+func (s *Scheduler) runScheduled(j job, schedule cron.Schedule) {
+	slot := schedule.Next(time.Now().Add(-slotWindow))
+
+	acquired, err := s.repo.WithJobLock(j.name, func() error {
+		run, claimed, err := s.repo.StartScheduledRun(j.name, slot, s.host)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			s.logger.Info("Job already ran for this slot", utils.Field{Key: "job", Value: j.name})
+			return nil
+		}
+		s.execute(j, run)
+		return nil
 	})
 	if err != nil {
-		return err
+		s.logger.ErrorWithErr("Failed to run scheduled job", err, utils.Field{Key: "job", Value: j.name})
+		return
+	}
+	if !acquired {
+		s.logger.Info("Job is running on another replica", utils.Field{Key: "job", Value: j.name})
 	}
+}
 
-	// Update invoice statuses - every 6 hours
-	_, err = s.cron.AddFunc("0 */6 * * *", func() {
-		s.service.UpdateInvoiceStatuses()
-	})
+// runQueued executes manually triggered runs
+func (s *Scheduler) runQueued() {
+	runs, err := s.repo.GetQueuedRuns()
 	if err != nil {
-		return err
+		s.logger.ErrorWithErr("Failed to get queued job runs", err)
+		return
 	}
 
-	s.cron.Start()
-	return nil
+	for _, run := range runs {
+		j, ok := s.jobs[run.JobName]
+		if !ok {
+			s.repo.FinishRun(run.ID, 0, fmt.Errorf("unknown job: %s", run.JobName))
+			continue
+		}
+
+		run := run
+		// Leave the run queued if the job is busy; the next poll retries it
+		_, err := s.repo.WithJobLock(j.name, func() error {
+			claimed, err := s.repo.ClaimQueuedRun(run.ID, s.host)
+			if err != nil || !claimed {
+				return err
+			}
+			s.execute(j, &run)
+			return nil
+		})
+		if err != nil {
+			s.logger.ErrorWithErr("Failed to run queued job", err, utils.Field{Key: "job", Value: j.name})
+		}
+	}
 }
 
-// Stop stops all cron jobs
-func (s *Scheduler) Stop() {
-	s.cron.Stop()
+// execute runs a job and records its outcome
+func (s *Scheduler) execute(j job, run *models.JobRun) {
+	rows, runErr := j.run()
+	if err := s.repo.FinishRun(run.ID, rows, runErr); err != nil {
+		s.logger.ErrorWithErr("Failed to record job run", err, utils.Field{Key: "job", Value: j.name})
+	}
 }
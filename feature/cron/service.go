@@ -1,11 +1,24 @@
 package cron
 
 import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Keba777/levpay-backend/feature/billing"
+	"github.com/Keba777/levpay-backend/feature/dispute"
+	"github.com/Keba777/levpay-backend/feature/notification"
+	"github.com/Keba777/levpay-backend/feature/settlement"
+	"github.com/Keba777/levpay-backend/feature/user"
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/config"
 	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
 	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +26,9 @@ import (
 type Service struct {
 	db          *gorm.DB
 	billingRepo *billing.Repository
+	userRepo    *user.Repository
+	settlements *settlement.Service
+	disputes    *dispute.Service
 	logger      *utils.Logger
 }
 
@@ -21,37 +37,44 @@ func NewService(db *gorm.DB) *Service {
 	return &Service{
 		db:          db,
 		billingRepo: billing.NewRepository(db),
+		userRepo:    user.NewRepository(db),
+		settlements: settlement.NewService(db),
+		disputes:    dispute.NewService(db),
 		logger:      utils.GetLogger("cron"),
 	}
 }
 
-// MarkOverdueInvoices marks invoices as overdue if past due date
-func (s *Service) MarkOverdueInvoices() error {
+// MarkOverdueInvoices marks invoices as overdue if past due date and notifies the customer
+func (s *Service) MarkOverdueInvoices() (int64, error) {
 	s.logger.Info("Running: Mark overdue invoices")
 
 	overdueInvoices, err := s.billingRepo.GetOverdueInvoices()
 	if err != nil {
 		s.logger.ErrorWithErr("Failed to get overdue invoices", err)
-		return err
+		return 0, err
 	}
 
-	count := 0
+	var count int64
 	for _, invoice := range overdueInvoices {
-		if invoice.Status != models.InvoiceStatusPaid && invoice.Status != models.InvoiceStatusCancelled {
+		if invoice.Status != models.InvoiceStatusPaid && invoice.Status != models.InvoiceStatusCancelled && invoice.Status != models.InvoiceStatusOverdue {
 			if err := s.billingRepo.UpdateInvoiceStatus(invoice.ID, models.InvoiceStatusOverdue); err != nil {
 				s.logger.ErrorWithErr("Failed to mark invoice as overdue", err)
 				continue
 			}
 			count++
+
+			if _, err := s.sendInvoiceReminder(invoice, models.InvoiceReminderOverdue, 0); err != nil {
+				s.logger.ErrorWithErr("Failed to send overdue notice", err, utils.Field{Key: "invoice_id", Value: invoice.ID})
+			}
 		}
 	}
 
 	s.logger.Info("Marked invoices as overdue", utils.Field{Key: "count", Value: count})
-	return nil
+	return count, nil
 }
 
 // CleanupExpiredSessions removes old JWT sessions
-func (s *Service) CleanupExpiredSessions() error {
+func (s *Service) CleanupExpiredSessions() (int64, error) {
 	s.logger.Info("Running: Cleanup expired sessions")
 
 	// Delete sessions older than 30 days
@@ -60,40 +83,164 @@ func (s *Service) CleanupExpiredSessions() error {
 	result := s.db.Where("updated_at < ?", thirtyDaysAgo).Delete(&models.Session{})
 	if result.Error != nil {
 		s.logger.ErrorWithErr("Failed to cleanup sessions", result.Error)
-		return result.Error
+		return 0, result.Error
 	}
 
 	s.logger.Info("Cleaned up expired sessions", utils.Field{Key: "count", Value: result.RowsAffected})
-	return nil
+	return result.RowsAffected, nil
 }
 
-// SendPaymentReminders sends reminders for invoices due soon
-func (s *Service) SendPaymentReminders() error {
+// SendPaymentReminders sends reminders for invoices approaching or past their due date
+// according to each merchant's reminder offsets
+func (s *Service) SendPaymentReminders() (int64, error) {
 	s.logger.Info("Running: Send payment reminders")
 
-	// Find invoices due in 3 days
-	threeDaysFromNow := time.Now().AddDate(0, 0, 3)
+	invoices, err := s.billingRepo.GetRemindableInvoices()
+	if err != nil {
+		s.logger.ErrorWithErr("Failed to get remindable invoices", err)
+		return 0, err
+	}
+
+	now := time.Now()
+	settings := make(map[uuid.UUID]*models.InvoiceReminderSetting)
+	var sent int64
 
-	var upcomingInvoices []models.Invoice
-	err := s.db.Where("status = ? AND due_date BETWEEN ? AND ?",
-		models.InvoiceStatusSent,
-		time.Now(),
-		threeDaysFromNow).
-		Find(&upcomingInvoices).Error
+	for _, invoice := range invoices {
+		setting, ok := settings[invoice.MerchantID]
+		if !ok {
+			setting, err = s.billingRepo.GetReminderSetting(invoice.MerchantID)
+			if err != nil {
+				s.logger.ErrorWithErr("Failed to get reminder settings", err, utils.Field{Key: "merchant_id", Value: invoice.MerchantID})
+				continue
+			}
+			settings[invoice.MerchantID] = setting
+		}
+		if !setting.Enabled {
+			continue
+		}
+
+		kind, offset, ok := reminderStage(*invoice.DueDate, now, setting)
+		if !ok {
+			continue
+		}
+
+		ok, err = s.sendInvoiceReminder(invoice, kind, offset)
+		if err != nil {
+			s.logger.ErrorWithErr("Failed to send payment reminder", err, utils.Field{Key: "invoice_id", Value: invoice.ID})
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	s.logger.Info("Payment reminders sent", utils.Field{Key: "count", Value: sent})
+	return sent, nil
+}
 
+// reminderStage returns the single reminder stage that applies to a due date right now.
+// Before the due date the closest configured offset wins, after it the furthest one passed,
+// so an invoice created late never receives several reminders in the same run.
+func reminderStage(dueDate, now time.Time, setting *models.InvoiceReminderSetting) (string, int, bool) {
+	if dueDate.After(now) {
+		daysLeft := int(math.Ceil(dueDate.Sub(now).Hours() / 24))
+		offsets := append([]int{}, setting.BeforeDueDays...)
+		sort.Ints(offsets)
+		for _, days := range offsets {
+			if daysLeft <= days {
+				return models.InvoiceReminderBeforeDue, days, true
+			}
+		}
+		return "", 0, false
+	}
+
+	daysLate := int(now.Sub(dueDate).Hours() / 24)
+	offsets := append([]int{}, setting.AfterDueDays...)
+	sort.Sort(sort.Reverse(sort.IntSlice(offsets)))
+	for _, days := range offsets {
+		if daysLate >= days {
+			return models.InvoiceReminderAfterDue, days, true
+		}
+	}
+	return "", 0, false
+}
+
+// sendInvoiceReminder publishes a templated reminder to the notification queue
+// and records it. Stages that were already sent are skipped and reported as not sent.
+func (s *Service) sendInvoiceReminder(invoice models.Invoice, kind string, offset int) (bool, error) {
+	if invoice.CustomerID == nil || invoice.DueDate == nil {
+		return false, nil
+	}
+
+	alreadySent, err := s.billingRepo.HasReminder(invoice.ID, kind, offset)
 	if err != nil {
-		s.logger.ErrorWithErr("Failed to get upcoming invoices", err)
-		return err
+		return false, err
+	}
+	if alreadySent {
+		return false, nil
 	}
 
-	// TODO: Send notifications via RabbitMQ
-	s.logger.Info("Payment reminders to send", utils.Field{Key: "count", Value: len(upcomingInvoices)})
+	customer, err := s.userRepo.GetUserByID(*invoice.CustomerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get customer: %w", err)
+	}
+	merchant, err := s.userRepo.GetUserByID(invoice.MerchantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get merchant: %w", err)
+	}
 
-	return nil
+	days := offset
+	if kind == models.InvoiceReminderBeforeDue {
+		days = int(math.Ceil(time.Until(*invoice.DueDate).Hours() / 24))
+	} else if kind == models.InvoiceReminderAfterDue {
+		days = int(time.Since(*invoice.DueDate).Hours() / 24)
+	}
+
+	tmpl := notification.TemplateInvoiceReminder
+	if kind == models.InvoiceReminderOverdue {
+		tmpl = notification.TemplateInvoiceOverdue
+	}
+
+	subject, body, err := notification.Render(tmpl, map[string]interface{}{
+		"CustomerName":  customer.FirstName,
+		"MerchantName":  strings.TrimSpace(merchant.FirstName + " " + merchant.LastName),
+		"InvoiceNumber": invoice.InvoiceNumber,
+		"Amount":        invoice.Amount,
+		"Currency":      invoice.Currency,
+		"DueDate":       invoice.DueDate.Format("2006-01-02"),
+		"Days":          days,
+		"Overdue":       kind != models.InvoiceReminderBeforeDue,
+		"PayLink":       fmt.Sprintf("%s/billing/invoices/%s", os.Getenv("FRONTEND_URL"), invoice.ID),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Record first so a crash after publishing cannot cause a duplicate on the next run
+	reminder := &models.InvoiceReminder{
+		InvoiceID:  invoice.ID,
+		Kind:       kind,
+		OffsetDays: offset,
+		Recipient:  customer.Email,
+		SentAt:     time.Now(),
+	}
+	if err := s.billingRepo.CreateReminder(reminder); err != nil {
+		return false, fmt.Errorf("failed to record reminder: %w", err)
+	}
+
+	rabbitmq.RMQ.Publish(models.Message{
+		From:     config.CFG.MSG.From,
+		FromName: config.CFG.MSG.FromName,
+		To:       []string{customer.Email},
+		Subject:  subject,
+		Body:     body,
+	}, config.CFG.RMQ.NotificationQueue)
+
+	return true, nil
 }
 
 // UpdateInvoiceStatuses performs general invoice status maintenance
-func (s *Service) UpdateInvoiceStatuses() error {
+func (s *Service) UpdateInvoiceStatuses() (int64, error) {
 	s.logger.Info("Running: Update invoice statuses")
 
 	// Mark draft invoices as sent if they have a due date
@@ -103,9 +250,71 @@ func (s *Service) UpdateInvoiceStatuses() error {
 
 	if result.Error != nil {
 		s.logger.ErrorWithErr("Failed to update invoice statuses", result.Error)
-		return result.Error
+		return 0, result.Error
 	}
 
 	s.logger.Info("Updated invoice statuses", utils.Field{Key: "count", Value: result.RowsAffected})
-	return nil
+	return result.RowsAffected, nil
+}
+
+// SettleMerchants batches each due merchant's settled payments into a payout
+func (s *Service) SettleMerchants() (int64, error) {
+	s.logger.Info("Running: Settle merchants")
+
+	count, err := s.settlements.RunSettlements(time.Now())
+	if err != nil {
+		s.logger.ErrorWithErr("Failed to settle merchants", err)
+		return 0, err
+	}
+
+	s.logger.Info("Settled merchants", utils.Field{Key: "count", Value: count})
+	return count, nil
+}
+
+// EnforceDisputeDeadlines reminds merchants of pending dispute responses,
+// refunds payers whose disputes went unanswered and escalates overdue reviews
+func (s *Service) EnforceDisputeDeadlines() (int64, error) {
+	s.logger.Info("Running: Enforce dispute deadlines")
+
+	count, err := s.disputes.EnforceDeadlines(time.Now())
+	if err != nil {
+		s.logger.ErrorWithErr("Failed to enforce dispute deadlines", err)
+		return 0, err
+	}
+
+	s.logger.Info("Resolved expired disputes", utils.Field{Key: "count", Value: count})
+	return count, nil
+}
+
+// PurgeAuditLogs deletes audit log entries older than the retention period.
+// The purge is itself audited so gaps at the start of the chain are explained.
+func (s *Service) PurgeAuditLogs() (int64, error) {
+	s.logger.Info("Running: Purge audit logs")
+
+	days := config.CFG.Security.AuditRetentionDays
+	if days <= 0 {
+		s.logger.Info("Audit log retention is disabled")
+		return 0, nil
+	}
+
+	before := time.Now().AddDate(0, 0, -days)
+	count, err := audit.Purge(s.db, before)
+	if err != nil {
+		s.logger.ErrorWithErr("Failed to purge audit logs", err)
+		return 0, err
+	}
+
+	if count > 0 {
+		audit.Record(audit.Event{
+			Action: models.AuditActionAuditPurged,
+			Details: map[string]interface{}{
+				"before":         before,
+				"retention_days": days,
+				"deleted":        count,
+			},
+		})
+	}
+
+	s.logger.Info("Purged audit logs", utils.Field{Key: "count", Value: count})
+	return count, nil
 }
@@ -0,0 +1,328 @@
+package dispute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Handler handles dispute HTTP requests
+type Handler struct {
+	repo    *Repository
+	service *Service
+}
+
+// NewHandler creates a new dispute handler
+func NewHandler(repo *Repository, service *Service) *Handler {
+	return &Handler{repo: repo, service: service}
+}
+
+// Helper to get the authenticated user from context
+func getUser(c *fiber.Ctx) (models.User, error) {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return models.User{}, fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+	return user, nil
+}
+
+// Helper to check whether the caller acts for the merchant with a permission (see middleware.MerchantContext)
+func actsForMerchant(c *fiber.Ctx, merchantID uuid.UUID, permission string) bool {
+	id, ok := c.Locals("merchant_id").(uuid.UUID)
+	if !ok || id != merchantID {
+		return false
+	}
+	role, _ := c.Locals("merchant_role").(string)
+	return models.OrgRoleHasPermission(role, permission)
+}
+
+// serviceError passes client errors through and hides internal ones
+func serviceError(err error, message string) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr
+	}
+	if err.Error() == "wallet is locked" {
+		return fiber.NewError(fiber.StatusForbidden, "Wallet is locked")
+	}
+	if err.Error() == "wallet is frozen" {
+		return fiber.NewError(fiber.StatusForbidden, "Wallet is frozen")
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, message)
+}
+
+// loadDispute parses the :id param and loads the dispute
+func (h *Handler) loadDispute(c *fiber.Ctx) (*models.Dispute, error) {
+	disputeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid dispute ID")
+	}
+
+	dispute, err := h.repo.GetDisputeByID(disputeID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Dispute not found")
+	}
+	return dispute, nil
+}
+
+// OpenDispute opens a dispute on a payment made by the caller
+func (h *Handler) OpenDispute(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.OpenDisputeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	dispute, err := h.service.Open(user.ID, req)
+	if err != nil {
+		return serviceError(err, "Failed to open dispute")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Dispute opened successfully",
+		"dispute": dispute.ToResponse(),
+	})
+}
+
+// ListDisputes lists disputes the caller opened, or with view=merchant the
+// disputes against the merchant they act for
+func (h *Handler) ListDisputes(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	payerID, merchantID := user.ID, uuid.Nil
+	if c.Query("view", "payer") == "merchant" {
+		id, ok := c.Locals("merchant_id").(uuid.UUID)
+		if !ok || !actsForMerchant(c, id, models.PermReportsRead) {
+			return fiber.NewError(fiber.StatusForbidden, "Access denied")
+		}
+		payerID, merchantID = uuid.Nil, id
+	}
+
+	var req models.ListedRequest
+	req.FromContext(c)
+
+	disputes, total, err := h.repo.GetDisputes(payerID, merchantID, c.Query("status"), req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve disputes")
+	}
+
+	records := make([]interface{}, len(disputes))
+	for i, d := range disputes {
+		records[i] = d.ToResponse()
+	}
+
+	return c.JSON(models.ListedResponse{
+		Records: records,
+		Total:   int(total),
+		Page:    req.Page,
+		Limit:   req.Limit,
+	})
+}
+
+// GetDispute returns a dispute with its evidence
+func (h *Handler) GetDispute(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	dispute, err := h.loadDispute(c)
+	if err != nil {
+		return err
+	}
+
+	// Verify access (payer, merchant staff or platform staff)
+	if dispute.PayerID != user.ID && !user.HasPermission(models.PermReportsView) &&
+		!actsForMerchant(c, dispute.MerchantID, models.PermReportsRead) &&
+		!actsForMerchant(c, dispute.MerchantID, models.PermRefundsIssue) {
+		return fiber.NewError(fiber.StatusForbidden, "Access denied")
+	}
+
+	return c.JSON(dispute.ToResponse())
+}
+
+// UploadEvidence attaches an evidence file from the payer or the merchant
+func (h *Handler) UploadEvidence(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	dispute, err := h.loadDispute(c)
+	if err != nil {
+		return err
+	}
+	if !dispute.IsActive() {
+		return fiber.NewError(fiber.StatusConflict, "Evidence can only be added to active disputes")
+	}
+
+	var party string
+	switch {
+	case dispute.PayerID == user.ID:
+		party = models.DisputePartyPayer
+	case actsForMerchant(c, dispute.MerchantID, models.PermRefundsIssue):
+		party = models.DisputePartyMerchant
+	default:
+		return fiber.NewError(fiber.StatusForbidden, "Access denied")
+	}
+
+	file, err := c.FormFile("evidence")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Evidence file is required")
+	}
+
+	// Validate file size (max 10MB)
+	maxSize := int64(10 * 1024 * 1024)
+	if file.Size > maxSize {
+		return fiber.NewError(fiber.StatusBadRequest, "File size exceeds 10MB limit")
+	}
+
+	ext := ""
+	contentType := file.Header.Get("Content-Type")
+	if strings.Contains(contentType, "pdf") {
+		ext = "pdf"
+	} else if strings.Contains(contentType, "png") {
+		ext = "png"
+	} else if strings.Contains(contentType, "jpeg") || strings.Contains(contentType, "jpg") {
+		ext = "jpg"
+	} else {
+		return fiber.NewError(fiber.StatusBadRequest, "Unsupported file type. Only JPG, PNG, PDF allowed.")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Failed to open file")
+	}
+	defer src.Close()
+
+	objectName := fmt.Sprintf("disputes/%s/%s/%d.%s", dispute.ID.String(), party, time.Now().UnixNano(), ext)
+	fileURL, err := storage.UploadFile(objectName, src, file.Size, contentType)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to upload evidence")
+	}
+
+	evidence := &models.DisputeEvidence{
+		DisputeID:   dispute.ID,
+		UploadedBy:  user.ID,
+		Party:       party,
+		FileName:    file.Filename,
+		FilePath:    fileURL,
+		ContentType: contentType,
+		FileSize:    file.Size,
+	}
+	if note := strings.TrimSpace(c.FormValue("note")); note != "" {
+		evidence.Note = &note
+	}
+
+	if err := h.repo.CreateEvidence(evidence); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to record evidence")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(evidence.ToResponse())
+}
+
+// RespondDispute lets the merchant accept or contest a dispute
+func (h *Handler) RespondDispute(c *fiber.Ctx) error {
+	dispute, err := h.loadDispute(c)
+	if err != nil {
+		return err
+	}
+
+	if !actsForMerchant(c, dispute.MerchantID, models.PermRefundsIssue) {
+		return fiber.NewError(fiber.StatusForbidden, "Access denied")
+	}
+
+	var req models.RespondDisputeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.service.Respond(dispute, req); err != nil {
+		return serviceError(err, "Failed to respond to dispute")
+	}
+
+	return c.JSON(dispute.ToResponse())
+}
+
+// CancelDispute withdraws a dispute opened by the caller
+func (h *Handler) CancelDispute(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	dispute, err := h.loadDispute(c)
+	if err != nil {
+		return err
+	}
+
+	if dispute.PayerID != user.ID {
+		return fiber.NewError(fiber.StatusForbidden, "Access denied")
+	}
+
+	if err := h.service.Cancel(dispute); err != nil {
+		return serviceError(err, "Failed to cancel dispute")
+	}
+
+	return c.JSON(fiber.Map{"message": "Dispute withdrawn successfully"})
+}
+
+// Admin: ListAllDisputes lists disputes across all merchants
+func (h *Handler) ListAllDisputes(c *fiber.Ctx) error {
+	var req models.ListedRequest
+	req.FromContext(c)
+
+	disputes, total, err := h.repo.GetDisputes(uuid.Nil, uuid.Nil, c.Query("status"), req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve disputes")
+	}
+
+	records := make([]interface{}, len(disputes))
+	for i, d := range disputes {
+		records[i] = d.ToResponse()
+	}
+
+	return c.JSON(models.ListedResponse{
+		Records: records,
+		Total:   int(total),
+		Page:    req.Page,
+		Limit:   req.Limit,
+	})
+}
+
+// Admin: DecideDispute resolves a dispute in favor of the payer or the merchant
+func (h *Handler) DecideDispute(c *fiber.Ctx) error {
+	admin, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	dispute, err := h.loadDispute(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.DecideDisputeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.service.Resolve(dispute, req.Outcome, strings.TrimSpace(req.Notes), &admin.ID); err != nil {
+		return serviceError(err, "Failed to resolve dispute")
+	}
+
+	return c.JSON(dispute.ToResponse())
+}
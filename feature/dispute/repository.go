@@ -0,0 +1,133 @@
+package dispute
+
+import (
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles dispute-related database operations
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new dispute repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateDispute creates a new dispute record
+func (r *Repository) CreateDispute(dispute *models.Dispute) error {
+	return r.db.Create(dispute).Error
+}
+
+// GetDisputeByID retrieves a dispute with its evidence
+func (r *Repository) GetDisputeByID(id uuid.UUID) (*models.Dispute, error) {
+	var dispute models.Dispute
+	if err := r.db.Preload("Evidence", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at asc")
+	}).Where("id = ?", id).First(&dispute).Error; err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// HasActiveDispute checks whether a transaction already has an undecided dispute
+func (r *Repository) HasActiveDispute(transactionID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Dispute{}).
+		Where("transaction_id = ? AND status IN ?", transactionID,
+			[]string{models.DisputeStatusOpen, models.DisputeStatusUnderReview}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateDispute updates specific fields of a dispute
+func (r *Repository) UpdateDispute(id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.Model(&models.Dispute{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// CreateEvidence records an evidence file for a dispute
+func (r *Repository) CreateEvidence(evidence *models.DisputeEvidence) error {
+	return r.db.Create(evidence).Error
+}
+
+// GetDisputes retrieves disputes filtered by party and status with pagination.
+// Pass uuid.Nil for a party to leave it unfiltered.
+func (r *Repository) GetDisputes(payerID, merchantID uuid.UUID, status string, req models.ListedRequest) ([]models.Dispute, int64, error) {
+	var disputes []models.Dispute
+	var total int64
+
+	query := r.db.Model(&models.Dispute{})
+	if payerID != uuid.Nil {
+		query = query.Where("payer_id = ?", payerID)
+	}
+	if merchantID != uuid.Nil {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order(req.OrderBy + " " + req.Order).
+		Limit(req.Limit).
+		Offset(offset).
+		Find(&disputes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return disputes, total, nil
+}
+
+// GetExpiredOpenDisputes retrieves open disputes whose merchant response deadline has passed
+func (r *Repository) GetExpiredOpenDisputes(now time.Time) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.Where("status = ? AND response_due_at <= ?", models.DisputeStatusOpen, now).
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// GetOverdueReviews retrieves disputes whose review deadline has passed and
+// whose reviewers have not been alerted
+func (r *Repository) GetOverdueReviews(now time.Time) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.Where("status = ? AND review_due_at <= ? AND review_escalated_at IS NULL", models.DisputeStatusUnderReview, now).
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// MarkReviewEscalated records that reviewers were alerted to an overdue
+// review, returning false if another run already did
+func (r *Repository) MarkReviewEscalated(id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.Dispute{}).
+		Where("id = ? AND review_escalated_at IS NULL", id).
+		Update("review_escalated_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// GetReviewers retrieves the active staff who can decide disputes
+func (r *Repository) GetReviewers() ([]models.User, error) {
+	roles := models.RolesWithPermission(models.PermTransactionsReverse)
+	var users []models.User
+	err := r.db.Where("status = ?", models.AccountStatusActive).
+		Where("role IN ? OR id IN (?)", roles,
+			r.db.Model(&models.RoleAssignment{}).Select("user_id").Where("role IN ?", roles)).
+		Find(&users).Error
+	return users, err
+}
+
+// GetDisputesDueSoon retrieves open disputes due before the cut-off whose merchant has not been reminded
+func (r *Repository) GetDisputesDueSoon(now, cutoff time.Time) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.Where("status = ? AND response_due_at > ? AND response_due_at <= ? AND response_reminder_at IS NULL",
+		models.DisputeStatusOpen, now, cutoff).
+		Find(&disputes).Error
+	return disputes, err
+}
@@ -0,0 +1,458 @@
+package dispute
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/notification"
+	"github.com/Keba777/levpay-backend/feature/settlement"
+	"github.com/Keba777/levpay-backend/feature/transaction"
+	"github.com/Keba777/levpay-backend/feature/wallet"
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// activeStatuses are the dispute statuses that still await a decision
+var activeStatuses = []string{models.DisputeStatusOpen, models.DisputeStatusUnderReview}
+
+// Service moves disputes through their lifecycle and the funds that go with them
+type Service struct {
+	db     *gorm.DB
+	repo   *Repository
+	logger *utils.Logger
+}
+
+// NewService creates a new dispute service
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		db:     db,
+		repo:   NewRepository(db),
+		logger: utils.GetLogger("dispute"),
+	}
+}
+
+// Open disputes a completed merchant payment and holds the disputed amount
+// from the merchant's available balance
+func (s *Service) Open(payerID uuid.UUID, req models.OpenDisputeRequest) (*models.Dispute, error) {
+	if !models.IsDisputeReason(req.Reason) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid dispute reason")
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Description == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Description is required")
+	}
+
+	payment, err := transaction.NewRepository(s.db).GetTransactionByID(req.TransactionID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Transaction not found")
+	}
+	if payment.FromUserID != payerID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Only the payer can dispute this transaction")
+	}
+	if payment.Type != models.TransactionTypePayment || payment.ToUserID == nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Only merchant payments can be disputed")
+	}
+	if payment.Status != models.TransactionStatusCompleted {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Only completed payments can be disputed")
+	}
+	if time.Now().After(payment.CreatedAt.AddDate(0, 0, models.DisputeWindowDays)) {
+		return nil, fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("Payments can only be disputed within %d days", models.DisputeWindowDays))
+	}
+
+	active, err := s.repo.HasActiveDispute(payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, fiber.NewError(fiber.StatusConflict, "This transaction already has an open dispute")
+	}
+
+	dispute := &models.Dispute{
+		TransactionID: payment.ID,
+		PayerID:       payerID,
+		MerchantID:    *payment.ToUserID,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Reason:        req.Reason,
+		Description:   req.Description,
+		Status:        models.DisputeStatusOpen,
+		ResponseDueAt: time.Now().AddDate(0, 0, models.DisputeResponseDays),
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		walletRepo := wallet.NewRepository(tx)
+
+		// Hold what the merchant can cover from its available balance
+		if w, err := walletRepo.GetWalletByUserID(dispute.MerchantID); err == nil {
+			dispute.HeldAmount = round(math.Max(0, math.Min(dispute.Amount, w.Balance-w.HeldBalance)))
+		}
+		if dispute.HeldAmount > 0 {
+			if err := walletRepo.HoldFunds(dispute.MerchantID, dispute.HeldAmount); err != nil {
+				return err
+			}
+		}
+
+		return NewRepository(tx).CreateDispute(dispute)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(dispute.MerchantID, notification.TemplateDisputeOpened, dispute, true, "")
+	s.notify(dispute.PayerID, notification.TemplateDisputeOpened, dispute, false, "")
+
+	return dispute, nil
+}
+
+// Respond records the merchant's answer. Accepting refunds the payer at once;
+// contesting sends the dispute to an admin for a decision.
+func (s *Service) Respond(dispute *models.Dispute, req models.RespondDisputeRequest) error {
+	if dispute.Status != models.DisputeStatusOpen {
+		return fiber.NewError(fiber.StatusConflict, "Dispute is not awaiting a merchant response")
+	}
+
+	if req.Accept {
+		notes := "Accepted by merchant"
+		if req.Response != "" {
+			notes = req.Response
+		}
+		return s.Resolve(dispute, models.DisputeOutcomePayer, notes, nil)
+	}
+
+	response := strings.TrimSpace(req.Response)
+	if response == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "A response is required to contest a dispute")
+	}
+
+	now := time.Now()
+	reviewDue := now.AddDate(0, 0, models.DisputeReviewDays)
+	moved, err := s.transition(s.db, dispute.ID, []string{models.DisputeStatusOpen}, map[string]interface{}{
+		"status":            models.DisputeStatusUnderReview,
+		"merchant_response": response,
+		"responded_at":      now,
+		"review_due_at":     reviewDue,
+	})
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return fiber.NewError(fiber.StatusConflict, "Dispute is not awaiting a merchant response")
+	}
+
+	dispute.Status = models.DisputeStatusUnderReview
+	dispute.MerchantResponse = &response
+	dispute.RespondedAt = &now
+	dispute.ReviewDueAt = &reviewDue
+
+	s.notify(dispute.PayerID, notification.TemplateDisputeResponded, dispute, false, "")
+	return nil
+}
+
+// Cancel withdraws a dispute on the payer's request and releases the held funds
+func (s *Service) Cancel(dispute *models.Dispute) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		moved, err := s.transition(tx, dispute.ID, activeStatuses, map[string]interface{}{
+			"status":      models.DisputeStatusCancelled,
+			"resolved_at": time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if !moved {
+			return fiber.NewError(fiber.StatusConflict, "Dispute is no longer active")
+		}
+
+		if dispute.HeldAmount > 0 {
+			return wallet.NewRepository(tx).ReleaseFunds(dispute.MerchantID, dispute.HeldAmount)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dispute.Status = models.DisputeStatusCancelled
+	s.notify(dispute.MerchantID, notification.TemplateDisputeCancelled, dispute, true, "")
+	return nil
+}
+
+// Resolve closes an active dispute. A payer outcome refunds the payment from the
+// merchant's wallet, drawing on its unreleased reserves when the available
+// balance falls short; a merchant outcome releases the held funds. resolvedBy
+// is nil for automatic resolutions.
+func (s *Service) Resolve(dispute *models.Dispute, outcome, notes string, resolvedBy *uuid.UUID) error {
+	if outcome != models.DisputeOutcomePayer && outcome != models.DisputeOutcomeMerchant {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid outcome. Must be 'payer' or 'merchant'")
+	}
+
+	now := time.Now()
+	var refund *models.Transaction
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":      models.DisputeStatusResolved,
+			"outcome":     outcome,
+			"resolved_at": now,
+		}
+		if notes != "" {
+			updates["resolution_notes"] = notes
+		}
+		if resolvedBy != nil {
+			updates["resolved_by"] = *resolvedBy
+		}
+
+		moved, err := s.transition(tx, dispute.ID, activeStatuses, updates)
+		if err != nil {
+			return err
+		}
+		if !moved {
+			return fiber.NewError(fiber.StatusConflict, "Dispute is no longer active")
+		}
+
+		walletRepo := wallet.NewRepository(tx)
+		if dispute.HeldAmount > 0 {
+			if err := walletRepo.ReleaseFunds(dispute.MerchantID, dispute.HeldAmount); err != nil {
+				return err
+			}
+		}
+		if outcome == models.DisputeOutcomeMerchant {
+			return nil
+		}
+
+		// Refund the payer from the merchant's wallet. Whatever the available
+		// balance cannot cover comes out of the merchant's rolling reserve.
+		w, err := walletRepo.GetWalletByUserID(dispute.MerchantID)
+		if err != nil {
+			return err
+		}
+		if shortfall := round(dispute.Amount - (w.Balance - w.HeldBalance)); shortfall > 0 {
+			drawn, err := settlement.NewRepository(tx).DrawReserves(dispute.MerchantID, shortfall)
+			if err != nil {
+				return err
+			}
+			if drawn > 0 {
+				if err := walletRepo.ReleaseFunds(dispute.MerchantID, drawn); err != nil {
+					return err
+				}
+			}
+		}
+		if err := walletRepo.UpdateBalance(dispute.MerchantID, -dispute.Amount); err != nil {
+			if err.Error() == "insufficient balance" {
+				return fiber.NewError(fiber.StatusConflict, "Merchant balance is insufficient to refund this payment")
+			}
+			return err
+		}
+		if err := walletRepo.UpdateBalance(dispute.PayerID, dispute.Amount); err != nil {
+			return err
+		}
+
+		txRepo := transaction.NewRepository(tx)
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"dispute_id":              dispute.ID,
+			"original_transaction_id": dispute.TransactionID,
+		})
+		description := fmt.Sprintf("Dispute refund for transaction %s", dispute.TransactionID)
+		refund = &models.Transaction{
+			FromUserID:  dispute.MerchantID,
+			ToUserID:    &dispute.PayerID,
+			Amount:      dispute.Amount,
+			Currency:    dispute.Currency,
+			Type:        models.TransactionTypeRefund,
+			Status:      models.TransactionStatusCompleted,
+			Description: &description,
+			Metadata:    datatypes.JSON(metadata),
+		}
+		if err := txRepo.CreateTransaction(refund); err != nil {
+			return err
+		}
+		if err := txRepo.UpdateTransactionStatus(dispute.TransactionID, models.TransactionStatusRefunded); err != nil {
+			return err
+		}
+
+		return NewRepository(tx).UpdateDispute(dispute.ID, map[string]interface{}{"refund_transaction_id": refund.ID})
+	})
+	if err != nil {
+		return err
+	}
+
+	dispute.Status = models.DisputeStatusResolved
+	dispute.Outcome = &outcome
+	dispute.ResolvedAt = &now
+	dispute.ResolvedBy = resolvedBy
+	if notes != "" {
+		dispute.ResolutionNotes = &notes
+	}
+
+	if refund != nil {
+		dispute.RefundTransactionID = &refund.ID
+		// Automatic and merchant-accepted refunds have no admin actor
+		transaction.RecordEvent(audit.Event{
+			ActorID: resolvedBy,
+			Action:  models.AuditActionRefund,
+			Details: map[string]interface{}{
+				"dispute_id":              dispute.ID,
+				"original_transaction_id": dispute.TransactionID,
+			},
+		}, refund)
+	}
+
+	s.notify(dispute.PayerID, notification.TemplateDisputeResolved, dispute, false, notes)
+	s.notify(dispute.MerchantID, notification.TemplateDisputeResolved, dispute, true, notes)
+	return nil
+}
+
+// EnforceDeadlines reminds merchants of response deadlines due within a day,
+// resolves disputes they left unanswered in the payer's favor and alerts
+// reviewers to reviews past their deadline. It returns the number of
+// disputes resolved.
+func (s *Service) EnforceDeadlines(now time.Time) (int64, error) {
+	dueSoon, err := s.repo.GetDisputesDueSoon(now, now.Add(24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	for i := range dueSoon {
+		d := &dueSoon[i]
+		if err := s.repo.UpdateDispute(d.ID, map[string]interface{}{"response_reminder_at": now}); err != nil {
+			s.logger.ErrorWithErr("Failed to record dispute reminder", err, utils.Field{Key: "dispute_id", Value: d.ID})
+			continue
+		}
+		s.notify(d.MerchantID, notification.TemplateDisputeResponseDue, d, true, "")
+	}
+
+	expired, err := s.repo.GetExpiredOpenDisputes(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var resolved int64
+	for i := range expired {
+		d := &expired[i]
+		if err := s.Resolve(d, models.DisputeOutcomePayer, "Merchant did not respond before the deadline", nil); err != nil {
+			s.logger.ErrorWithErr("Failed to resolve expired dispute", err, utils.Field{Key: "dispute_id", Value: d.ID})
+			continue
+		}
+		resolved++
+	}
+
+	if err := s.escalateOverdueReviews(now); err != nil {
+		return resolved, err
+	}
+	return resolved, nil
+}
+
+// escalateOverdueReviews alerts every staff member who can decide disputes
+// to reviews past their deadline, once per dispute
+func (s *Service) escalateOverdueReviews(now time.Time) error {
+	overdue, err := s.repo.GetOverdueReviews(now)
+	if err != nil || len(overdue) == 0 {
+		return err
+	}
+	reviewers, err := s.repo.GetReviewers()
+	if err != nil {
+		return err
+	}
+	if len(reviewers) == 0 {
+		s.logger.Warn("No staff can review overdue disputes", utils.Field{Key: "count", Value: len(overdue)})
+		return nil
+	}
+
+	for i := range overdue {
+		d := &overdue[i]
+		marked, err := s.repo.MarkReviewEscalated(d.ID, now)
+		if err != nil {
+			s.logger.ErrorWithErr("Failed to record dispute escalation", err, utils.Field{Key: "dispute_id", Value: d.ID})
+			continue
+		}
+		if !marked {
+			continue
+		}
+		for _, reviewer := range reviewers {
+			s.notify(reviewer.ID, notification.TemplateDisputeReviewOverdue, d, false, "")
+		}
+		s.logger.Info("Escalated overdue dispute review", utils.Field{Key: "dispute_id", Value: d.ID})
+	}
+	return nil
+}
+
+// transition moves a dispute out of one of the given statuses, returning false
+// if another request changed it first
+func (s *Service) transition(db *gorm.DB, id uuid.UUID, from []string, updates map[string]interface{}) (bool, error) {
+	result := db.Model(&models.Dispute{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+// notify emails a party about a dispute update and records an in-app notification
+func (s *Service) notify(userID uuid.UUID, templateName string, dispute *models.Dispute, toMerchant bool, notes string) {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		s.logger.ErrorWithErr("Failed to load dispute notification recipient", err, utils.Field{Key: "user_id", Value: userID})
+		return
+	}
+
+	outcome := ""
+	if dispute.Outcome != nil {
+		outcome = *dispute.Outcome
+	}
+
+	reviewDueAt := ""
+	if dispute.ReviewDueAt != nil {
+		reviewDueAt = dispute.ReviewDueAt.Format("Jan 2, 2006 15:04")
+	}
+
+	subject, body, err := notification.Render(templateName, map[string]interface{}{
+		"Name":          user.FirstName,
+		"IsMerchant":    toMerchant,
+		"DisputeID":     dispute.ID.String()[:8],
+		"Amount":        dispute.Amount,
+		"Currency":      dispute.Currency,
+		"Reason":        strings.ReplaceAll(dispute.Reason, "_", " "),
+		"ResponseDueAt": dispute.ResponseDueAt.Format("Jan 2, 2006 15:04"),
+		"ReviewDueAt":   reviewDueAt,
+		"PayerWon":      outcome == models.DisputeOutcomePayer,
+		"Notes":         notes,
+		"Link":          fmt.Sprintf("%s/disputes/%s", os.Getenv("FRONTEND_URL"), dispute.ID),
+	})
+	if err != nil {
+		s.logger.ErrorWithErr("Failed to render dispute notification", err, utils.Field{Key: "template", Value: templateName})
+		return
+	}
+
+	rabbitmq.RMQ.Publish(models.Message{
+		From:     config.CFG.MSG.From,
+		FromName: config.CFG.MSG.FromName,
+		To:       []string{user.Email},
+		Subject:  subject,
+		Body:     body,
+	}, config.CFG.RMQ.NotificationQueue)
+
+	now := time.Now()
+	if err := notification.NewRepository(s.db).CreateNotification(&models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationTypeEmail,
+		Title:   subject,
+		Content: body,
+		Status:  models.NotificationStatusSent,
+		SentAt:  &now,
+	}); err != nil {
+		s.logger.ErrorWithErr("Failed to record dispute notification", err, utils.Field{Key: "user_id", Value: userID})
+	}
+}
+
+// round rounds an amount to two decimal places
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -1,12 +1,8 @@
 package kyc
 
 import (
-	"fmt"
-	"strings"
-	"time"
-
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/models"
-	"github.com/Keba777/levpay-backend/internal/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -42,6 +38,9 @@ func (h *Handler) UploadDocument(c *fiber.Ctx) error {
 	if docType == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "Document type is required")
 	}
+	if models.IsKYBDocType(docType) {
+		return fiber.NewError(fiber.StatusBadRequest, "Business documents must be uploaded through merchant onboarding")
+	}
 
 	// Handle File Upload
 	file, err := c.FormFile("document")
@@ -49,50 +48,9 @@ func (h *Handler) UploadDocument(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Document file is required")
 	}
 
-	src, err := file.Open()
+	doc, err := StoreDocument(h.repo, userID, docType, file)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Failed to open file")
-	}
-	defer src.Close()
-
-	// Generate object name
-	// kyc/{userID}/{type}/{timestamp}.ext
-	ext := "jpg"
-	contentType := file.Header.Get("Content-Type")
-	if strings.Contains(contentType, "png") {
-		ext = "png"
-	} else if strings.Contains(contentType, "pdf") {
-		ext = "pdf"
-	} else if strings.Contains(contentType, "jpeg") {
-		ext = "jpeg"
-	} else {
-		return fiber.NewError(fiber.StatusBadRequest, "Unsupported file type. Only JPG, PNG, PDF allowed.")
-	}
-
-	objectName := fmt.Sprintf("kyc/%s/%s/%d.%s", userID.String(), docType, time.Now().Unix(), ext)
-
-	// Upload to MinIO
-	// Note regarding bucket: plan said "kyc-documents", need to ensure config uses that or we override?
-	// The storage util currently uses config.CFG.Minio.Bucket which defaults to "kyc-files"
-	// (based on config.go reading). So "kyc-files" is fine.
-
-	// We use the simpler UploadFile which assumes the configured bucket.
-	fileURL, err := storage.UploadFile(objectName, src, file.Size, contentType)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to upload document")
-	}
-
-	// Create DB Record
-	doc := &models.KYCDocument{
-		UserID:     userID,
-		Type:       docType,
-		FilePath:   fileURL,
-		Status:     models.KYCStatusPending,
-		UploadedAt: time.Now(),
-	}
-
-	if err := h.repo.CreateDocument(doc); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to record document submission")
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -179,9 +137,28 @@ func (h *Handler) ReviewDocument(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid status. Must be 'approved' or 'rejected'")
 	}
 
+	doc, err := h.repo.GetDocumentByID(docID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Document not found")
+	}
+
 	if err := h.repo.UpdateDocumentStatus(docID, req.Status, req.Notes); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update document status")
 	}
 
+	e := audit.Request(c, models.AuditActionKYCReviewed)
+	e.TargetType = models.AuditTargetKYCDocument
+	e.TargetID = &doc.ID
+	e.Details = map[string]interface{}{
+		"user_id": doc.UserID,
+		"type":    doc.Type,
+		"notes":   req.Notes,
+	}
+	e.Changes = audit.Diff(
+		map[string]interface{}{"status": doc.Status},
+		map[string]interface{}{"status": req.Status},
+	)
+	audit.Record(e)
+
 	return c.JSON(fiber.Map{"message": "Document reviewed successfully"})
 }
@@ -21,10 +21,20 @@ func (r *Repository) CreateDocument(doc *models.KYCDocument) error {
 	return r.db.Create(doc).Error
 }
 
-// GetDocumentsByUserID retrieves all documents for a user
+// GetDocumentsByUserID retrieves a user's identity documents, excluding
+// business documents submitted for merchant onboarding
 func (r *Repository) GetDocumentsByUserID(userID uuid.UUID) ([]models.KYCDocument, error) {
 	var docs []models.KYCDocument
-	if err := r.db.Where("user_id = ?", userID).Find(&docs).Error; err != nil {
+	if err := r.db.Where("user_id = ? AND type NOT IN ?", userID, models.KYBDocTypes).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// GetDocumentsByTypes retrieves a user's documents of the given types
+func (r *Repository) GetDocumentsByTypes(userID uuid.UUID, types []string) ([]models.KYCDocument, error) {
+	var docs []models.KYCDocument
+	if err := r.db.Where("user_id = ? AND type IN ?", userID, types).Order("uploaded_at desc").Find(&docs).Error; err != nil {
 		return nil, err
 	}
 	return docs, nil
@@ -39,6 +49,15 @@ func (r *Repository) GetPendingDocuments() ([]models.KYCDocument, error) {
 	return docs, nil
 }
 
+// GetDocumentByID retrieves a document by ID
+func (r *Repository) GetDocumentByID(id uuid.UUID) (*models.KYCDocument, error) {
+	var doc models.KYCDocument
+	if err := r.db.First(&doc, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
 // UpdateDocumentStatus updates the status and notes of a document
 func (r *Repository) UpdateDocumentStatus(id uuid.UUID, status, notes string) error {
 	updates := map[string]interface{}{
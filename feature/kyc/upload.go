@@ -0,0 +1,66 @@
+package kyc
+
+import (
+	"fmt"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// StoreDocument validates an uploaded verification document, stores it in
+// MinIO and records it as pending review. KYB uploads for merchant onboarding
+// go through the same pipeline.
+func StoreDocument(repo *Repository, userID uuid.UUID, docType string, file *multipart.FileHeader) (*models.KYCDocument, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Failed to open file")
+	}
+	defer src.Close()
+
+	// Generate object name
+	// kyc/{userID}/{type}/{timestamp}.ext
+	ext := "jpg"
+	contentType := file.Header.Get("Content-Type")
+	if strings.Contains(contentType, "png") {
+		ext = "png"
+	} else if strings.Contains(contentType, "pdf") {
+		ext = "pdf"
+	} else if strings.Contains(contentType, "jpeg") {
+		ext = "jpeg"
+	} else {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Unsupported file type. Only JPG, PNG, PDF allowed.")
+	}
+
+	objectName := fmt.Sprintf("kyc/%s/%s/%d.%s", userID.String(), docType, time.Now().Unix(), ext)
+
+	// Upload to MinIO
+	// Note regarding bucket: plan said "kyc-documents", need to ensure config uses that or we override?
+	// The storage util currently uses config.CFG.Minio.Bucket which defaults to "kyc-files"
+	// (based on config.go reading). So "kyc-files" is fine.
+
+	// We use the simpler UploadFile which assumes the configured bucket.
+	fileURL, err := storage.UploadFile(objectName, src, file.Size, contentType)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to upload document")
+	}
+
+	// Create DB Record
+	doc := &models.KYCDocument{
+		UserID:     userID,
+		Type:       docType,
+		FilePath:   fileURL,
+		Status:     models.KYCStatusPending,
+		UploadedAt: time.Now(),
+	}
+
+	if err := repo.CreateDocument(doc); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to record document submission")
+	}
+
+	return doc, nil
+}
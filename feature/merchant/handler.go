@@ -0,0 +1,416 @@
+package merchant
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/kyc"
+	"github.com/Keba777/levpay-backend/feature/notification"
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/storage"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	tinPattern          = regexp.MustCompile(`^[0-9]{10}$`)
+	categoryCodePattern = regexp.MustCompile(`^[0-9]{4}$`)
+)
+
+// Handler handles merchant onboarding HTTP requests
+type Handler struct {
+	repo    *Repository
+	kycRepo *kyc.Repository
+	logger  *utils.Logger
+}
+
+// NewHandler creates a new merchant handler
+func NewHandler(repo *Repository, kycRepo *kyc.Repository) *Handler {
+	return &Handler{
+		repo:    repo,
+		kycRepo: kycRepo,
+		logger:  utils.GetLogger("merchant"),
+	}
+}
+
+// Helper to get userID from context
+func getUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+	return user.ID, nil
+}
+
+// GetProfile returns the caller's business profile and KYB documents
+func (h *Handler) GetProfile(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	profile, err := h.repo.GetProfileByUserID(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Business profile not found")
+	}
+
+	docs, err := h.kycRepo.GetDocumentsByTypes(userID, models.KYBDocTypes)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve documents")
+	}
+
+	return c.JSON(fiber.Map{
+		"profile":   profile.ToResponse(),
+		"documents": docs,
+	})
+}
+
+// SaveProfile creates or updates the caller's business profile
+func (h *Handler) SaveProfile(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.MerchantProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	req.LegalName = strings.TrimSpace(req.LegalName)
+	req.TIN = strings.TrimSpace(req.TIN)
+	if req.LegalName == "" || req.AddressLine == "" || req.City == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Legal name, address and city are required")
+	}
+	if !tinPattern.MatchString(req.TIN) {
+		return fiber.NewError(fiber.StatusBadRequest, "TIN must be 10 digits")
+	}
+	if !categoryCodePattern.MatchString(req.CategoryCode) {
+		return fiber.NewError(fiber.StatusBadRequest, "Category code must be a 4-digit merchant category code")
+	}
+	if req.Country == "" {
+		req.Country = "ET"
+	}
+
+	profile, err := h.repo.GetProfileByUserID(userID)
+	if err != nil {
+		profile = &models.MerchantProfile{
+			UserID: userID,
+			Status: models.MerchantStatusDraft,
+		}
+	}
+
+	switch profile.Status {
+	case models.MerchantStatusPending:
+		return fiber.NewError(fiber.StatusConflict, "Profile cannot be changed while under review")
+	case models.MerchantStatusApproved:
+		// Verified legal details are locked once approved
+		if req.LegalName != profile.LegalName || req.TIN != profile.TIN {
+			return fiber.NewError(fiber.StatusConflict, "Legal name and TIN cannot be changed after approval")
+		}
+	}
+
+	profile.LegalName = req.LegalName
+	profile.TradingName = req.TradingName
+	profile.TIN = req.TIN
+	profile.CategoryCode = req.CategoryCode
+	profile.Description = req.Description
+	profile.Website = req.Website
+	profile.Email = req.Email
+	profile.Phone = req.Phone
+	profile.AddressLine = req.AddressLine
+	profile.City = req.City
+	profile.Region = req.Region
+	profile.Country = strings.ToUpper(req.Country)
+
+	if err := h.repo.SaveProfile(profile); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save business profile")
+	}
+
+	return c.JSON(profile.ToResponse())
+}
+
+// UploadLogo uploads the business logo shown on payment pages
+func (h *Handler) UploadLogo(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	profile, err := h.repo.GetProfileByUserID(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Create a business profile first")
+	}
+
+	file, err := c.FormFile("logo")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Logo file is required")
+	}
+
+	// Validate file size (max 2MB)
+	maxSize := int64(2 * 1024 * 1024)
+	if file.Size > maxSize {
+		return fiber.NewError(fiber.StatusBadRequest, "Logo size exceeds 2MB limit")
+	}
+
+	ext := ""
+	contentType := file.Header.Get("Content-Type")
+	if strings.Contains(contentType, "png") {
+		ext = "png"
+	} else if strings.Contains(contentType, "jpeg") || strings.Contains(contentType, "jpg") {
+		ext = "jpg"
+	} else {
+		return fiber.NewError(fiber.StatusBadRequest, "Unsupported file type. Only JPG, PNG allowed.")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Failed to open file")
+	}
+	defer src.Close()
+
+	objectName := fmt.Sprintf("merchants/%s/logo/%d.%s", userID.String(), time.Now().Unix(), ext)
+	logoURL, err := storage.UploadFile(objectName, src, file.Size, contentType)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to upload logo")
+	}
+
+	profile.LogoURL = &logoURL
+	if err := h.repo.SaveProfile(profile); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save business profile")
+	}
+
+	return c.JSON(profile.ToResponse())
+}
+
+// UploadDocument uploads a KYB document through the KYC file pipeline
+func (h *Handler) UploadDocument(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	profile, err := h.repo.GetProfileByUserID(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Create a business profile first")
+	}
+	if profile.Status == models.MerchantStatusPending {
+		return fiber.NewError(fiber.StatusConflict, "Documents cannot be changed while under review")
+	}
+
+	docType := c.FormValue("type")
+	if !models.IsKYBDocType(docType) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid business document type")
+	}
+
+	file, err := c.FormFile("document")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Document file is required")
+	}
+
+	doc, err := kyc.StoreDocument(h.kycRepo, userID, docType, file)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Document uploaded successfully",
+		"document": doc,
+	})
+}
+
+// SubmitApplication sends the business profile for admin review
+func (h *Handler) SubmitApplication(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	profile, err := h.repo.GetProfileByUserID(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Create a business profile first")
+	}
+	if profile.Status != models.MerchantStatusDraft && profile.Status != models.MerchantStatusRejected {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("Application is already %s", profile.Status))
+	}
+
+	docs, err := h.kycRepo.GetDocumentsByTypes(userID, models.KYBDocTypes)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve documents")
+	}
+
+	// Each required type needs at least one document that has not been rejected
+	uploaded := map[string]bool{}
+	for _, d := range docs {
+		if d.Status != models.KYCStatusRejected {
+			uploaded[d.Type] = true
+		}
+	}
+	var missing []string
+	for _, t := range models.RequiredKYBDocTypes {
+		if !uploaded[t] {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Missing required documents: "+strings.Join(missing, ", "))
+	}
+
+	now := time.Now()
+	profile.Status = models.MerchantStatusPending
+	profile.SubmittedAt = &now
+	profile.ReviewNotes = nil
+	if err := h.repo.SaveProfile(profile); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to submit application")
+	}
+
+	return c.JSON(profile.ToResponse())
+}
+
+// GetPublicProfile returns the public profile of an approved merchant for payment pages
+func (h *Handler) GetPublicProfile(c *fiber.Ctx) error {
+	merchantID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant ID")
+	}
+
+	profile, err := h.repo.GetApprovedProfile(merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Merchant not found")
+	}
+
+	return c.JSON(profile.ToPublicResponse())
+}
+
+// Admin: ListApplications lists business profiles, pending ones by default
+func (h *Handler) ListApplications(c *fiber.Ctx) error {
+	status := c.Query("status", models.MerchantStatusPending)
+	if status == "all" {
+		status = ""
+	}
+
+	var req models.ListedRequest
+	req.FromContext(c)
+
+	profiles, total, err := h.repo.GetProfiles(status, req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to list merchant applications")
+	}
+
+	records := make([]interface{}, len(profiles))
+	for i, p := range profiles {
+		records[i] = fiber.Map{
+			"profile": p.ToResponse(),
+			"user":    p.User.PrepareResponse(),
+		}
+	}
+
+	return c.JSON(models.ListedResponse{
+		Records: records,
+		Total:   int(total),
+		Page:    req.Page,
+		Limit:   req.Limit,
+	})
+}
+
+// Admin: GetApplication returns a business profile with its owner and KYB documents
+func (h *Handler) GetApplication(c *fiber.Ctx) error {
+	profileID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid application ID")
+	}
+
+	profile, err := h.repo.GetProfileByID(profileID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Merchant application not found")
+	}
+
+	docs, err := h.kycRepo.GetDocumentsByTypes(profile.UserID, models.KYBDocTypes)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve documents")
+	}
+
+	return c.JSON(fiber.Map{
+		"profile":   profile.ToResponse(),
+		"user":      profile.User.PrepareResponse(),
+		"documents": docs,
+	})
+}
+
+// Admin: ReviewApplication approves or rejects a pending merchant application
+func (h *Handler) ReviewApplication(c *fiber.Ctx) error {
+	reviewerID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	profileID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid application ID")
+	}
+
+	var req models.ReviewMerchantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if req.Status != models.MerchantStatusApproved && req.Status != models.MerchantStatusRejected {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid status. Must be 'approved' or 'rejected'")
+	}
+	if req.Status == models.MerchantStatusRejected && strings.TrimSpace(req.Notes) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Notes are required when rejecting an application")
+	}
+
+	profile, err := h.repo.GetProfileByID(profileID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fiber.NewError(fiber.StatusNotFound, "Merchant application not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve merchant application")
+	}
+	if profile.Status != models.MerchantStatusPending {
+		return fiber.NewError(fiber.StatusConflict, "Only pending applications can be reviewed")
+	}
+
+	if err := h.repo.ReviewProfile(profile, req.Status, req.Notes, reviewerID); err != nil {
+		if errors.Is(err, errNotPending) {
+			return fiber.NewError(fiber.StatusConflict, "Only pending applications can be reviewed")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to review merchant application")
+	}
+
+	h.notifyReview(profile, req.Status == models.MerchantStatusApproved, req.Notes)
+
+	return c.JSON(fiber.Map{"message": "Merchant application reviewed successfully"})
+}
+
+// notifyReview emails the applicant the outcome of their review
+func (h *Handler) notifyReview(profile *models.MerchantProfile, approved bool, notes string) {
+	subject, body, err := notification.Render(notification.TemplateMerchantReview, map[string]interface{}{
+		"Name":          profile.User.FirstName,
+		"BusinessName":  profile.DisplayName(),
+		"Approved":      approved,
+		"Notes":         notes,
+		"DashboardLink": os.Getenv("FRONTEND_URL") + "/merchant/onboarding",
+	})
+	if err != nil {
+		h.logger.ErrorWithErr("Failed to render merchant review email", err)
+		return
+	}
+
+	rabbitmq.RMQ.Publish(models.Message{
+		From:     config.CFG.MSG.From,
+		FromName: config.CFG.MSG.FromName,
+		To:       []string{profile.User.Email},
+		Subject:  subject,
+		Body:     body,
+	}, config.CFG.RMQ.NotificationQueue)
+}
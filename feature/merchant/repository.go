@@ -0,0 +1,127 @@
+package merchant
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errNotPending is returned when an application was reviewed in the meantime
+var errNotPending = errors.New("application is no longer pending")
+
+// Repository handles merchant profile database operations
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new merchant repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetProfileByUserID retrieves a user's business profile
+func (r *Repository) GetProfileByUserID(userID uuid.UUID) (*models.MerchantProfile, error) {
+	var profile models.MerchantProfile
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// GetProfileByID retrieves a business profile with its owner
+func (r *Repository) GetProfileByID(id uuid.UUID) (*models.MerchantProfile, error) {
+	var profile models.MerchantProfile
+	if err := r.db.Preload("User").Where("id = ?", id).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// GetApprovedProfile retrieves an approved merchant's profile by user ID
+func (r *Repository) GetApprovedProfile(userID uuid.UUID) (*models.MerchantProfile, error) {
+	var profile models.MerchantProfile
+	if err := r.db.Where("user_id = ? AND status = ?", userID, models.MerchantStatusApproved).
+		First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SaveProfile creates or updates a business profile
+func (r *Repository) SaveProfile(profile *models.MerchantProfile) error {
+	return r.db.Save(profile).Error
+}
+
+// GetProfiles retrieves business profiles filtered by status with pagination
+func (r *Repository) GetProfiles(status string, req models.ListedRequest) ([]models.MerchantProfile, int64, error) {
+	var profiles []models.MerchantProfile
+	var total int64
+
+	query := r.db.Model(&models.MerchantProfile{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Preload("User").
+		Order(req.OrderBy + " " + req.Order).
+		Limit(req.Limit).
+		Offset(offset).
+		Find(&profiles).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return profiles, total, nil
+}
+
+// ReviewProfile records an admin decision on a pending application. Approval
+// promotes the owner to the merchant role; both outcomes settle the pending
+// KYB documents. It returns errNotPending if another review got there first.
+func (r *Repository) ReviewProfile(profile *models.MerchantProfile, status, notes string, reviewerID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":      status,
+			"reviewed_at": now,
+			"reviewed_by": reviewerID,
+		}
+		if notes != "" {
+			updates["review_notes"] = notes
+		}
+		result := tx.Model(&models.MerchantProfile{}).
+			Where("id = ? AND status = ?", profile.ID, models.MerchantStatusPending).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return errNotPending
+		}
+
+		docStatus := models.KYCStatusRejected
+		if status == models.MerchantStatusApproved {
+			docStatus = models.KYCStatusApproved
+			// Never demote an admin who also runs a merchant account
+			if err := tx.Model(&models.User{}).
+				Where("id = ? AND role = ?", profile.UserID, models.RoleUser).
+				Update("role", models.RoleMerchant).Error; err != nil {
+				return err
+			}
+		}
+
+		docUpdates := map[string]interface{}{"status": docStatus}
+		if notes != "" {
+			docUpdates["notes"] = notes
+		}
+		return tx.Model(&models.KYCDocument{}).
+			Where("user_id = ? AND type IN ? AND status = ?", profile.UserID, models.KYBDocTypes, models.KYCStatusPending).
+			Updates(docUpdates).Error
+	})
+}
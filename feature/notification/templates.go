@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Template name constants
+const (
+	TemplateInvoiceReminder   = "invoice_reminder"
+	TemplateInvoiceOverdue    = "invoice_overdue"
+	TemplateMerchantReview    = "merchant_review"
+	TemplateTeamInvitation    = "team_invitation"
+	TemplateEmailVerification = "email_verification"
+	TemplateAccountLocked     = "account_locked"
+	TemplateSessionRevoked    = "session_revoked"
+
+	TemplateDisputeOpened        = "dispute_opened"
+	TemplateDisputeResponded     = "dispute_responded"
+	TemplateDisputeResponseDue   = "dispute_response_due"
+	TemplateDisputeResolved      = "dispute_resolved"
+	TemplateDisputeCancelled     = "dispute_cancelled"
+	TemplateDisputeReviewOverdue = "dispute_review_overdue"
+)
+
+// messageTemplate pairs a subject and body template
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var templates = map[string]messageTemplate{}
+
+// register parses and stores a message template, panicking on invalid syntax
+func register(name, subject, body string) {
+	templates[name] = messageTemplate{
+		subject: template.Must(template.New(name + "_subject").Parse(subject)),
+		body:    template.Must(template.New(name + "_body").Parse(body)),
+	}
+}
+
+func init() {
+	register(TemplateInvoiceReminder,
+		`{{if .Overdue}}Payment overdue{{else}}Payment reminder{{end}}: invoice {{.InvoiceNumber}}`,
+		`Hello {{.CustomerName}},
+
+{{if .Overdue}}Invoice {{.InvoiceNumber}} from {{.MerchantName}} for {{printf "%.2f" .Amount}} {{.Currency}} was due on {{.DueDate}} and is now {{.Days}} day(s) overdue.{{else}}Invoice {{.InvoiceNumber}} from {{.MerchantName}} for {{printf "%.2f" .Amount}} {{.Currency}} is due on {{.DueDate}} ({{.Days}} day(s) from now).{{end}}
+
+You can pay it from your LevPay wallet:
+
+{{.PayLink}}
+
+If you have already paid, please ignore this email.`)
+
+	register(TemplateInvoiceOverdue,
+		`Invoice {{.InvoiceNumber}} is now overdue`,
+		`Hello {{.CustomerName}},
+
+Invoice {{.InvoiceNumber}} from {{.MerchantName}} for {{printf "%.2f" .Amount}} {{.Currency}} was due on {{.DueDate}} and has been marked as overdue.
+
+Please settle it as soon as possible:
+
+{{.PayLink}}`)
+
+	register(TemplateMerchantReview,
+		`{{if .Approved}}Your merchant account is approved{{else}}Your merchant application needs attention{{end}}`,
+		`Hello {{.Name}},
+
+{{if .Approved}}{{.BusinessName}} has been approved as a LevPay merchant. You can now create invoices and accept payments.{{else}}We could not approve the merchant application for {{.BusinessName}}.{{if .Notes}}
+
+Reviewer notes: {{.Notes}}{{end}}
+
+You can update your business profile and documents and submit the application again.{{end}}
+
+{{.DashboardLink}}`)
+
+	register(TemplateTeamInvitation,
+		`{{.InviterName}} invited you to join {{.OrganizationName}} on LevPay`,
+		`Hello,
+
+{{.InviterName}} has invited you to join {{.OrganizationName}} on LevPay as {{.Role}}.
+
+Accept the invitation using the link below. You will need to sign in or create an account with this email address ({{.Email}}).
+
+{{.AcceptLink}}
+
+This invitation expires on {{.ExpiresAt}}. If you were not expecting it, you can ignore this email.`)
+
+	register(TemplateEmailVerification,
+		`Verify your LevPay email address`,
+		`Hello {{.Name}},
+
+Please confirm that {{.Email}} is your email address using the link below:
+
+{{.VerifyLink}}
+
+The link expires in {{.ExpiresHours}} hours. You need a verified email address to send, receive or withdraw money.
+
+If you did not create a LevPay account, please ignore this email.`)
+
+	register(TemplateAccountLocked,
+		`Your LevPay account was temporarily locked`,
+		`Hello {{.Name}},
+
+We locked your LevPay account for {{.Minutes}} minutes after {{.Attempts}} failed sign-in attempts. The last attempt came from {{.IPAddress}}.
+
+If this was you, you can unlock your account now (link valid for {{.ExpiresHours}} hours):
+
+{{.UnlockLink}}
+
+If this was not you, someone may be trying to guess your password. We recommend resetting it:
+
+{{.ForgotLink}}`)
+
+	register(TemplateSessionRevoked,
+		`Security alert: we signed you out of LevPay`,
+		`Hello {{.Name}},
+
+An old sign-in token for your LevPay account was used again from {{.IPAddress}} at {{.Time}}. This can mean the token was copied from one of your devices, so we signed out {{.Sessions}} session(s) that came from the same sign-in.
+
+Please sign in again. If you did not recognize this activity, change your password:
+
+{{.ForgotLink}}`)
+
+	register(TemplateDisputeOpened,
+		`Dispute {{.DisputeID}} opened for {{printf "%.2f" .Amount}} {{.Currency}}`,
+		`Hello {{.Name}},
+
+{{if .IsMerchant}}A customer has disputed a payment of {{printf "%.2f" .Amount}} {{.Currency}} ({{.Reason}}). The disputed amount is on hold in your wallet.
+
+Please accept the dispute or respond with evidence by {{.ResponseDueAt}}. If you do not respond in time, the payment will be refunded automatically.{{else}}Your dispute of a {{printf "%.2f" .Amount}} {{.Currency}} payment ({{.Reason}}) has been opened. The merchant has until {{.ResponseDueAt}} to respond. You can add evidence while the dispute is open.{{end}}
+
+{{.Link}}`)
+
+	register(TemplateDisputeResponded,
+		`The merchant responded to dispute {{.DisputeID}}`,
+		`Hello {{.Name}},
+
+The merchant has contested your dispute of a {{printf "%.2f" .Amount}} {{.Currency}} payment. Our team will review the evidence from both sides and decide the outcome. You can still add evidence while the review is in progress.
+
+{{.Link}}`)
+
+	register(TemplateDisputeResponseDue,
+		`Action required: dispute {{.DisputeID}} is due {{.ResponseDueAt}}`,
+		`Hello {{.Name}},
+
+A dispute of a {{printf "%.2f" .Amount}} {{.Currency}} payment is still awaiting your response. If you do not accept or contest it by {{.ResponseDueAt}}, the payment will be refunded to the customer automatically.
+
+{{.Link}}`)
+
+	register(TemplateDisputeResolved,
+		`Dispute {{.DisputeID}} has been resolved`,
+		`Hello {{.Name}},
+
+{{if .PayerWon}}{{if .IsMerchant}}The dispute was resolved in the customer's favor and {{printf "%.2f" .Amount}} {{.Currency}} has been refunded from your wallet.{{else}}The dispute was resolved in your favor and {{printf "%.2f" .Amount}} {{.Currency}} has been refunded to your wallet.{{end}}{{else}}{{if .IsMerchant}}The dispute was resolved in your favor and the held {{printf "%.2f" .Amount}} {{.Currency}} has been released.{{else}}The dispute was resolved in the merchant's favor, so the payment stands.{{end}}{{end}}{{if .Notes}}
+
+Notes: {{.Notes}}{{end}}
+
+{{.Link}}`)
+
+	register(TemplateDisputeCancelled,
+		`Dispute {{.DisputeID}} was withdrawn`,
+		`Hello {{.Name}},
+
+The customer has withdrawn their dispute of a {{printf "%.2f" .Amount}} {{.Currency}} payment. Any held funds have been released to your wallet.
+
+{{.Link}}`)
+
+	register(TemplateDisputeReviewOverdue,
+		`Review overdue: dispute {{.DisputeID}}`,
+		`Hello {{.Name}},
+
+The review of a disputed {{printf "%.2f" .Amount}} {{.Currency}} payment ({{.Reason}}) was due {{.ReviewDueAt}} and has not been decided. The disputed amount stays on hold until it is.
+
+{{.Link}}`)
+}
+
+// Render executes the named template with data and returns the subject and body
+func Render(name string, data interface{}) (string, string, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown template: %s", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("failed to render body: %w", err)
+	}
+
+	return subject.String(), body.String(), nil
+}
@@ -0,0 +1,65 @@
+package organization
+
+import (
+	"encoding/json"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Merchant Audit Action Constants
+const (
+	ActionInvoiceCreated            = "invoice.created"
+	ActionInvoiceCancelled          = "invoice.cancelled"
+	ActionReminderSettingsUpdated   = "reminder_settings.updated"
+	ActionSettlementSettingsUpdated = "settlement_settings.updated"
+	ActionMemberInvited             = "member.invited"
+	ActionMemberJoined              = "member.joined"
+	ActionMemberRoleChanged         = "member.role_changed"
+	ActionMemberRemoved             = "member.removed"
+)
+
+// RecordAction writes an audit entry for an action the caller took on behalf
+// of the request's merchant. Failures are logged and never block the action.
+func RecordAction(db *gorm.DB, c *fiber.Ctx, action, resourceType, resourceID string, details map[string]interface{}) {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return
+	}
+
+	merchantID, ok := c.Locals("merchant_id").(uuid.UUID)
+	if !ok {
+		merchantID = user.ID
+	}
+	role, ok := c.Locals("merchant_role").(string)
+	if !ok {
+		role = models.OrgRoleOwner
+	}
+
+	entry := &models.MerchantAuditLog{
+		MerchantID:   merchantID,
+		ActorID:      user.ID,
+		ActorRole:    role,
+		Action:       action,
+		ResourceType: resourceType,
+		IPAddress:    c.IP(),
+	}
+	if resourceID != "" {
+		entry.ResourceID = &resourceID
+	}
+	if details != nil {
+		raw, _ := json.Marshal(details)
+		entry.Details = datatypes.JSON(raw)
+	}
+
+	if err := NewRepository(db).CreateAuditLog(entry); err != nil {
+		utils.GetLogger("organization").ErrorWithErr("Failed to record merchant audit entry", err,
+			utils.Field{Key: "action", Value: action},
+			utils.Field{Key: "merchant_id", Value: merchantID},
+		)
+	}
+}
@@ -0,0 +1,413 @@
+package organization
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/notification"
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/rabbitmq"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// inviteExpiry is how long an invitation link stays valid
+const inviteExpiry = 7 * 24 * time.Hour
+
+// Handler handles merchant team HTTP requests
+type Handler struct {
+	repo   *Repository
+	db     *gorm.DB
+	logger *utils.Logger
+}
+
+// NewHandler creates a new organization handler
+func NewHandler(repo *Repository, db *gorm.DB) *Handler {
+	return &Handler{
+		repo:   repo,
+		db:     db,
+		logger: utils.GetLogger("organization"),
+	}
+}
+
+// Helper to get the authenticated user from context
+func getUser(c *fiber.Ctx) (models.User, error) {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return models.User{}, fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+	return user, nil
+}
+
+// Helper to get the merchant the request acts on behalf of
+func getMerchantID(c *fiber.Ctx) (uuid.UUID, error) {
+	merchantID, ok := c.Locals("merchant_id").(uuid.UUID)
+	if !ok {
+		return uuid.Nil, fiber.NewError(fiber.StatusForbidden, "Merchant context required")
+	}
+	return merchantID, nil
+}
+
+// hashToken returns the hex SHA-256 of an invitation token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// organizationFor returns the merchant's organization, creating it with the
+// merchant as owner on first use
+func (h *Handler) organizationFor(merchantID uuid.UUID) (*models.MerchantOrganization, error) {
+	org, err := h.repo.GetOrganization(merchantID)
+	if err == nil {
+		return org, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve organization")
+	}
+
+	var owner models.User
+	if err := h.db.First(&owner, "id = ?", merchantID).Error; err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Merchant not found")
+	}
+	if owner.Role != models.RoleMerchant {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Only merchant accounts can have team members")
+	}
+
+	name := strings.TrimSpace(owner.FirstName + " " + owner.LastName)
+	var profile models.MerchantProfile
+	if err := h.db.Where("user_id = ?", merchantID).First(&profile).Error; err == nil {
+		name = profile.DisplayName()
+	}
+
+	org = &models.MerchantOrganization{MerchantID: merchantID, Name: name}
+	if err := h.repo.CreateOrganization(org, &owner); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create organization")
+	}
+	return org, nil
+}
+
+// GetTeam returns the organization and its members
+func (h *Handler) GetTeam(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	org, err := h.organizationFor(merchantID)
+	if err != nil {
+		return err
+	}
+
+	members, err := h.repo.GetMembers(merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve team members")
+	}
+
+	responses := make([]models.MerchantMemberResponse, len(members))
+	for i, m := range members {
+		responses[i] = m.ToResponse()
+	}
+
+	return c.JSON(fiber.Map{
+		"organization_id": org.ID,
+		"merchant_id":     org.MerchantID,
+		"name":            org.Name,
+		"members":         responses,
+	})
+}
+
+// InviteMember invites a staff member by email
+func (h *Handler) InviteMember(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.InviteMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if req.Email == "" || !strings.Contains(req.Email, "@") {
+		return fiber.NewError(fiber.StatusBadRequest, "A valid email is required")
+	}
+	if !models.IsAssignableOrgRole(req.Role) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role. Must be 'admin', 'accountant' or 'cashier'")
+	}
+
+	org, err := h.organizationFor(merchantID)
+	if err != nil {
+		return err
+	}
+
+	member, err := h.repo.GetMemberByEmail(merchantID, req.Email)
+	if err == nil && member.Status == models.MemberStatusActive {
+		return fiber.NewError(fiber.StatusConflict, "This person is already a team member")
+	}
+	if err != nil {
+		member = &models.MerchantMember{
+			OrganizationID: org.ID,
+			MerchantID:     merchantID,
+			Email:          req.Email,
+		}
+	}
+
+	// Re-inviting refreshes the link and invalidates the previous one
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to generate invitation")
+	}
+	token := hex.EncodeToString(buf)
+	tokenHash := hashToken(token)
+	expiresAt := time.Now().Add(inviteExpiry)
+
+	member.Role = req.Role
+	member.Status = models.MemberStatusInvited
+	member.UserID = nil
+	member.JoinedAt = nil
+	member.InvitedBy = &user.ID
+	member.InviteTokenHash = &tokenHash
+	member.InviteExpiresAt = &expiresAt
+
+	if err := h.repo.SaveMember(member); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create invitation")
+	}
+
+	subject, body, err := notification.Render(notification.TemplateTeamInvitation, map[string]interface{}{
+		"InviterName":      strings.TrimSpace(user.FirstName + " " + user.LastName),
+		"OrganizationName": org.Name,
+		"Role":             req.Role,
+		"Email":            req.Email,
+		"AcceptLink":       fmt.Sprintf("%s/merchant/invitations/accept?token=%s", os.Getenv("FRONTEND_URL"), token),
+		"ExpiresAt":        expiresAt.Format("Jan 2, 2006"),
+	})
+	if err != nil {
+		h.logger.ErrorWithErr("Failed to render invitation email", err)
+	} else {
+		rabbitmq.RMQ.Publish(models.Message{
+			From:     config.CFG.MSG.From,
+			FromName: config.CFG.MSG.FromName,
+			To:       []string{req.Email},
+			Subject:  subject,
+			Body:     body,
+		}, config.CFG.RMQ.NotificationQueue)
+	}
+
+	RecordAction(h.db, c, ActionMemberInvited, "member", member.ID.String(), map[string]interface{}{
+		"email": req.Email,
+		"role":  req.Role,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(member.ToResponse())
+}
+
+// UpdateMemberRole changes a team member's role
+func (h *Handler) UpdateMemberRole(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	memberID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid member ID")
+	}
+
+	var req models.UpdateMemberRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	if !models.IsAssignableOrgRole(req.Role) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid role. Must be 'admin', 'accountant' or 'cashier'")
+	}
+
+	member, err := h.repo.GetMemberByID(merchantID, memberID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Team member not found")
+	}
+	if member.Role == models.OrgRoleOwner {
+		return fiber.NewError(fiber.StatusForbidden, "The owner's role cannot be changed")
+	}
+	if member.Status == models.MemberStatusRevoked {
+		return fiber.NewError(fiber.StatusConflict, "Team member has been removed")
+	}
+
+	previous := member.Role
+	member.Role = req.Role
+	if err := h.repo.SaveMember(member); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update team member")
+	}
+
+	RecordAction(h.db, c, ActionMemberRoleChanged, "member", member.ID.String(), map[string]interface{}{
+		"email": member.Email,
+		"from":  previous,
+		"to":    req.Role,
+	})
+
+	return c.JSON(member.ToResponse())
+}
+
+// RemoveMember revokes a team member's access or cancels their invitation
+func (h *Handler) RemoveMember(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	memberID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid member ID")
+	}
+
+	member, err := h.repo.GetMemberByID(merchantID, memberID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Team member not found")
+	}
+	if member.Role == models.OrgRoleOwner {
+		return fiber.NewError(fiber.StatusForbidden, "The owner cannot be removed")
+	}
+
+	member.Status = models.MemberStatusRevoked
+	member.InviteTokenHash = nil
+	member.InviteExpiresAt = nil
+	if err := h.repo.SaveMember(member); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to remove team member")
+	}
+
+	RecordAction(h.db, c, ActionMemberRemoved, "member", member.ID.String(), map[string]interface{}{
+		"email": member.Email,
+		"role":  member.Role,
+	})
+
+	return c.JSON(fiber.Map{"message": "Team member removed successfully"})
+}
+
+// GetAuditLog lists actions taken on behalf of the merchant
+func (h *Handler) GetAuditLog(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	var actorID *uuid.UUID
+	if raw := c.Query("actor_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid actor ID")
+		}
+		actorID = &id
+	}
+
+	var req models.ListedRequest
+	req.FromContext(c)
+
+	logs, total, err := h.repo.GetAuditLogs(merchantID, c.Query("action"), actorID, req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve audit log")
+	}
+
+	records := make([]interface{}, len(logs))
+	for i, l := range logs {
+		records[i] = l.ToResponse()
+	}
+
+	return c.JSON(models.ListedResponse{
+		Records: records,
+		Total:   int(total),
+		Page:    req.Page,
+		Limit:   req.Limit,
+	})
+}
+
+// AcceptInvitation joins the caller to the organization that invited them
+func (h *Handler) AcceptInvitation(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.AcceptInvitationRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invitation token is required")
+	}
+
+	member, err := h.repo.GetMemberByTokenHash(hashToken(req.Token))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Invitation not found or already used")
+	}
+	if member.InviteExpiresAt == nil || time.Now().After(*member.InviteExpiresAt) {
+		return fiber.NewError(fiber.StatusGone, "Invitation has expired")
+	}
+	if !strings.EqualFold(member.Email, user.Email) {
+		return fiber.NewError(fiber.StatusForbidden, "This invitation was sent to a different email address")
+	}
+	if member.MerchantID == user.ID {
+		return fiber.NewError(fiber.StatusBadRequest, "You already own this merchant account")
+	}
+
+	now := time.Now()
+	member.UserID = &user.ID
+	member.Status = models.MemberStatusActive
+	member.JoinedAt = &now
+	member.InviteTokenHash = nil
+	member.InviteExpiresAt = nil
+	if err := h.repo.SaveMember(member); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to accept invitation")
+	}
+
+	// Attribute the join to the merchant the member now acts for
+	c.Locals("merchant_id", member.MerchantID)
+	c.Locals("merchant_role", member.Role)
+	RecordAction(h.db, c, ActionMemberJoined, "member", member.ID.String(), map[string]interface{}{
+		"email": member.Email,
+		"role":  member.Role,
+	})
+
+	return c.JSON(fiber.Map{
+		"message":     "Invitation accepted",
+		"merchant_id": member.MerchantID,
+		"role":        member.Role,
+	})
+}
+
+// ListMemberships lists the merchants the caller can act on behalf of
+func (h *Handler) ListMemberships(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	members, err := h.repo.GetMemberships(user.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve memberships")
+	}
+
+	memberships := make([]fiber.Map, 0, len(members))
+	for _, m := range members {
+		name := ""
+		if m.Organization != nil {
+			name = m.Organization.Name
+		}
+		memberships = append(memberships, fiber.Map{
+			"merchant_id":       m.MerchantID,
+			"organization_name": name,
+			"role":              m.Role,
+			"permissions":       models.OrgRolePermissions[m.Role],
+		})
+	}
+
+	return c.JSON(memberships)
+}
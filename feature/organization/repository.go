@@ -0,0 +1,135 @@
+package organization
+
+import (
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles merchant organization database operations
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new organization repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetOrganization retrieves the organization of a merchant account
+func (r *Repository) GetOrganization(merchantID uuid.UUID) (*models.MerchantOrganization, error) {
+	var org models.MerchantOrganization
+	if err := r.db.Where("merchant_id = ?", merchantID).First(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// CreateOrganization creates an organization with the merchant account as its owner
+func (r *Repository) CreateOrganization(org *models.MerchantOrganization, owner *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		member := &models.MerchantMember{
+			OrganizationID: org.ID,
+			MerchantID:     org.MerchantID,
+			UserID:         &owner.ID,
+			Email:          owner.Email,
+			Role:           models.OrgRoleOwner,
+			Status:         models.MemberStatusActive,
+			JoinedAt:       &org.CreatedAt,
+		}
+		return tx.Create(member).Error
+	})
+}
+
+// GetMembers retrieves all members of a merchant organization
+func (r *Repository) GetMembers(merchantID uuid.UUID) ([]models.MerchantMember, error) {
+	var members []models.MerchantMember
+	if err := r.db.Preload("User").
+		Where("merchant_id = ?", merchantID).
+		Order("created_at asc").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetMemberByID retrieves a member of a merchant organization
+func (r *Repository) GetMemberByID(merchantID, id uuid.UUID) (*models.MerchantMember, error) {
+	var member models.MerchantMember
+	if err := r.db.Where("merchant_id = ? AND id = ?", merchantID, id).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMemberByEmail retrieves a merchant's member record for an email address
+func (r *Repository) GetMemberByEmail(merchantID uuid.UUID, email string) (*models.MerchantMember, error) {
+	var member models.MerchantMember
+	if err := r.db.Where("merchant_id = ? AND email = ?", merchantID, email).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMemberByTokenHash retrieves a pending invitation by its token hash
+func (r *Repository) GetMemberByTokenHash(hash string) (*models.MerchantMember, error) {
+	var member models.MerchantMember
+	if err := r.db.Preload("Organization").
+		Where("invite_token_hash = ? AND status = ?", hash, models.MemberStatusInvited).
+		First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// SaveMember creates or updates a member record
+func (r *Repository) SaveMember(member *models.MerchantMember) error {
+	return r.db.Save(member).Error
+}
+
+// GetMemberships retrieves the organizations a user actively belongs to
+func (r *Repository) GetMemberships(userID uuid.UUID) ([]models.MerchantMember, error) {
+	var members []models.MerchantMember
+	if err := r.db.Preload("Organization").
+		Where("user_id = ? AND status = ?", userID, models.MemberStatusActive).
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// CreateAuditLog records a merchant audit entry
+func (r *Repository) CreateAuditLog(entry *models.MerchantAuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// GetAuditLogs retrieves a merchant's audit entries with optional filters and pagination
+func (r *Repository) GetAuditLogs(merchantID uuid.UUID, action string, actorID *uuid.UUID, req models.ListedRequest) ([]models.MerchantAuditLog, int64, error) {
+	var logs []models.MerchantAuditLog
+	var total int64
+
+	query := r.db.Model(&models.MerchantAuditLog{}).Where("merchant_id = ?", merchantID)
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if actorID != nil {
+		query = query.Where("actor_id = ?", *actorID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order(req.OrderBy + " " + req.Order).
+		Limit(req.Limit).
+		Offset(offset).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
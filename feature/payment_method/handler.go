@@ -1,20 +1,24 @@
 package payment_method
 
 import (
-	"encoding/json"
+	"errors"
+	"log"
+	"strings"
 
+	"github.com/Keba777/levpay-backend/internal/kms"
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
 	repo *Repository
+	keys kms.KeyManager
 }
 
-func NewHandler(repo *Repository) *Handler {
-	return &Handler{repo: repo}
+func NewHandler(repo *Repository, keys kms.KeyManager) *Handler {
+	return &Handler{repo: repo, keys: keys}
 }
 
 func getUserID(c *fiber.Ctx) (uuid.UUID, error) {
@@ -43,8 +47,8 @@ func (h *Handler) ListPaymentMethods(c *fiber.Ctx) error {
 			Type:      pm.Type,
 			IsDefault: pm.IsDefault,
 			Verified:  pm.Verified,
-			// Simplified details for demo purposes
-			LastFourDigits: "****",
+			// Details stay encrypted; the last four digits identify the method
+			LastFourDigits: pm.LastFour,
 		})
 	}
 
@@ -62,20 +66,38 @@ func (h *Handler) AddPaymentMethod(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	detailsJSON, err := json.Marshal(req.Details)
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid details format")
+	if _, ok := models.PaymentMethodNumberFields[req.Type]; !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid payment method type")
+	}
+	if _, ok := paymentNumber(req.Type, req.Details); !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "Details must include the account, card or phone number")
 	}
 
 	pm := &models.PaymentMethod{
 		UserID:    userID,
 		Type:      req.Type,
-		Details:   datatypes.JSON(detailsJSON),
 		IsDefault: req.IsDefault,
 		Verified:  true, // Auto-verify for demo
 	}
+	if err := SealDetails(h.keys, pm, req.Details); err != nil {
+		log.Printf("[AddPaymentMethod] Failed to seal details: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create payment method")
+	}
+
+	_, err = h.repo.GetByFingerprint(userID, pm.Fingerprint)
+	if err == nil {
+		return fiber.NewError(fiber.StatusConflict, "This payment method is already linked")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[AddPaymentMethod] Failed to check for a linked payment method: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create payment method")
+	}
 
 	if err := h.repo.Create(pm); err != nil {
+		// The unique index catches a parallel request linking the same number
+		if strings.Contains(err.Error(), "duplicate key") {
+			return fiber.NewError(fiber.StatusConflict, "This payment method is already linked")
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create payment method")
 	}
 
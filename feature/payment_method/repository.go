@@ -40,6 +40,15 @@ func (r *Repository) GetDefaultByUserID(userID uuid.UUID) (*models.PaymentMethod
 	return &pm, nil
 }
 
+// GetByFingerprint finds a user's payment method by the fingerprint of its number
+func (r *Repository) GetByFingerprint(userID uuid.UUID, fingerprint string) (*models.PaymentMethod, error) {
+	var pm models.PaymentMethod
+	if err := r.db.Where("user_id = ? AND fingerprint = ?", userID, fingerprint).First(&pm).Error; err != nil {
+		return nil, err
+	}
+	return &pm, nil
+}
+
 func (r *Repository) Update(pm *models.PaymentMethod) error {
 	return r.db.Save(pm).Error
 }
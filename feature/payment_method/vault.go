@@ -0,0 +1,174 @@
+package payment_method
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Keba777/levpay-backend/internal/kms"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const reencryptBatchSize = 100
+
+// forbiddenDetails are card security codes, which must never be stored, not
+// even encrypted
+var forbiddenDetails = []string{"cvv", "cvc", "cvv2", "security_code"}
+
+// ReencryptResult summarises a re-encryption run
+type ReencryptResult struct {
+	ActiveKeyID string
+	Checked     int
+	Reencrypted int
+	Failed      int
+}
+
+// paymentNumber finds the number identifying a payment method and normalises
+// it to upper-case letters and digits
+func paymentNumber(methodType string, details map[string]interface{}) (string, bool) {
+	for _, field := range models.PaymentMethodNumberFields[methodType] {
+		value, ok := details[field].(string)
+		if !ok {
+			continue
+		}
+		number := strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) || unicode.IsLetter(r) {
+				return unicode.ToUpper(r)
+			}
+			return -1
+		}, value)
+		if len(number) >= 4 {
+			return number, true
+		}
+	}
+	return "", false
+}
+
+// SealDetails encrypts a payment method's details and sets the fingerprint and
+// last four digits of its number. Card security codes are dropped.
+func SealDetails(km kms.KeyManager, pm *models.PaymentMethod, details map[string]interface{}) error {
+	for _, field := range forbiddenDetails {
+		delete(details, field)
+	}
+
+	pm.Fingerprint, pm.LastFour = "", ""
+	if number, ok := paymentNumber(pm.Type, details); ok {
+		// The type keeps an account number from matching an equal phone number
+		pm.Fingerprint = km.Fingerprint(pm.Type + ":" + number)
+		pm.LastFour = number[len(number)-4:]
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode details: %w", err)
+	}
+	env, err := kms.Seal(km, data, pm.UserID[:])
+	if err != nil {
+		return fmt.Errorf("failed to encrypt details: %w", err)
+	}
+
+	pm.EncryptedDetails = env.Ciphertext
+	pm.EncryptedKey = env.EncryptedKey
+	pm.KeyID = env.KeyID
+	pm.Details = nil
+	return nil
+}
+
+// OpenDetails decrypts a payment method's details. Rows written before
+// encryption are read from their legacy plaintext column.
+func OpenDetails(km kms.KeyManager, pm *models.PaymentMethod) (map[string]interface{}, error) {
+	var data []byte
+	if len(pm.EncryptedDetails) == 0 {
+		data = pm.Details
+	} else {
+		var err error
+		data, err = kms.Open(km, &kms.Envelope{
+			KeyID:        pm.KeyID,
+			EncryptedKey: pm.EncryptedKey,
+			Ciphertext:   pm.EncryptedDetails,
+		}, pm.UserID[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt details: %w", err)
+		}
+	}
+
+	details := map[string]interface{}{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &details); err != nil {
+			return nil, fmt.Errorf("failed to decode details: %w", err)
+		}
+	}
+	return details, nil
+}
+
+// Reencrypt seals every payment method not yet under the active key with a
+// fresh data key, including legacy plaintext rows and deleted rows, so that
+// retired keys can be removed. Rows that cannot be decrypted are reported
+// through onError and left as they are.
+func Reencrypt(db *gorm.DB, km kms.KeyManager, onError func(id uuid.UUID, err error)) (*ReencryptResult, error) {
+	active := km.ActiveKeyID()
+	result := &ReencryptResult{ActiveKeyID: active}
+
+	var last uuid.UUID
+	for {
+		var methods []models.PaymentMethod
+		err := db.Unscoped().
+			Where("id > ?", last).
+			Where("COALESCE(key_id, '') <> ? OR encrypted_details IS NULL OR details IS NOT NULL", active).
+			Order("id asc").Limit(reencryptBatchSize).
+			Find(&methods).Error
+		if err != nil {
+			return result, fmt.Errorf("failed to read payment methods: %w", err)
+		}
+
+		for i := range methods {
+			pm := &methods[i]
+			result.Checked++
+			if err := reencrypt(db, km, pm); err != nil {
+				result.Failed++
+				onError(pm.ID, err)
+				continue
+			}
+			result.Reencrypted++
+		}
+
+		if len(methods) < reencryptBatchSize {
+			return result, nil
+		}
+		last = methods[len(methods)-1].ID
+	}
+}
+
+// reencrypt seals one payment method again under the active key. The update
+// only applies if the row still holds what was read.
+func reencrypt(db *gorm.DB, km kms.KeyManager, pm *models.PaymentMethod) error {
+	details, err := OpenDetails(km, pm)
+	if err != nil {
+		return err
+	}
+	previousKeyID := pm.KeyID
+	if err := SealDetails(km, pm, details); err != nil {
+		return err
+	}
+
+	update := db.Unscoped().Model(&models.PaymentMethod{}).
+		Where("id = ? AND COALESCE(key_id, '') = ?", pm.ID, previousKeyID).
+		Updates(map[string]interface{}{
+			"encrypted_details": pm.EncryptedDetails,
+			"encrypted_key":     pm.EncryptedKey,
+			"key_id":            pm.KeyID,
+			"fingerprint":       pm.Fingerprint,
+			"last_four":         pm.LastFour,
+			"details":           gorm.Expr("NULL"),
+		})
+	if update.Error != nil {
+		return fmt.Errorf("failed to save payment method: %w", update.Error)
+	}
+	if update.RowsAffected == 0 {
+		return fmt.Errorf("payment method changed while it was re-encrypted")
+	}
+	return nil
+}
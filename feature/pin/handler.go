@@ -0,0 +1,161 @@
+package pin
+
+import (
+	"log"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles transaction PIN HTTP requests
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new PIN handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Helper to get the user from context
+func getUser(c *fiber.Ctx) (models.User, error) {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return models.User{}, fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+	return user, nil
+}
+
+// GetStatus reports whether a PIN is set and whether it is locked
+func (h *Handler) GetStatus(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	resp := models.PINStatusResponse{
+		HasPIN:    user.PINHash != nil,
+		Locked:    user.IsPINLocked(time.Now()),
+		Threshold: h.service.policy.PINThreshold,
+	}
+	if resp.Locked {
+		resp.LockedUntil = user.PINLockedUntil
+	}
+	return c.JSON(resp)
+}
+
+// SetPIN sets the user's first transaction PIN. Like ResetPIN it needs the
+// account password, and the route adds a step-up for users with 2FA.
+func (h *Handler) SetPIN(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.SetPINRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if user.PINHash != nil {
+		return fiber.NewError(fiber.StatusConflict, "Transaction PIN already set")
+	}
+	if user.PasswordHash == nil {
+		// OAuth-only accounts have no password; the 2FA step-up stands in for it
+		if !user.TwoFAEnabled {
+			return fiber.NewError(fiber.StatusBadRequest, "Set a password or enable two-factor authentication to set your PIN")
+		}
+	} else if !utils.PWD.CheckPasswordHash(req.Password, *user.PasswordHash) {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid password")
+	}
+
+	if err := h.storePIN(&user, req.PIN); err != nil {
+		return err
+	}
+	h.service.audit(&user, models.AuditActionPINSet, c.IP(), map[string]interface{}{})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "Transaction PIN set"})
+}
+
+// ChangePIN replaces the PIN after checking the current one
+func (h *Handler) ChangePIN(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ChangePINRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if user.PINHash == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Transaction PIN not set")
+	}
+	attempt, err := h.service.reserveAttempt(&user)
+	if err != nil {
+		return err
+	}
+	if !utils.PWD.CheckPasswordHash(req.CurrentPIN, *user.PINHash) {
+		return h.service.failed(&user, c.IP(), attempt, "Invalid transaction PIN")
+	}
+
+	if err := h.storePIN(&user, req.NewPIN); err != nil {
+		return err
+	}
+	h.service.audit(&user, models.AuditActionPINChanged, c.IP(), map[string]interface{}{})
+
+	return c.JSON(fiber.Map{"message": "Transaction PIN changed"})
+}
+
+// ResetPIN replaces a forgotten or locked PIN. It needs the account password,
+// and the route adds a step-up for users with 2FA.
+func (h *Handler) ResetPIN(c *fiber.Ctx) error {
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ResetPINRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if user.PasswordHash == nil {
+		// OAuth-only accounts have no password; the 2FA step-up stands in for it
+		if !user.TwoFAEnabled {
+			return fiber.NewError(fiber.StatusBadRequest, "Set a password or enable two-factor authentication to reset your PIN")
+		}
+	} else if !utils.PWD.CheckPasswordHash(req.Password, *user.PasswordHash) {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid password")
+	}
+
+	if err := h.storePIN(&user, req.NewPIN); err != nil {
+		return err
+	}
+	h.service.audit(&user, models.AuditActionPINReset, c.IP(), map[string]interface{}{
+		"was_locked": user.IsPINLocked(time.Now()),
+	})
+
+	return c.JSON(fiber.Map{"message": "Transaction PIN reset"})
+}
+
+// storePIN validates, hashes and saves a new PIN
+func (h *Handler) storePIN(user *models.User, pin string) error {
+	if err := validatePIN(pin); err != nil {
+		return err
+	}
+
+	hash, err := utils.PWD.HashPassword(pin)
+	if err != nil {
+		log.Printf("[PIN] Failed to hash pin: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save transaction PIN")
+	}
+	if err := h.service.repo.SetPIN(user.ID, hash); err != nil {
+		log.Printf("[PIN] %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save transaction PIN")
+	}
+	return nil
+}
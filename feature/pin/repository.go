@@ -0,0 +1,72 @@
+package pin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository handles transaction PIN database operations
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new PIN repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// SetPIN stores a new PIN hash and clears any failures and lockout
+func (r *Repository) SetPIN(userID uuid.UUID, hash string) error {
+	err := r.db.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"pin_hash":         hash,
+			"pin_failed_count": 0,
+			"pin_locked_until": nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to set pin: %w", err)
+	}
+	return nil
+}
+
+// ReserveAttempt counts an attempt before the PIN or code is checked, so
+// parallel requests cannot all be evaluated against the same count. It
+// returns the attempt number, or false if the PIN is locked.
+func (r *Repository) ReserveAttempt(userID uuid.UUID) (int, bool, error) {
+	var user models.User
+	result := r.db.Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "pin_failed_count"}}}).
+		Where("id = ? AND (pin_locked_until IS NULL OR pin_locked_until <= NOW())", userID).
+		Update("pin_failed_count", gorm.Expr("pin_failed_count + 1"))
+	if result.Error != nil {
+		return 0, false, fmt.Errorf("failed to reserve pin attempt: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, false, nil
+	}
+	return user.PINFailedCount, true, nil
+}
+
+// LockPIN locks the PIN until the given time and starts the count afresh
+func (r *Repository) LockPIN(userID uuid.UUID, until time.Time) error {
+	err := r.db.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"pin_failed_count": 0,
+			"pin_locked_until": until,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock pin: %w", err)
+	}
+	return nil
+}
+
+// ResetFailures clears the consecutive failure count after a correct PIN
+func (r *Repository) ResetFailures(userID uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ? AND pin_failed_count > 0", userID).
+		Update("pin_failed_count", 0).Error
+}
@@ -0,0 +1,174 @@
+package pin
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/auth"
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/security"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Accepted PIN lengths, in digits
+const (
+	minLength = 4
+	maxLength = 6
+)
+
+// Service checks transaction PINs and second factors for outgoing payments
+type Service struct {
+	repo     *Repository
+	authRepo *auth.Repository
+	policy   *security.Policy
+}
+
+// NewService creates a new PIN service
+func NewService(db *gorm.DB, policy *security.Policy) *Service {
+	return &Service{
+		repo:     NewRepository(db),
+		authRepo: auth.NewRepository(db),
+		policy:   policy,
+	}
+}
+
+// Authorize lets payments at or below the policy threshold through. Larger
+// payments need the user's PIN or, with 2FA enabled, a current TOTP code.
+// Every answer reserves an attempt before it is checked, so wrong answers
+// count towards the PIN lockout even when sent in parallel.
+func (s *Service) Authorize(user *models.User, amount float64, auth models.PaymentAuthorization, ip string) error {
+	if amount <= s.policy.PINThreshold {
+		return nil
+	}
+
+	switch {
+	case auth.PIN != "":
+		if user.PINHash == nil {
+			return fiber.NewError(fiber.StatusForbidden, "Set a transaction PIN to authorize payments")
+		}
+		attempt, err := s.reserveAttempt(user)
+		if err != nil {
+			return err
+		}
+		if utils.PWD.CheckPasswordHash(auth.PIN, *user.PINHash) {
+			s.clearFailures(user)
+			return nil
+		}
+		return s.failed(user, ip, attempt, "Invalid transaction PIN")
+
+	case auth.TwoFactorCode != "":
+		if !user.TwoFAEnabled {
+			return fiber.NewError(fiber.StatusBadRequest, "Two-factor authentication is not enabled")
+		}
+		attempt, err := s.reserveAttempt(user)
+		if err != nil {
+			return err
+		}
+		if s.verifyTOTP(user, auth.TwoFactorCode) {
+			s.clearFailures(user)
+			return nil
+		}
+		return s.failed(user, ip, attempt, "Invalid verification code")
+	}
+
+	if user.PINHash == nil && !user.TwoFAEnabled {
+		return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("Set a transaction PIN to authorize payments over %.2f", s.policy.PINThreshold))
+	}
+	return fiber.NewError(fiber.StatusForbidden, "Transaction PIN required")
+}
+
+// reserveAttempt counts an attempt in the database before the answer is
+// checked. Attempts past the limit are refused while the one that reached it
+// locks the PIN.
+func (s *Service) reserveAttempt(user *models.User) (int, error) {
+	attempt, ok, err := s.repo.ReserveAttempt(user.ID)
+	if err != nil {
+		log.Printf("[PIN] %v", err)
+		return 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to check transaction PIN")
+	}
+	if !ok || attempt > s.policy.PINMaxAttempts {
+		return 0, lockedError()
+	}
+	return attempt, nil
+}
+
+// failed locks the PIN when a wrong answer used the last allowed attempt
+func (s *Service) failed(user *models.User, ip string, attempt int, message string) error {
+	if attempt < s.policy.PINMaxAttempts {
+		return fiber.NewError(fiber.StatusUnauthorized, message)
+	}
+
+	until := time.Now().Add(s.policy.PINLockout)
+	if err := s.repo.LockPIN(user.ID, until); err != nil {
+		// The count stays past the limit, so later attempts are still refused
+		log.Printf("[PIN] %v", err)
+		return lockedError()
+	}
+	s.audit(user, models.AuditActionPINLocked, ip, map[string]interface{}{
+		"locked_until": until,
+	})
+	return lockedError()
+}
+
+// clearFailures resets the failure count once the user gets the PIN right
+func (s *Service) clearFailures(user *models.User) {
+	if err := s.repo.ResetFailures(user.ID); err != nil {
+		log.Printf("[PIN] Failed to reset failures: %v", err)
+	}
+}
+
+// verifyTOTP checks a code through the auth repository, which records its
+// time step so the code cannot be replayed at sign-in either
+func (s *Service) verifyTOTP(user *models.User, code string) bool {
+	ok, err := s.authRepo.VerifyTOTP(user, code)
+	if err != nil {
+		log.Printf("[PIN] Failed to verify code for user %s: %v", user.ID, err)
+	}
+	return ok
+}
+
+// audit records a PIN event on the user's account
+func (s *Service) audit(user *models.User, action, ip string, details map[string]interface{}) {
+	audit.Record(audit.Event{
+		ActorID:    &user.ID,
+		Action:     action,
+		TargetType: models.AuditTargetUser,
+		TargetID:   &user.ID,
+		IPAddress:  ip,
+		Details:    details,
+	})
+}
+
+// lockedError tells the client the PIN is locked and how to recover
+func lockedError() error {
+	return fiber.NewError(fiber.StatusLocked, "Transaction PIN locked after too many wrong attempts. Reset it with your password to continue.")
+}
+
+// validatePIN enforces the PIN format and rejects trivially guessable PINs
+// such as 1111 or 1234
+func validatePIN(pin string) error {
+	if len(pin) < minLength || len(pin) > maxLength {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("PIN must be %d to %d digits", minLength, maxLength))
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return fiber.NewError(fiber.StatusBadRequest, "PIN must contain only digits")
+		}
+	}
+
+	repeated, ascending, descending := true, true, true
+	for i := 1; i < len(pin); i++ {
+		diff := int(pin[i]) - int(pin[i-1])
+		repeated = repeated && diff == 0
+		ascending = ascending && diff == 1
+		descending = descending && diff == -1
+	}
+	if repeated || ascending || descending {
+		return fiber.NewError(fiber.StatusBadRequest, "PIN is too easy to guess")
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package settlement
+
+import (
+	"github.com/Keba777/levpay-backend/feature/organization"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Handler handles settlement HTTP requests
+type Handler struct {
+	repo *Repository
+	db   *gorm.DB
+}
+
+// NewHandler creates a new settlement handler
+func NewHandler(repo *Repository, db *gorm.DB) *Handler {
+	return &Handler{repo: repo, db: db}
+}
+
+// Helper to get userID from context
+func getUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+	return user.ID, nil
+}
+
+// Helper to get the merchant the request acts on behalf of (see middleware.MerchantContext)
+func getMerchantID(c *fiber.Ctx) (uuid.UUID, error) {
+	merchantID, ok := c.Locals("merchant_id").(uuid.UUID)
+	if !ok {
+		return getUserID(c)
+	}
+	return merchantID, nil
+}
+
+// settingResponse shapes settlement settings for API responses
+func settingResponse(setting *models.SettlementSetting) fiber.Map {
+	return fiber.Map{
+		"merchant_id":       setting.MerchantID,
+		"schedule":          setting.Schedule,
+		"weekly_day":        setting.WeeklyDay,
+		"reserve_percent":   setting.ReservePercent,
+		"reserve_hold_days": setting.ReserveHoldDays,
+	}
+}
+
+// GetSettings returns the merchant's settlement schedule and reserve policy
+func (h *Handler) GetSettings(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	setting, err := h.repo.GetSetting(merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve settlement settings")
+	}
+
+	return c.JSON(settingResponse(setting))
+}
+
+// UpdateSettings changes the merchant's settlement schedule
+func (h *Handler) UpdateSettings(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateSettlementSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if req.Schedule != models.SettlementScheduleDaily && req.Schedule != models.SettlementScheduleWeekly {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid schedule. Must be 'daily' or 'weekly'")
+	}
+	if req.WeeklyDay != nil && (*req.WeeklyDay < 0 || *req.WeeklyDay > 6) {
+		return fiber.NewError(fiber.StatusBadRequest, "Weekly day must be between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	setting, err := h.repo.GetSetting(merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve settlement settings")
+	}
+
+	setting.Schedule = req.Schedule
+	if req.WeeklyDay != nil {
+		setting.WeeklyDay = *req.WeeklyDay
+	}
+
+	if err := h.repo.SaveSetting(setting); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update settlement settings")
+	}
+
+	organization.RecordAction(h.db, c, organization.ActionSettlementSettingsUpdated, "settlement_settings", setting.ID.String(), map[string]interface{}{
+		"schedule":   setting.Schedule,
+		"weekly_day": setting.WeeklyDay,
+	})
+
+	return c.JSON(settingResponse(setting))
+}
+
+// ListBatches lists the merchant's settlement batches
+func (h *Handler) ListBatches(c *fiber.Ctx) error {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ListedRequest
+	req.FromContext(c)
+
+	batches, total, err := h.repo.GetMerchantBatches(merchantID, req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve settlement batches")
+	}
+
+	records := make([]interface{}, len(batches))
+	for i, b := range batches {
+		records[i] = b.ToResponse()
+	}
+
+	return c.JSON(models.ListedResponse{
+		Records: records,
+		Total:   int(total),
+		Page:    req.Page,
+		Limit:   req.Limit,
+	})
+}
+
+// GetBatchReport returns a settlement batch with the payments it settled
+func (h *Handler) GetBatchReport(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(models.User)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid user session")
+	}
+
+	batchID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid batch ID")
+	}
+
+	batch, err := h.repo.GetBatchByID(batchID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Settlement batch not found")
+	}
+
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		return err
+	}
+
+	// Verify access (owning merchant or staff who can view reports)
+	if batch.MerchantID != merchantID && !user.HasPermission(models.PermReportsView) {
+		return fiber.NewError(fiber.StatusForbidden, "Access denied")
+	}
+
+	transactions, err := h.repo.GetBatchTransactions(batchID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve settled transactions")
+	}
+
+	responses := make([]models.TransactionResponse, len(transactions))
+	for i, tx := range transactions {
+		responses[i] = tx.ToResponse()
+	}
+
+	return c.JSON(fiber.Map{
+		"batch":        batch.ToResponse(),
+		"transactions": responses,
+	})
+}
+
+// Admin: UpdateReserve sets a merchant's rolling reserve policy
+func (h *Handler) UpdateReserve(c *fiber.Ctx) error {
+	merchantID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant ID")
+	}
+
+	var req models.UpdateReserveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if req.ReservePercent != nil && (*req.ReservePercent < 0 || *req.ReservePercent > 100) {
+		return fiber.NewError(fiber.StatusBadRequest, "Reserve percent must be between 0 and 100")
+	}
+	if req.ReserveHoldDays != nil && (*req.ReserveHoldDays < 1 || *req.ReserveHoldDays > 180) {
+		return fiber.NewError(fiber.StatusBadRequest, "Reserve hold days must be between 1 and 180")
+	}
+
+	setting, err := h.repo.GetSetting(merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve settlement settings")
+	}
+
+	if req.ReservePercent != nil {
+		setting.ReservePercent = *req.ReservePercent
+	}
+	if req.ReserveHoldDays != nil {
+		setting.ReserveHoldDays = *req.ReserveHoldDays
+	}
+
+	if err := h.repo.SaveSetting(setting); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update reserve policy")
+	}
+
+	return c.JSON(settingResponse(setting))
+}
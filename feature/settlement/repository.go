@@ -0,0 +1,226 @@
+package settlement
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository handles settlement-related database operations
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new settlement repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetSetting retrieves a merchant's settlement settings, falling back to defaults
+func (r *Repository) GetSetting(merchantID uuid.UUID) (*models.SettlementSetting, error) {
+	var setting models.SettlementSetting
+	err := r.db.Where("merchant_id = ?", merchantID).First(&setting).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.SettlementSetting{
+			MerchantID:      merchantID,
+			Schedule:        models.SettlementScheduleDaily,
+			WeeklyDay:       int(time.Monday),
+			ReservePercent:  models.DefaultReservePercent,
+			ReserveHoldDays: models.DefaultReserveHoldDays,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// SaveSetting creates or updates a merchant's settlement settings
+func (r *Repository) SaveSetting(setting *models.SettlementSetting) error {
+	var existing models.SettlementSetting
+	err := r.db.Where("merchant_id = ?", setting.MerchantID).First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return r.db.Create(setting).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	setting.ID = existing.ID
+	return r.db.Model(&existing).Updates(map[string]interface{}{
+		"schedule":          setting.Schedule,
+		"weekly_day":        setting.WeeklyDay,
+		"reserve_percent":   setting.ReservePercent,
+		"reserve_hold_days": setting.ReserveHoldDays,
+	}).Error
+}
+
+// GetMerchantIDs retrieves the IDs of all merchant accounts
+func (r *Repository) GetMerchantIDs() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.User{}).Where("role = ?", "merchant").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetLastBatch retrieves the most recent paid batch of a merchant
+func (r *Repository) GetLastBatch(merchantID uuid.UUID) (*models.SettlementBatch, error) {
+	var batch models.SettlementBatch
+	if err := r.db.Where("merchant_id = ? AND status = ?", merchantID, models.SettlementStatusPaid).
+		Order("period_end desc").
+		First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetUnsettledPayments retrieves merchant payments created before a cut-off that
+// no batch has paid out yet. Payments under an active dispute wait for its outcome.
+func (r *Repository) GetUnsettledPayments(merchantID uuid.UUID, before time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	disputed := r.db.Model(&models.Dispute{}).
+		Select("transaction_id").
+		Where("status IN ?", []string{models.DisputeStatusOpen, models.DisputeStatusUnderReview})
+	err := r.db.Where("to_user_id = ? AND type = ? AND status IN ? AND settlement_batch_id IS NULL AND created_at < ?",
+		merchantID,
+		models.TransactionTypePayment,
+		[]string{models.TransactionStatusCompleted, models.TransactionStatusRefunded},
+		before).
+		Where("id NOT IN (?)", disputed).
+		Order("created_at asc").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// GetReleasableReserves retrieves paid batches whose reserve has matured
+func (r *Repository) GetReleasableReserves(merchantID uuid.UUID, now time.Time) ([]models.SettlementBatch, error) {
+	var batches []models.SettlementBatch
+	err := r.db.Where("merchant_id = ? AND status = ? AND reserve_amount > 0 AND reserve_released_at IS NULL AND reserve_release_at <= ?",
+		merchantID, models.SettlementStatusPaid, now).
+		Find(&batches).Error
+	return batches, err
+}
+
+// CreateBatch records a settlement batch
+func (r *Repository) CreateBatch(batch *models.SettlementBatch) error {
+	return r.db.Create(batch).Error
+}
+
+// UpdateBatch updates fields of a settlement batch
+func (r *Repository) UpdateBatch(id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.Model(&models.SettlementBatch{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// AssignTransactions links payments to the batch that settled them
+func (r *Repository) AssignTransactions(batchID uuid.UUID, transactionIDs []uuid.UUID) error {
+	if len(transactionIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Transaction{}).
+		Where("id IN ?", transactionIDs).
+		Update("settlement_batch_id", batchID).Error
+}
+
+// MarkReservesReleased records that matured reserves were paid out. It fails
+// if the reserves no longer add up to the amount released, which happens when
+// a dispute refund drew on one of them while the settlement ran.
+func (r *Repository) MarkReservesReleased(batchIDs []uuid.UUID, released float64, releasedAt time.Time) error {
+	if len(batchIDs) == 0 {
+		return nil
+	}
+
+	var batches []models.SettlementBatch
+	result := r.db.Model(&batches).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "reserve_amount"}}}).
+		Where("id IN ? AND reserve_released_at IS NULL", batchIDs).
+		Update("reserve_released_at", releasedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var total float64
+	for _, b := range batches {
+		total += b.ReserveAmount
+	}
+	if int(result.RowsAffected) != len(batchIDs) || round(total) != round(released) {
+		return fmt.Errorf("reserves changed during settlement")
+	}
+	return nil
+}
+
+// DrawReserves takes up to amount from the merchant's unreleased reserves,
+// oldest first, to cover a dispute refund. Each batch's reserve is lowered by
+// what was taken from it. The caller releases the returned amount from the
+// wallet's held balance in the same transaction.
+func (r *Repository) DrawReserves(merchantID uuid.UUID, amount float64) (float64, error) {
+	var batches []models.SettlementBatch
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("merchant_id = ? AND status = ? AND reserve_amount > 0 AND reserve_released_at IS NULL",
+			merchantID, models.SettlementStatusPaid).
+		Order("created_at asc").
+		Find(&batches).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var drawn float64
+	for _, b := range batches {
+		if round(amount-drawn) <= 0 {
+			break
+		}
+		take := math.Min(b.ReserveAmount, round(amount-drawn))
+		if err := r.UpdateBatch(b.ID, map[string]interface{}{
+			"reserve_amount": round(b.ReserveAmount - take),
+		}); err != nil {
+			return 0, err
+		}
+		drawn += take
+	}
+	return round(drawn), nil
+}
+
+// GetBatchByID retrieves a settlement batch by ID
+func (r *Repository) GetBatchByID(id uuid.UUID) (*models.SettlementBatch, error) {
+	var batch models.SettlementBatch
+	if err := r.db.Where("id = ?", id).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetMerchantBatches retrieves a merchant's settlement batches with pagination
+func (r *Repository) GetMerchantBatches(merchantID uuid.UUID, req models.ListedRequest) ([]models.SettlementBatch, int64, error) {
+	var batches []models.SettlementBatch
+	var total int64
+
+	query := r.db.Model(&models.SettlementBatch{}).Where("merchant_id = ?", merchantID)
+
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination and ordering
+	if err := query.
+		Order(req.OrderBy + " " + req.Order).
+		Limit(req.Limit).
+		Offset(req.Offset).
+		Find(&batches).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return batches, total, nil
+}
+
+// GetBatchTransactions retrieves the payments settled by a batch
+func (r *Repository) GetBatchTransactions(batchID uuid.UUID) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Where("settlement_batch_id = ?", batchID).
+		Order("created_at asc").
+		Find(&transactions).Error
+	return transactions, err
+}
@@ -0,0 +1,223 @@
+package settlement
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Keba777/levpay-backend/feature/payment_method"
+	"github.com/Keba777/levpay-backend/feature/transaction"
+	"github.com/Keba777/levpay-backend/feature/wallet"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Service batches merchant payments into payouts
+type Service struct {
+	db     *gorm.DB
+	repo   *Repository
+	logger *utils.Logger
+}
+
+// NewService creates a new settlement service
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		db:     db,
+		repo:   NewRepository(db),
+		logger: utils.GetLogger("settlement"),
+	}
+}
+
+// RunSettlements settles every merchant that is due on the given day and
+// returns the number of batches paid out
+func (s *Service) RunSettlements(now time.Time) (int64, error) {
+	merchantIDs, err := s.repo.GetMerchantIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	var paid int64
+	for _, merchantID := range merchantIDs {
+		batch, err := s.SettleMerchant(merchantID, now)
+		if err != nil {
+			s.logger.ErrorWithErr("Failed to settle merchant", err, utils.Field{Key: "merchant_id", Value: merchantID})
+			continue
+		}
+		if batch != nil && batch.Status == models.SettlementStatusPaid {
+			paid++
+		}
+	}
+
+	return paid, nil
+}
+
+// SettleMerchant pays out a merchant's unsettled payments up to the start of the
+// given day. It returns nil when the merchant is not due or has nothing to settle.
+func (s *Service) SettleMerchant(merchantID uuid.UUID, now time.Time) (*models.SettlementBatch, error) {
+	setting, err := s.repo.GetSetting(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if setting.Schedule == models.SettlementScheduleWeekly && int(now.Weekday()) != setting.WeeklyDay {
+		return nil, nil
+	}
+
+	// Only settle whole days so late payments land in the next batch
+	periodEnd := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	payments, err := s.repo.GetUnsettledPayments(merchantID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	reserves, err := s.repo.GetReleasableReserves(merchantID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 && len(reserves) == 0 {
+		return nil, nil
+	}
+
+	batch := &models.SettlementBatch{
+		MerchantID:       merchantID,
+		PeriodEnd:        periodEnd,
+		Currency:         "ETB",
+		TransactionCount: len(payments),
+	}
+
+	if last, err := s.repo.GetLastBatch(merchantID); err == nil {
+		batch.PeriodStart = last.PeriodEnd
+	} else if len(payments) > 0 {
+		batch.PeriodStart = payments[0].CreatedAt
+	} else {
+		batch.PeriodStart = periodEnd
+	}
+
+	paymentIDs := make([]uuid.UUID, len(payments))
+	for i, p := range payments {
+		paymentIDs[i] = p.ID
+		batch.GrossAmount += p.Amount
+		batch.FeeAmount += p.Fee
+		if p.Status == models.TransactionStatusRefunded {
+			batch.RefundAmount += p.Amount
+		}
+	}
+	batch.NetAmount = round(batch.GrossAmount - batch.RefundAmount - batch.FeeAmount)
+
+	if batch.NetAmount > 0 && setting.ReservePercent > 0 {
+		batch.ReserveAmount = round(batch.NetAmount * setting.ReservePercent / 100)
+		releaseAt := now.AddDate(0, 0, setting.ReserveHoldDays)
+		batch.ReserveReleaseAt = &releaseAt
+	}
+
+	reserveIDs := make([]uuid.UUID, len(reserves))
+	for i, r := range reserves {
+		reserveIDs[i] = r.ID
+		batch.ReserveReleased += r.ReserveAmount
+	}
+	batch.ReserveReleased = round(batch.ReserveReleased)
+	batch.PayoutAmount = round(batch.NetAmount - batch.ReserveAmount + batch.ReserveReleased)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := NewRepository(tx)
+		walletRepo := wallet.NewRepository(tx)
+		txRepo := transaction.NewRepository(tx)
+
+		var method *models.PaymentMethod
+		if batch.PayoutAmount > 0 {
+			method, err = payment_method.NewRepository(tx).GetDefaultByUserID(merchantID)
+			if err != nil {
+				return fmt.Errorf("no default payment method")
+			}
+			batch.PaymentMethodID = &method.ID
+		}
+
+		if w, err := walletRepo.GetWalletByUserID(merchantID); err == nil {
+			batch.Currency = w.Currency
+		}
+
+		if batch.ReserveReleased > 0 {
+			if err := walletRepo.ReleaseFunds(merchantID, batch.ReserveReleased); err != nil {
+				return err
+			}
+		}
+		if batch.ReserveAmount > 0 {
+			if err := walletRepo.HoldFunds(merchantID, batch.ReserveAmount); err != nil {
+				return err
+			}
+		}
+		// Refunds were debited when they happened; fees leave the wallet with the payout
+		if debit := round(batch.PayoutAmount + batch.FeeAmount); debit > 0 {
+			if err := walletRepo.UpdateBalance(merchantID, -debit); err != nil {
+				return err
+			}
+		}
+
+		batch.Status = models.SettlementStatusPaid
+		if err := repo.CreateBatch(batch); err != nil {
+			return err
+		}
+
+		if batch.PayoutAmount > 0 {
+			metadata, _ := json.Marshal(map[string]interface{}{
+				"settlement_batch_id": batch.ID,
+				"payment_method_id":   method.ID,
+			})
+			description := fmt.Sprintf("Settlement %s to %s", periodEnd.Format("2006-01-02"), method.Type)
+			payout := &models.Transaction{
+				FromUserID:  merchantID,
+				Amount:      batch.PayoutAmount,
+				Currency:    batch.Currency,
+				Type:        models.TransactionTypePayout,
+				Status:      models.TransactionStatusCompleted,
+				Description: &description,
+				Metadata:    datatypes.JSON(metadata),
+				Fee:         batch.FeeAmount,
+			}
+			if err := txRepo.CreateTransaction(payout); err != nil {
+				return err
+			}
+			batch.PayoutTransactionID = &payout.ID
+			if err := repo.UpdateBatch(batch.ID, map[string]interface{}{"payout_transaction_id": payout.ID}); err != nil {
+				return err
+			}
+		}
+
+		if err := repo.AssignTransactions(batch.ID, paymentIDs); err != nil {
+			return err
+		}
+		return repo.MarkReservesReleased(reserveIDs, batch.ReserveReleased, now)
+	})
+
+	if err != nil {
+		// Record the failed attempt for the merchant's reports; its payments stay
+		// unsettled and are picked up again by the next run
+		reason := err.Error()
+		failed := *batch
+		failed.Model = gorm.Model{}
+		failed.ID = uuid.Nil
+		failed.Status = models.SettlementStatusFailed
+		failed.FailureReason = &reason
+		failed.PayoutTransactionID = nil
+		failed.ReserveReleaseAt = nil
+		if createErr := s.repo.CreateBatch(&failed); createErr != nil {
+			s.logger.ErrorWithErr("Failed to record failed settlement", createErr)
+		}
+		return &failed, err
+	}
+
+	s.logger.Info("Merchant settled",
+		utils.Field{Key: "merchant_id", Value: merchantID},
+		utils.Field{Key: "payout", Value: batch.PayoutAmount},
+		utils.Field{Key: "reserve", Value: batch.ReserveAmount},
+	)
+	return batch, nil
+}
+
+// round rounds an amount to two decimal places
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
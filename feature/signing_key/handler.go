@@ -0,0 +1,21 @@
+package signing_key
+
+import (
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves the public JWT verification keys
+type Handler struct{}
+
+// NewHandler creates a new signing key handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetJWKS returns the keys that verify tokens issued by this service
+func (h *Handler) GetJWKS(c *fiber.Ctx) error {
+	// Verifiers refetch on unknown kids, so a short cache is enough
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(utils.JWT.JWKS())
+}
@@ -0,0 +1,58 @@
+package signing_key
+
+import (
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository handles signing key database operations
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new signing key repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Lock serializes key rotation across replicas for the current transaction
+func (r *Repository) Lock() error {
+	return r.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "signing_keys").Error
+}
+
+// GetLatest retrieves the most recently activated or scheduled key
+func (r *Repository) GetLatest() (*models.SigningKey, error) {
+	var key models.SigningKey
+	err := r.db.Order("not_before desc").First(&key).Error
+	return &key, err
+}
+
+// GetValid retrieves keys that still verify tokens, newest first
+func (r *Repository) GetValid(now time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("not_before desc").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Create saves a new signing key
+func (r *Repository) Create(key *models.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+// ExpireOthers schedules the expiry of every key superseded by keepID
+func (r *Repository) ExpireOthers(keepID uuid.UUID, expiresAt time.Time) error {
+	return r.db.Model(&models.SigningKey{}).
+		Where("id <> ? AND expires_at IS NULL", keepID).
+		Update("expires_at", expiresAt).Error
+}
+
+// DeleteExpired removes keys that no longer verify any token
+func (r *Repository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&models.SigningKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,170 @@
+package signing_key
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/security"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Service rotates JWT signing keys and loads them into utils.JWT
+type Service struct {
+	db     *gorm.DB
+	repo   *Repository
+	policy *security.Policy
+	logger *utils.Logger
+}
+
+// NewService creates a new signing key service
+func NewService(db *gorm.DB, policy *security.Policy) *Service {
+	return &Service{
+		db:     db,
+		repo:   NewRepository(db),
+		policy: policy,
+		logger: utils.GetLogger("signing_key"),
+	}
+}
+
+// Start syncs the keys once, failing if no key can be loaded, then keeps
+// them current in the background so every replica follows rotations
+func (s *Service) Start(interval time.Duration) error {
+	if err := s.Sync(); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.Sync(); err != nil {
+				s.logger.ErrorWithErr("Failed to sync signing keys", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Sync rotates the signing key when it is due and reloads the key set
+func (s *Service) Sync() error {
+	now := time.Now()
+	if err := s.Rotate(now); err != nil {
+		return err
+	}
+	return s.Load(now)
+}
+
+// Rotate creates the first signing key, or schedules a successor once the
+// current key has been signing for the configured rotation period. The
+// successor is published SigningKeyPublishLead before it starts signing.
+func (s *Service) Rotate(now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := NewRepository(tx)
+		if err := repo.Lock(); err != nil {
+			return fmt.Errorf("failed to lock signing keys: %w", err)
+		}
+
+		notBefore := now
+		latest, err := repo.GetLatest()
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// The first key signs immediately
+		case err != nil:
+			return err
+		default:
+			if now.Before(latest.NotBefore.Add(s.policy.KeyRotation - models.SigningKeyPublishLead)) {
+				return nil
+			}
+			notBefore = now.Add(models.SigningKeyPublishLead)
+		}
+
+		key, err := newSigningKey(notBefore)
+		if err != nil {
+			return err
+		}
+		if err := repo.Create(key); err != nil {
+			return fmt.Errorf("failed to create signing key: %w", err)
+		}
+
+		// Superseded keys keep verifying until the longest lived token they
+		// signed has expired
+		expiresAt := notBefore.Add(time.Duration(s.policy.RefreshExpiry) * time.Second)
+		if err := repo.ExpireOthers(key.ID, expiresAt); err != nil {
+			return fmt.Errorf("failed to expire signing keys: %w", err)
+		}
+		if _, err := repo.DeleteExpired(now); err != nil {
+			return fmt.Errorf("failed to delete expired signing keys: %w", err)
+		}
+
+		s.logger.Info("Signing key created",
+			utils.Field{Key: "kid", Value: key.ID},
+			utils.Field{Key: "not_before", Value: key.NotBefore},
+		)
+		return nil
+	})
+}
+
+// Load reads the valid keys and installs the newest active one as the signer
+func (s *Service) Load(now time.Time) error {
+	keys, err := s.repo.GetValid(now)
+	if err != nil {
+		return fmt.Errorf("failed to get signing keys: %w", err)
+	}
+
+	public := make(map[string]ed25519.PublicKey, len(keys))
+	var signing *models.SigningKey
+	for i := range keys {
+		key, err := utils.DecodeJWK(models.JWK{Kty: "OKP", Crv: models.SigningKeyCurve, X: keys[i].PublicKey})
+		if err != nil {
+			return fmt.Errorf("invalid signing key %s: %w", keys[i].ID, err)
+		}
+		public[keys[i].ID.String()] = key
+
+		if signing == nil && !keys[i].NotBefore.After(now) {
+			signing = &keys[i]
+		}
+	}
+	if signing == nil {
+		return fmt.Errorf("no active signing key")
+	}
+
+	encoded, err := utils.CIPHER.Decrypt(signing.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt signing key %s: %w", signing.ID, err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return fmt.Errorf("invalid signing key seed %s", signing.ID)
+	}
+
+	utils.JWT.SetKeys(signing.ID.String(), ed25519.NewKeyFromSeed(seed), public)
+	return nil
+}
+
+// newSigningKey generates a key pair with its private half encrypted at rest
+func newSigningKey(notBefore time.Time) (*models.SigningKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	encrypted, err := utils.CIPHER.Encrypt(base64.StdEncoding.EncodeToString(private.Seed()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	return &models.SigningKey{
+		ID:         uuid.New(),
+		Algorithm:  models.SigningKeyAlgorithm,
+		PublicKey:  base64.RawURLEncoding.EncodeToString(public),
+		PrivateKey: encrypted,
+		NotBefore:  notBefore,
+	}, nil
+}
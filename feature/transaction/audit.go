@@ -0,0 +1,23 @@
+package transaction
+
+import (
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+)
+
+// RecordEvent audits a completed money movement. e carries the action and
+// who made the movement; the transaction becomes its target.
+func RecordEvent(e audit.Event, t *models.Transaction) {
+	e.TargetType = models.AuditTargetTransaction
+	e.TargetID = &t.ID
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	e.Details["type"] = t.Type
+	e.Details["from_user_id"] = t.FromUserID
+	e.Details["to_user_id"] = t.ToUserID
+	e.Details["amount"] = t.Amount
+	e.Details["currency"] = t.Currency
+	e.Details["status"] = t.Status
+	audit.Record(e)
+}
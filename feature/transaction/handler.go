@@ -1,7 +1,9 @@
 package transaction
 
 import (
+	"github.com/Keba777/levpay-backend/feature/pin"
 	"github.com/Keba777/levpay-backend/feature/wallet"
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -12,14 +14,16 @@ import (
 type Handler struct {
 	repo       *Repository
 	walletRepo *wallet.Repository
+	pins       *pin.Service
 	db         *gorm.DB
 }
 
 // NewHandler creates a new transaction handler
-func NewHandler(repo *Repository, walletRepo *wallet.Repository, db *gorm.DB) *Handler {
+func NewHandler(repo *Repository, walletRepo *wallet.Repository, pins *pin.Service, db *gorm.DB) *Handler {
 	return &Handler{
 		repo:       repo,
 		walletRepo: walletRepo,
+		pins:       pins,
 		db:         db,
 	}
 }
@@ -55,6 +59,12 @@ func (h *Handler) Transfer(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Cannot transfer to yourself")
 	}
 
+	// Payments over the threshold need the PIN or a 2FA code
+	user := c.Locals("user").(models.User)
+	if err := h.pins.Authorize(&user, req.Amount, req.PaymentAuthorization, c.IP()); err != nil {
+		return err
+	}
+
 	// Execute transfer in a transaction
 	var transaction *models.Transaction
 	err = h.db.Transaction(func(tx *gorm.DB) error {
@@ -93,9 +103,14 @@ func (h *Handler) Transfer(c *fiber.Ctx) error {
 		if err.Error() == "wallet is locked" {
 			return fiber.NewError(fiber.StatusForbidden, "Wallet is locked")
 		}
+		if err.Error() == "wallet is frozen" {
+			return fiber.NewError(fiber.StatusForbidden, "Wallet is frozen")
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "Transfer failed")
 	}
 
+	RecordEvent(audit.Request(c, models.AuditActionTransfer), transaction)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message":     "Transfer successful",
 		"transaction": transaction.ToResponse(),
@@ -119,6 +134,12 @@ func (h *Handler) Payment(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Amount must be positive")
 	}
 
+	// Payments over the threshold need the PIN or a 2FA code
+	user := c.Locals("user").(models.User)
+	if err := h.pins.Authorize(&user, req.Amount, req.PaymentAuthorization, c.IP()); err != nil {
+		return err
+	}
+
 	// Execute payment in a transaction
 	var transaction *models.Transaction
 	err = h.db.Transaction(func(tx *gorm.DB) error {
@@ -157,9 +178,14 @@ func (h *Handler) Payment(c *fiber.Ctx) error {
 		if err.Error() == "wallet is locked" {
 			return fiber.NewError(fiber.StatusForbidden, "Wallet is locked")
 		}
+		if err.Error() == "wallet is frozen" {
+			return fiber.NewError(fiber.StatusForbidden, "Wallet is frozen")
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "Payment failed")
 	}
 
+	RecordEvent(audit.Request(c, models.AuditActionPayment), transaction)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message":     "Payment successful",
 		"transaction": transaction.ToResponse(),
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/Keba777/levpay-backend/internal/storage"
 	"github.com/gofiber/fiber/v2"
@@ -42,7 +43,15 @@ func (h *Handler) GetProfile(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "User not found")
 	}
 
-	return c.JSON(user.PrepareResponse())
+	resp := user.PrepareResponse()
+
+	// Flag the response so clients can show an impersonation banner
+	if decodedToken, ok := c.Locals("decoded_token").(models.DecodedToken); ok && decodedToken.IsImpersonation() {
+		resp.Impersonated = true
+		resp.ImpersonatedBy = &decodedToken.ImpersonatorID
+	}
+
+	return c.JSON(resp)
 }
 
 // UpdateProfile updates the current user's profile
@@ -65,7 +74,10 @@ func (h *Handler) UpdateProfile(c *fiber.Ctx) error {
 		updates["last_name"] = req.LastName
 	}
 	if req.Phone != "" {
-		updates["phone"] = req.Phone
+		// Phone numbers only change through SMS verification
+		if current, _ := c.Locals("user").(models.User); current.Phone == nil || *current.Phone != req.Phone {
+			return fiber.NewError(fiber.StatusBadRequest, "Phone numbers are changed via /api/auth/phone/change")
+		}
 	}
 	if req.Address != nil {
 		updates["address"] = *req.Address
@@ -208,20 +220,8 @@ func (h *Handler) UpdateSettings(c *fiber.Ctx) error {
 	})
 }
 
-// ListUsers (Admin only)
+// ListUsers lists every user for staff with the users.view permission
 func (h *Handler) ListUsers(c *fiber.Ctx) error {
-	userID, err := getUserID(c)
-	if err != nil {
-		return err
-	}
-	caller, err := h.repo.GetUserByID(userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "User not found")
-	}
-	if caller.Role != "admin" {
-		return fiber.NewError(fiber.StatusForbidden, "Admins only")
-	}
-
 	var req models.ListedRequest
 	req.FromContext(c)
 
@@ -287,20 +287,8 @@ func (h *Handler) SearchUsers(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateKYC (Admin only)
+// UpdateKYC sets a user's KYC status for staff with the kyc.review permission
 func (h *Handler) UpdateKYC(c *fiber.Ctx) error {
-	userID, err := getUserID(c)
-	if err != nil {
-		return err
-	}
-	caller, err := h.repo.GetUserByID(userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "User not found")
-	}
-	if caller.Role != "admin" {
-		return fiber.NewError(fiber.StatusForbidden, "Admins only")
-	}
-
 	targetIDStr := c.Params("id")
 	targetID, err := uuid.Parse(targetIDStr)
 	if err != nil {
@@ -314,9 +302,23 @@ func (h *Handler) UpdateKYC(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid body")
 	}
 
+	target, err := h.repo.GetUserByID(targetID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "User not found")
+	}
+
 	if err := h.repo.UpdateKYCStatus(targetID, body.Status); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update KYC status")
 	}
 
+	e := audit.Request(c, models.AuditActionKYCReviewed)
+	e.TargetType = models.AuditTargetUser
+	e.TargetID = &target.ID
+	e.Changes = audit.Diff(
+		map[string]interface{}{"kyc_status": target.KYCStatus},
+		map[string]interface{}{"kyc_status": body.Status},
+	)
+	audit.Record(e)
+
 	return c.JSON(fiber.Map{"message": "KYC status updated"})
 }
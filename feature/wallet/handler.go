@@ -1,6 +1,8 @@
 package wallet
 
 import (
+	"github.com/Keba777/levpay-backend/feature/pin"
+	"github.com/Keba777/levpay-backend/internal/audit"
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -9,11 +11,12 @@ import (
 // Handler handles wallet HTTP requests
 type Handler struct {
 	repo *Repository
+	pins *pin.Service
 }
 
 // NewHandler creates a new wallet handler
-func NewHandler(repo *Repository) *Handler {
-	return &Handler{repo: repo}
+func NewHandler(repo *Repository, pins *pin.Service) *Handler {
+	return &Handler{repo: repo, pins: pins}
 }
 
 // Helper to get userID from context
@@ -73,6 +76,12 @@ func (h *Handler) TopUp(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve updated wallet")
 	}
 
+	recordWalletEvent(c, models.AuditActionTopUp, wallet, map[string]interface{}{
+		"amount":   req.Amount,
+		"currency": wallet.Currency,
+		"balance":  wallet.Balance,
+	}, nil)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Top-up successful",
 		"wallet":  wallet.ToResponse(),
@@ -95,6 +104,12 @@ func (h *Handler) Withdraw(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Amount must be positive")
 	}
 
+	// Payments over the threshold need the PIN or a 2FA code
+	user := c.Locals("user").(models.User)
+	if err := h.pins.Authorize(&user, req.Amount, req.PaymentAuthorization, c.IP()); err != nil {
+		return err
+	}
+
 	// Update balance (negative amount for withdrawal)
 	if err := h.repo.UpdateBalance(userID, -req.Amount); err != nil {
 		if err.Error() == "insufficient balance" {
@@ -103,6 +118,9 @@ func (h *Handler) Withdraw(c *fiber.Ctx) error {
 		if err.Error() == "wallet is locked" {
 			return fiber.NewError(fiber.StatusForbidden, "Wallet is locked")
 		}
+		if err.Error() == "wallet is frozen" {
+			return fiber.NewError(fiber.StatusForbidden, "Wallet is frozen")
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to process withdrawal")
 	}
 
@@ -112,6 +130,12 @@ func (h *Handler) Withdraw(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve updated wallet")
 	}
 
+	recordWalletEvent(c, models.AuditActionWithdrawal, wallet, map[string]interface{}{
+		"amount":   req.Amount,
+		"currency": wallet.Currency,
+		"balance":  wallet.Balance,
+	}, nil)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Withdrawal successful",
 		"wallet":  wallet.ToResponse(),
@@ -125,10 +149,20 @@ func (h *Handler) LockWallet(c *fiber.Ctx) error {
 		return err
 	}
 
+	wallet, err := h.repo.GetWalletByUserID(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Wallet not found")
+	}
+
 	if err := h.repo.LockWallet(userID); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to lock wallet")
 	}
 
+	recordWalletEvent(c, models.AuditActionWalletLocked, wallet, nil, audit.Diff(
+		map[string]interface{}{"locked": wallet.Locked},
+		map[string]interface{}{"locked": true},
+	))
+
 	return c.JSON(fiber.Map{"message": "Wallet locked successfully"})
 }
 
@@ -139,9 +173,29 @@ func (h *Handler) UnlockWallet(c *fiber.Ctx) error {
 		return err
 	}
 
+	wallet, err := h.repo.GetWalletByUserID(userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Wallet not found")
+	}
+
 	if err := h.repo.UnlockWallet(userID); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to unlock wallet")
 	}
 
+	recordWalletEvent(c, models.AuditActionWalletUnlocked, wallet, nil, audit.Diff(
+		map[string]interface{}{"locked": wallet.Locked},
+		map[string]interface{}{"locked": false},
+	))
+
 	return c.JSON(fiber.Map{"message": "Wallet unlocked successfully"})
 }
+
+// recordWalletEvent audits an action on a wallet
+func recordWalletEvent(c *fiber.Ctx, action string, wallet *models.Wallet, details map[string]interface{}, changes map[string]audit.Change) {
+	e := audit.Request(c, action)
+	e.TargetType = models.AuditTargetWallet
+	e.TargetID = &wallet.ID
+	e.Details = details
+	e.Changes = changes
+	audit.Record(e)
+}
@@ -74,11 +74,16 @@ func (r *Repository) UpdateBalance(userID uuid.UUID, amount float64) error {
 			return fmt.Errorf("wallet is locked")
 		}
 
+		// Frozen wallets neither send nor receive until the account is reinstated
+		if wallet.Frozen {
+			return fmt.Errorf("wallet is frozen")
+		}
+
 		// Calculate new balance
 		newBalance := wallet.Balance + amount
 
-		// Prevent negative balance for debits
-		if newBalance < 0 {
+		// Prevent debits from dipping into negative or held funds
+		if amount < 0 && newBalance < wallet.HeldBalance {
 			return fmt.Errorf("insufficient balance")
 		}
 
@@ -94,6 +99,49 @@ func (r *Repository) UpdateBalance(userID uuid.UUID, amount float64) error {
 	})
 }
 
+// HoldFunds moves part of the available balance into the held balance
+func (r *Repository) HoldFunds(userID uuid.UUID, amount float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var wallet models.Wallet
+
+		if err := tx.Raw("SELECT * FROM wallets WHERE user_id = ? FOR UPDATE", userID).
+			Scan(&wallet).Error; err != nil {
+			return err
+		}
+
+		if wallet.Balance-wallet.HeldBalance < amount {
+			return fmt.Errorf("insufficient balance")
+		}
+
+		return tx.Model(&wallet).Updates(map[string]interface{}{
+			"held_balance": wallet.HeldBalance + amount,
+			"last_updated": time.Now(),
+		}).Error
+	})
+}
+
+// ReleaseFunds returns held funds to the available balance
+func (r *Repository) ReleaseFunds(userID uuid.UUID, amount float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var wallet models.Wallet
+
+		if err := tx.Raw("SELECT * FROM wallets WHERE user_id = ? FOR UPDATE", userID).
+			Scan(&wallet).Error; err != nil {
+			return err
+		}
+
+		held := wallet.HeldBalance - amount
+		if held < 0 {
+			held = 0
+		}
+
+		return tx.Model(&wallet).Updates(map[string]interface{}{
+			"held_balance": held,
+			"last_updated": time.Now(),
+		}).Error
+	})
+}
+
 // LockWallet locks a wallet (e.g., for security reasons)
 func (r *Repository) LockWallet(userID uuid.UUID) error {
 	return r.db.Model(&models.Wallet{}).
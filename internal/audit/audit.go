@@ -0,0 +1,349 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	queueSize    = 1024
+	maxBatchSize = 100
+	maxAttempts  = 3
+)
+
+// Event describes one security or financial action to be audited
+type Event struct {
+	ActorID    *uuid.UUID // Who acted; nil for anonymous and system events
+	Action     string
+	TargetType string // What was acted on, one of the models.AuditTarget* types
+	TargetID   *uuid.UUID
+	IPAddress  string
+	UserAgent  string
+	Details    map[string]interface{}
+	Changes    map[string]Change
+	OccurredAt time.Time
+}
+
+// Change is a field's value before and after an action
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff returns the fields whose values differ between two snapshots
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := map[string]Change{}
+	for field, from := range before {
+		if to, ok := after[field]; !ok || !reflect.DeepEqual(from, to) {
+			changes[field] = Change{From: from, To: after[field]}
+		}
+	}
+	for field, to := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = Change{To: to}
+		}
+	}
+	return changes
+}
+
+// Request starts an event for an HTTP request, taking the actor from the
+// signed-in user and the client IP and user agent from the request
+func Request(c *fiber.Ctx, action string) Event {
+	e := Event{
+		Action:    action,
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+	if user, ok := c.Locals("user").(models.User); ok {
+		e.ActorID = &user.ID
+	}
+	return e
+}
+
+// Recorder writes audit events in the background so requests never wait on
+// the audit log. A single writer per process keeps the hash chain in order.
+// Batches the database refuses are spooled to disk and written once it is
+// back.
+type Recorder struct {
+	db      *gorm.DB
+	spool   *spool
+	entries chan *models.AuditLog
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+}
+
+var Log *Recorder
+
+// InitAudit starts the process-wide audit recorder
+func InitAudit(db *gorm.DB) {
+	Log = NewRecorder(db, config.CFG.Security.AuditSpoolDir)
+}
+
+// Record queues an event on the process-wide recorder
+func Record(e Event) {
+	if Log == nil {
+		log.Printf("[Audit] Recorder not initialized, dropping %s", e.Action)
+		return
+	}
+	Log.Record(e)
+}
+
+// Close flushes and stops the process-wide recorder
+func Close() {
+	if Log != nil {
+		Log.Close()
+	}
+}
+
+// NewRecorder creates a recorder spooling to spoolDir and starts its writer
+func NewRecorder(db *gorm.DB, spoolDir string) *Recorder {
+	r := &Recorder{
+		db:      db,
+		spool:   &spool{dir: spoolDir},
+		entries: make(chan *models.AuditLog, queueSize),
+		done:    make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Record queues an event. It only blocks if the queue is full. The event is
+// encoded before queueing because Fiber reuses request memory once the
+// handler returns.
+func (r *Recorder) Record(e Event) {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	entry, err := newEntry(e)
+	if err != nil {
+		log.Printf("[Audit] Dropping %s: %v", e.Action, err)
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		log.Printf("[Audit] Recorder closed, dropping %s", e.Action)
+		return
+	}
+	r.entries <- entry
+}
+
+// Close stops accepting events and waits for queued ones to be written
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.entries)
+	}
+	r.mu.Unlock()
+	<-r.done
+}
+
+// run writes queued events in batches until the recorder is closed, and
+// retries spooled batches on start, periodically and before stopping
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	retry := time.NewTicker(spoolRetryInterval)
+	defer retry.Stop()
+	r.replay()
+
+	for {
+		select {
+		case entry, ok := <-r.entries:
+			if !ok {
+				r.replay()
+				return
+			}
+			batch := []*models.AuditLog{entry}
+		drain:
+			for len(batch) < maxBatchSize {
+				select {
+				case next, ok := <-r.entries:
+					if !ok {
+						break drain
+					}
+					batch = append(batch, next)
+				default:
+					break drain
+				}
+			}
+			r.write(batch)
+		case <-retry.C:
+			r.replay()
+		}
+	}
+}
+
+// write appends a batch to the chain, retrying briefly before spooling it to
+// disk. Only if that fails too are the events logged, so they are not lost
+// without a trace.
+func (r *Recorder) write(batch []*models.AuditLog) {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = r.append(batch, false); err == nil {
+			return
+		}
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+
+	spoolErr := r.spool.save(batch)
+	if spoolErr == nil {
+		log.Printf("[Audit] Failed to write %d events, spooled for retry: %v", len(batch), err)
+		return
+	}
+
+	log.Printf("[Audit] Failed to write %d events: %v; failed to spool them: %v", len(batch), err, spoolErr)
+	for _, entry := range batch {
+		data, _ := json.Marshal(entry)
+		log.Printf("[Audit] Unwritten event: %s", data)
+	}
+}
+
+// replay writes spooled batches, oldest first, stopping at the first one the
+// database still refuses
+func (r *Recorder) replay() {
+	for _, file := range r.spool.pending() {
+		claimed, ok := r.spool.claim(file)
+		if !ok {
+			continue
+		}
+		batch, err := r.spool.load(claimed)
+		if err != nil {
+			// Keep the file for inspection rather than retrying it forever
+			log.Printf("[Audit] %v", err)
+			os.Rename(claimed, claimed+".bad")
+			continue
+		}
+		if err := r.append(batch, true); err != nil {
+			r.spool.release(claimed)
+			log.Printf("[Audit] Failed to replay %d spooled events: %v", len(batch), err)
+			return
+		}
+		os.Remove(claimed)
+		log.Printf("[Audit] Replayed %d spooled events", len(batch))
+	}
+}
+
+// append links a batch onto the end of the chain. The advisory lock keeps
+// replicas sharing the table from forking the chain. Replayed batches skip
+// entries that an earlier replay already wrote.
+func (r *Recorder) append(batch []*models.AuditLog, replayed bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "audit_logs").Error; err != nil {
+			return fmt.Errorf("failed to lock audit chain: %w", err)
+		}
+
+		if replayed {
+			var err error
+			if batch, err = unwritten(tx, batch); err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				return nil
+			}
+		}
+
+		var head models.AuditLog
+		err := tx.Unscoped().Where("sequence > 0").Order("sequence desc").Limit(1).Find(&head).Error
+		if err != nil {
+			return fmt.Errorf("failed to read audit chain head: %w", err)
+		}
+
+		entries := make([]models.AuditLog, len(batch))
+		for i, pending := range batch {
+			entry := *pending
+			entry.Sequence = head.Sequence + 1
+			entry.PrevHash = head.Hash
+			if entry.Hash, err = hashEntry(&entry); err != nil {
+				return err
+			}
+			entries[i] = entry
+			head = entry
+		}
+
+		return tx.Create(&entries).Error
+	})
+}
+
+// unwritten drops the entries of a batch that are already in the table
+func unwritten(tx *gorm.DB, batch []*models.AuditLog) ([]*models.AuditLog, error) {
+	ids := make([]uuid.UUID, len(batch))
+	for i, entry := range batch {
+		ids[i] = entry.ID
+	}
+	var written []uuid.UUID
+	if err := tx.Unscoped().Model(&models.AuditLog{}).Where("id IN ?", ids).Pluck("id", &written).Error; err != nil {
+		return nil, fmt.Errorf("failed to check replayed audit entries: %w", err)
+	}
+	if len(written) == 0 {
+		return batch, nil
+	}
+
+	skip := make(map[uuid.UUID]bool, len(written))
+	for _, id := range written {
+		skip[id] = true
+	}
+	remaining := make([]*models.AuditLog, 0, len(batch))
+	for _, entry := range batch {
+		if !skip[entry.ID] {
+			remaining = append(remaining, entry)
+		}
+	}
+	return remaining, nil
+}
+
+// newEntry converts an event into an unchained audit log entry
+func newEntry(e Event) (*models.AuditLog, error) {
+	// The ID is set here rather than by the database so a spooled entry
+	// keeps it when replayed
+	entry := &models.AuditLog{
+		ID:         uuid.New(),
+		UserID:     e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+	}
+	// Postgres keeps microseconds; hash what will be read back
+	entry.CreatedAt = e.OccurredAt.UTC().Truncate(time.Microsecond)
+	entry.UpdatedAt = entry.CreatedAt
+
+	if e.IPAddress != "" {
+		ip := strings.Clone(e.IPAddress)
+		entry.IPAddress = &ip
+	}
+	if e.UserAgent != "" {
+		userAgent := strings.Clone(e.UserAgent)
+		entry.UserAgent = &userAgent
+	}
+	if len(e.Details) > 0 {
+		data, err := json.Marshal(e.Details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit details: %w", err)
+		}
+		entry.Details = datatypes.JSON(data)
+	}
+	if len(e.Changes) > 0 {
+		data, err := json.Marshal(e.Changes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+		}
+		entry.Changes = datatypes.JSON(data)
+	}
+	return entry, nil
+}
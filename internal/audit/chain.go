@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const verifyBatchSize = 500
+
+// link is the canonical form of an entry that its hash covers
+type link struct {
+	Sequence   int64           `json:"sequence"`
+	PrevHash   string          `json:"prev_hash"`
+	CreatedAt  string          `json:"created_at"`
+	ActorID    string          `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	IPAddress  string          `json:"ip_address"`
+	UserAgent  string          `json:"user_agent"`
+	Details    json.RawMessage `json:"details"`
+	Changes    json.RawMessage `json:"changes"`
+}
+
+// hashEntry computes the chain hash of an entry from its content and PrevHash
+func hashEntry(entry *models.AuditLog) (string, error) {
+	l := link{
+		Sequence:   entry.Sequence,
+		PrevHash:   entry.PrevHash,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+	}
+	if entry.UserID != nil {
+		l.ActorID = entry.UserID.String()
+	}
+	if entry.TargetID != nil {
+		l.TargetID = entry.TargetID.String()
+	}
+	if entry.IPAddress != nil {
+		l.IPAddress = *entry.IPAddress
+	}
+	if entry.UserAgent != nil {
+		l.UserAgent = *entry.UserAgent
+	}
+
+	var err error
+	if l.Details, err = canonicalJSON(entry.Details); err != nil {
+		return "", err
+	}
+	if l.Changes, err = canonicalJSON(entry.Changes); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes JSON with sorted keys and no whitespace, since
+// jsonb does not keep the bytes that were written
+func canonicalJSON(data []byte) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode audit JSON: %w", err)
+	}
+	return json.Marshal(v)
+}
+
+// Verify walks the audit chain in order, recomputing every hash. The chain
+// may start after the first sequence if older entries were purged, but every
+// entry from there on must follow on from the one before it.
+func Verify(db *gorm.DB) (*models.AuditChainVerification, error) {
+	result := &models.AuditChainVerification{Valid: true}
+	broken := func(entry *models.AuditLog, reason string) (*models.AuditChainVerification, error) {
+		result.Valid = false
+		result.BrokenAt = &entry.Sequence
+		result.Reason = reason
+		result.VerifiedAt = time.Now()
+		return result, nil
+	}
+
+	var prev *models.AuditLog
+	for {
+		query := db.Unscoped().Where("sequence > 0").Order("sequence asc").Limit(verifyBatchSize)
+		if prev != nil {
+			query = query.Where("sequence > ?", prev.Sequence)
+		}
+
+		var entries []models.AuditLog
+		if err := query.Find(&entries).Error; err != nil {
+			return nil, fmt.Errorf("failed to read audit chain: %w", err)
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if entry.DeletedAt.Valid {
+				return broken(entry, "entry was deleted")
+			}
+			if prev != nil {
+				if entry.Sequence != prev.Sequence+1 {
+					return broken(entry, fmt.Sprintf("entries %d to %d are missing", prev.Sequence+1, entry.Sequence-1))
+				}
+				if entry.PrevHash != prev.Hash {
+					return broken(entry, "previous hash does not match")
+				}
+			}
+
+			hash, err := hashEntry(entry)
+			if err != nil {
+				return nil, err
+			}
+			if hash != entry.Hash {
+				return broken(entry, "entry was modified")
+			}
+
+			result.Checked++
+			prev = entry
+		}
+
+		if len(entries) < verifyBatchSize {
+			break
+		}
+		// Keep only the last entry so the batch can be released
+		last := entries[len(entries)-1]
+		prev = &last
+	}
+
+	result.VerifiedAt = time.Now()
+	return result, nil
+}
+
+// Purge deletes entries created before the cutoff. Only the start of the
+// chain is removed and the newest entry is always kept, so what remains still
+// verifies and new entries keep linking onto it.
+func Purge(db *gorm.DB, before time.Time) (int64, error) {
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "audit_logs").Error; err != nil {
+			return fmt.Errorf("failed to lock audit chain: %w", err)
+		}
+
+		// The chain is kept from its first entry inside the retention window
+		var keepFrom sql.NullInt64
+		err := tx.Model(&models.AuditLog{}).Unscoped().
+			Select("COALESCE(MIN(CASE WHEN created_at >= ? THEN sequence END), MAX(sequence))", before).
+			Where("sequence > 0").
+			Row().Scan(&keepFrom)
+		if err != nil {
+			return fmt.Errorf("failed to find retained audit entries: %w", err)
+		}
+
+		query := tx.Unscoped().Where("(sequence IS NULL AND created_at < ?)", before)
+		if keepFrom.Valid {
+			query = query.Or("(sequence > 0 AND sequence < ?)", keepFrom.Int64)
+		}
+		result := query.Delete(&models.AuditLog{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to purge audit entries: %w", result.Error)
+		}
+		deleted = result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	spoolRetryInterval = 30 * time.Second
+	// A claimed file this old belongs to a writer that stopped mid-replay
+	spoolStaleClaim = 10 * time.Minute
+)
+
+// spool keeps batches the database refused in files on disk, so an outage
+// delays audit entries instead of losing them. Entries carry their IDs, so a
+// batch replayed twice is only written once.
+type spool struct {
+	dir string
+}
+
+// save writes a batch to a new file. The file is synced and renamed into
+// place so a crash never leaves half a batch to replay.
+func (s *spool) save(batch []*models.AuditLog) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create audit spool: %w", err)
+	}
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit batch: %w", err)
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d-%s.json", time.Now().UnixNano(), uuid.NewString()))
+	f, err := os.OpenFile(name+".tmp", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create audit spool file: %w", err)
+	}
+	if _, err := f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name + ".tmp")
+		return fmt.Errorf("failed to write audit spool file: %w", err)
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		os.Remove(name + ".tmp")
+		return fmt.Errorf("failed to write audit spool file: %w", err)
+	}
+	return nil
+}
+
+// pending lists spooled files waiting to be written, oldest first. Files
+// claimed by a writer that stopped mid-replay are released again.
+func (s *spool) pending() []string {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, d := range dirEntries {
+		name := filepath.Join(s.dir, d.Name())
+		switch {
+		case strings.HasSuffix(name, ".json"):
+			files = append(files, name)
+		case strings.HasSuffix(name, ".json.claimed"):
+			info, err := d.Info()
+			if err != nil || time.Since(info.ModTime()) < spoolStaleClaim {
+				continue
+			}
+			released := strings.TrimSuffix(name, ".claimed")
+			if os.Rename(name, released) == nil {
+				files = append(files, released)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// claim takes a spooled file so writers sharing the directory do not replay
+// it at the same time. It returns false if another writer got there first.
+func (s *spool) claim(file string) (string, bool) {
+	claimed := file + ".claimed"
+	if err := os.Rename(file, claimed); err != nil {
+		return "", false
+	}
+	now := time.Now()
+	os.Chtimes(claimed, now, now)
+	return claimed, true
+}
+
+// load reads a claimed file's batch
+func (s *spool) load(claimed string) ([]*models.AuditLog, error) {
+	data, err := os.ReadFile(claimed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit spool file: %w", err)
+	}
+	var batch []*models.AuditLog
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, fmt.Errorf("failed to decode audit spool file %s: %w", claimed, err)
+	}
+	return batch, nil
+}
+
+// release hands a claimed file back to be retried later
+func (s *spool) release(claimed string) {
+	os.Rename(claimed, strings.TrimSuffix(claimed, ".claimed"))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/Keba777/levpay-backend/internal/utils"
@@ -11,6 +12,16 @@ import (
 
 var CFG *models.Config
 
+// EnvProduction is the APP_ENV value of production deployments
+const EnvProduction = "production"
+
+// Placeholder secrets used when the environment does not set them. They are
+// refused in production.
+const (
+	DefaultSecuritySecret = "your-super-secret-jwt-key-here"
+	DefaultEncryptionKey  = "your-super-secret-encryption-key"
+)
+
 func InitConfig() {
 	logger := utils.GetLogger("config")
 
@@ -31,21 +42,41 @@ func InitConfig() {
 			Shutdown: getEnvInt("APP_SHUTDOWN", 30),
 			Service:  os.Getenv("APP_SERVICE"), // critical: app, auth, wallet, etc.
 			Url:      os.Getenv("APP_URL"),
+			Env:      getEnvString("APP_ENV", "development"),
+			TrustedProxies: getEnvList("APP_TRUSTED_PROXIES", "127.0.0.1,::1"),
 		},
 		Security: models.Security{
 			Complecity:     getEnvInt("SECURITY_COMPLECITY", 14),
-			Secret:         getEnvString("SECURITY_SECRET", "your-super-secret-jwt-key-here"),
+			Secret:         getEnvString("SECURITY_SECRET", DefaultSecuritySecret),
 			AccessExpiries: getEnvInt("SECURITY_ACCESS_EXPIRIES", 30*60),     // 30 mins
 			RefreshExpiries: getEnvInt("SECURITY_REFRESH_EXPIRIES", 7*24*60*60), // 7 days
 			ForgotExpiries: getEnvInt("SECURITY_FORGOT_EXPIRIES", 45*60),     // 45 mins
+			VerifyExpiries: getEnvInt("SECURITY_VERIFY_EXPIRIES", 24*60*60),  // 24 hours
+			UnlockExpiries: getEnvInt("SECURITY_UNLOCK_EXPIRIES", 24*60*60),  // 24 hours
+			TwoFactorExpiries: getEnvInt("SECURITY_TWO_FACTOR_EXPIRIES", 5*60), // 5 mins
+			ImpersonationExpiries: getEnvInt("SECURITY_IMPERSONATION_EXPIRIES", 15*60), // 15 mins
+			EncryptionKey:  getEnvString("SECURITY_ENCRYPTION_KEY", DefaultEncryptionKey),
+			PaymentKeyFile: getEnvString("SECURITY_PAYMENT_KEY_FILE", "keys/payment_keys.json"),
+			KeyRotationDays: getEnvInt("SECURITY_KEY_ROTATION_DAYS", 30),
+			PasswordMinLength: getEnvInt("SECURITY_PASSWORD_MIN_LENGTH", 10),
+			PasswordMinClasses: getEnvInt("SECURITY_PASSWORD_MIN_CLASSES", 3),
+			PasswordHistory: getEnvInt("SECURITY_PASSWORD_HISTORY", 5),
+			BreachedPasswordsPath: getEnvString("SECURITY_BREACHED_PASSWORDS_PATH", ""),
+			PINMaxAttempts: getEnvInt("SECURITY_PIN_MAX_ATTEMPTS", 5),
+			PINLockoutSeconds: getEnvInt("SECURITY_PIN_LOCKOUT_SECONDS", 30*60), // 30 mins
+			PINThreshold: getEnvInt("SECURITY_PIN_THRESHOLD", 1000),
+			AuditRetentionDays: getEnvInt("SECURITY_AUDIT_RETENTION_DAYS", 7*365), // 7 years
+			AuditSpoolDir: getEnvString("SECURITY_AUDIT_SPOOL_DIR", "data/audit_spool"),
+			JWKSURL:        getEnvString("SECURITY_JWKS_URL", "http://auth:5000/.well-known/jwks.json"),
 		},
 		RMQ: models.RMQ{
-			Host:     getEnvString("RMQ_HOST", "rabbitmq"),
-			Port:     getEnvInt("RMQ_PORT", 5672),
-			User:     getEnvString("RMQ_USER", "rmquser"),
-			Pass:     getEnvString("RMQ_PASS", "rmqpassword"),
-			Queue:    getEnvString("RMQ_QUEUE", "general"),
-			Exchange: getEnvString("RMQ_EXCHANGE", ""),
+			Host:              getEnvString("RMQ_HOST", "rabbitmq"),
+			Port:              getEnvInt("RMQ_PORT", 5672),
+			User:              getEnvString("RMQ_USER", "rmquser"),
+			Pass:              getEnvString("RMQ_PASS", "rmqpassword"),
+			Queue:             getEnvString("RMQ_QUEUE", "general"),
+			Exchange:          getEnvString("RMQ_EXCHANGE", ""),
+			NotificationQueue: getEnvString("RMQ_NOTIFICATION_QUEUE", "notification"),
 		},
 		MSG: models.MSG{
 			From:     getEnvString("MSG_FROM", ""),
@@ -56,6 +87,19 @@ func InitConfig() {
 				URL:    getEnvString("MSG_SENDGRID_URL", ""),
 			},
 		},
+		SMS: models.SMS{
+			Provider: getEnvString("SMS_PROVIDER", "log"),
+			URL:      getEnvString("SMS_GATEWAY_URL", ""),
+			APIKey:   getEnvString("SMS_API_KEY", ""),
+			Sender:   getEnvString("SMS_SENDER", "LevPay"),
+			LogFile:  getEnvString("SMS_LOG_FILE", ""),
+		},
+		OAuth: models.OAuth{
+			GoogleClientIDs:    getEnvString("GOOGLE_CLIENT_IDS", os.Getenv("GOOGLE_CLIENT_ID")),
+			AppleClientIDs:     getEnvString("APPLE_CLIENT_IDS", ""),
+			MicrosoftClientIDs: getEnvString("MICROSOFT_CLIENT_IDS", ""),
+			MicrosoftTenant:    getEnvString("MICROSOFT_TENANT", "common"),
+		},
 		Minio: models.Minio{
 			Host:     getEnvString("MINIO_HOST", "minio"),
 			Port:     getEnvInt("MINIO_PORT", 9000),
@@ -71,6 +115,12 @@ func InitConfig() {
 			Password: getEnvString("REDIS_PASSWORD", "redispassword"),
 			DB:       getEnvInt("REDIS_DB", 0),
 		},
+		RateLimit: models.RateLimit{
+			Enabled: getEnvString("RATE_LIMIT_ENABLED", "true") == "true",
+			Auth:    getEnvString("RATE_LIMIT_AUTH", "60/1m"),
+			SignIn:  getEnvString("RATE_LIMIT_SIGN_IN", "10/1m"),
+			Money:   getEnvString("RATE_LIMIT_MONEY", "20/1m"),
+		},
 		Payments: models.Payments{
 			TelebirrKey: getEnvString("TELEBIRR_KEY", ""),
 			ChapaKey:    getEnvString("CHAPA_KEY", ""),
@@ -123,4 +173,15 @@ func getEnvInt(key string, fallback int) int {
 		}
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+// getEnvList reads a comma separated list
+func getEnvList(key, fallback string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnvString(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Backfills fill in data for columns AutoMigrate is about to add. They are
+// collected before AutoMigrate, since afterwards the columns exist and there
+// is no telling they were just added, and run once it completes.
+type Backfills []func(db *gorm.DB) error
+
+// PendingBackfills checks which backfills the next AutoMigrate calls for
+func PendingBackfills() Backfills {
+	var backfills Backfills
+	startedAt := time.Now()
+
+	// Accounts from before email verification keep the money movement they
+	// already had. Only accounts older than the migration are marked, so a
+	// service migrating late cannot verify new sign-ups.
+	if DB.Migrator().HasTable(&models.User{}) && !DB.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt") {
+		backfills = append(backfills, func(db *gorm.DB) error {
+			return db.Model(&models.User{}).
+				Where("email_verified_at IS NULL AND created_at < ?", startedAt).
+				Update("email_verified_at", gorm.Expr("created_at")).Error
+		})
+	}
+
+	return backfills
+}
+
+// Run applies the backfills after AutoMigrate
+func (b Backfills) Run() error {
+	for _, backfill := range b {
+		if err := backfill(DB); err != nil {
+			return fmt.Errorf("backfill failed: %w", err)
+		}
+	}
+	return nil
+}
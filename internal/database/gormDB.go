@@ -56,24 +56,53 @@ func Connect() {
 }
 
 func AutoMigrate() error {
-	return DB.AutoMigrate(
+	backfills := PendingBackfills()
+	if err := DB.AutoMigrate(
 		// Core user and authentication models
 		&models.User{},
 		&models.Session{},
+		&models.PasswordHistory{},
+		&models.PasswordResetToken{},
+		&models.OAuthIdentity{},
+		&models.AccountStatusEvent{},
+		&models.RoleAssignment{},
+		&models.RecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.PhoneVerification{},
+		&models.LoginAttempt{},
 
 		// Wallet and financial models
 		&models.Wallet{},
 		&models.Transaction{},
 		&models.PaymentMethod{},
 		&models.Invoice{},
+		&models.InvoiceReminderSetting{},
+		&models.InvoiceReminder{},
+		&models.SettlementSetting{},
+		&models.SettlementBatch{},
+		&models.Dispute{},
+		&models.DisputeEvidence{},
 
 		// KYC and verification models
 		&models.KYCDocument{},
+		&models.MerchantProfile{},
+
+		// Merchant organization models
+		&models.MerchantOrganization{},
+		&models.MerchantMember{},
+		&models.MerchantAuditLog{},
 
 		// Communication models
 		&models.Notification{},
 
 		// Audit and security models
 		&models.AuditLog{},
-	)
+		&models.SigningKey{},
+
+		// Scheduled job models
+		&models.JobRun{},
+	); err != nil {
+		return err
+	}
+	return backfills.Run()
 }
@@ -0,0 +1,139 @@
+package kms
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/utils"
+)
+
+// dataKeySize is the size of the AES-256 key generated for every record
+const dataKeySize = 32
+
+// KeyManager holds the key-encryption keys. Records are sealed with their own
+// data key and only the wrapped data key is stored, so rotating a key means
+// rewrapping small keys rather than trusting one key with every record.
+type KeyManager interface {
+	// WrapKey encrypts a data key under the active key and names that key
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts a data key wrapped under the named key
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+	// ActiveKeyID names the key new data keys are wrapped under
+	ActiveKeyID() string
+	// Fingerprint is a keyed hash that finds equal values without storing
+	// them. Its key never rotates, so fingerprints stay comparable.
+	Fingerprint(value string) string
+}
+
+// Envelope is a value sealed with a data key, stored with the wrapped data key
+type Envelope struct {
+	KeyID        string
+	EncryptedKey []byte
+	Ciphertext   []byte // nonce || AES-GCM ciphertext
+}
+
+// Seal encrypts plaintext under a fresh data key. The associated data is
+// authenticated but not stored, binding the ciphertext to its owner so it
+// cannot be copied onto another record.
+func Seal(km KeyManager, plaintext, associatedData []byte) (*Envelope, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, plaintext, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := km.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{KeyID: keyID, EncryptedKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts an envelope made by Seal with the same associated data
+func Open(km KeyManager, env *Envelope, associatedData []byte) ([]byte, error) {
+	dataKey, err := km.UnwrapKey(env.KeyID, env.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, env.Ciphertext, associatedData)
+}
+
+// seal encrypts with AES-256-GCM and prefixes the nonce
+func seal(key, plaintext, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// open decrypts a value produced by seal
+func open(key, sealed, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+	return aead, nil
+}
+
+// KMS is the process-wide key manager
+var KMS KeyManager
+
+// InitKMS loads the configured key file. Outside production a missing file
+// is generated so development works out of the box; production must be
+// given one, since replicas generating their own keys could not read each
+// other's records.
+func InitKMS() {
+	cfg := config.CFG
+	logger := utils.GetLogger("kms")
+	path := cfg.Security.PaymentKeyFile
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if cfg.App.Env == config.EnvProduction {
+			panic(fmt.Sprintf("payment key file %s does not exist", path))
+		}
+		if err := GenerateKeyFile(path); err != nil {
+			panic(err)
+		}
+		logger.Warn("Generated a new payment key file", utils.Field{Key: "path", Value: path})
+	}
+
+	km, err := NewLocalKeyManager(path)
+	if err != nil {
+		panic(err)
+	}
+	logger.Info("Payment keys loaded", utils.Field{Key: "active_key", Value: km.ActiveKeyID()})
+	KMS = km
+}
@@ -0,0 +1,259 @@
+package kms
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// keyFile is the on-disk form of a local key set
+type keyFile struct {
+	Active         string     `json:"active"`
+	FingerprintKey []byte     `json:"fingerprint_key"`
+	Keys           []localKey `json:"keys"`
+}
+
+type localKey struct {
+	ID        string    `json:"id"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LocalKeyManager keeps the keys in a JSON file readable only by its owner.
+// The file is reloaded when it changes, so running services pick up a
+// rotation without a restart.
+type LocalKeyManager struct {
+	path    string
+	mu      sync.RWMutex
+	file    keyFile
+	keys    map[string][]byte
+	modTime time.Time
+	size    int64
+}
+
+// NewLocalKeyManager loads a key file
+func NewLocalKeyManager(path string) (*LocalKeyManager, error) {
+	l := &LocalKeyManager{path: path}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GenerateKeyFile writes a new key file with one active key. It refuses to
+// overwrite an existing file, which would make its records unreadable.
+func GenerateKeyFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	fingerprintKey := make([]byte, 32)
+	if _, err := rand.Read(fingerprintKey); err != nil {
+		return fmt.Errorf("failed to generate fingerprint key: %w", err)
+	}
+	key, err := newLocalKey()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keyFile{Active: key.ID, FingerprintKey: fingerprintKey, Keys: []localKey{key}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode key file: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return f.Close()
+}
+
+// Rotate adds a new key and makes it active. Data keys wrapped under older
+// keys stay readable until they are re-encrypted.
+func (l *LocalKeyManager) Rotate() (string, error) {
+	// Build on the file as it is now, not as it was loaded
+	l.refresh()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key, err := newLocalKey()
+	if err != nil {
+		return "", err
+	}
+	file := l.file
+	file.Keys = append(append([]localKey{}, l.file.Keys...), key)
+	file.Active = key.ID
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode key file: %w", err)
+	}
+	// Write beside the file and rename so readers never see half a file
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to replace key file: %w", err)
+	}
+
+	l.set(file)
+	if info, err := os.Stat(l.path); err == nil {
+		l.modTime, l.size = info.ModTime(), info.Size()
+	}
+	return key.ID, nil
+}
+
+// ActiveKeyID names the key new data keys are wrapped under
+func (l *LocalKeyManager) ActiveKeyID() string {
+	l.refresh()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.file.Active
+}
+
+// KeyIDs lists every key in the file, oldest first
+func (l *LocalKeyManager) KeyIDs() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	ids := make([]string, len(l.file.Keys))
+	for i, k := range l.file.Keys {
+		ids[i] = k.ID
+	}
+	return ids
+}
+
+// WrapKey encrypts a data key under the active key
+func (l *LocalKeyManager) WrapKey(dataKey []byte) (string, []byte, error) {
+	l.refresh()
+	l.mu.RLock()
+	keyID := l.file.Active
+	kek := l.keys[keyID]
+	l.mu.RUnlock()
+
+	// The key ID is authenticated so a wrapped key cannot be relabelled
+	wrapped, err := seal(kek, dataKey, []byte(keyID))
+	if err != nil {
+		return "", nil, err
+	}
+	return keyID, wrapped, nil
+}
+
+// UnwrapKey decrypts a data key wrapped under the named key
+func (l *LocalKeyManager) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := l.key(keyID)
+	if !ok {
+		// The key may have been added since the file was loaded
+		l.refresh()
+		if kek, ok = l.key(keyID); !ok {
+			return nil, fmt.Errorf("unknown key %q", keyID)
+		}
+	}
+	return open(kek, wrapped, []byte(keyID))
+}
+
+// Fingerprint is an HMAC-SHA256 of the value under the fingerprint key
+func (l *LocalKeyManager) Fingerprint(value string) string {
+	l.mu.RLock()
+	mac := hmac.New(sha256.New, l.file.FingerprintKey)
+	l.mu.RUnlock()
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *LocalKeyManager) key(keyID string) ([]byte, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	kek, ok := l.keys[keyID]
+	return kek, ok
+}
+
+// refresh reloads the file if it changed on disk. The size is compared too
+// since a rotation always grows the file and mtimes can be coarse. A file that
+// cannot be read keeps the keys already loaded.
+func (l *LocalKeyManager) refresh() {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return
+	}
+	l.mu.RLock()
+	changed := !info.ModTime().Equal(l.modTime) || info.Size() != l.size
+	l.mu.RUnlock()
+	if changed {
+		_ = l.load()
+	}
+}
+
+// load reads and checks the key file
+func (l *LocalKeyManager) load() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to decode key file %s: %w", l.path, err)
+	}
+	if len(file.FingerprintKey) < 32 {
+		return fmt.Errorf("key file %s has no fingerprint key", l.path)
+	}
+	found := false
+	for _, k := range file.Keys {
+		if len(k.Key) != dataKeySize {
+			return fmt.Errorf("key %q in %s is not %d bytes", k.ID, l.path, dataKeySize)
+		}
+		found = found || k.ID == file.Active
+	}
+	if !found {
+		return fmt.Errorf("active key %q is missing from %s", file.Active, l.path)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.set(file)
+	l.modTime, l.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// set replaces the loaded keys; the caller holds the lock
+func (l *LocalKeyManager) set(file keyFile) {
+	l.file = file
+	l.keys = make(map[string][]byte, len(file.Keys))
+	for _, k := range file.Keys {
+		l.keys[k.ID] = k.Key
+	}
+}
+
+// newLocalKey generates a key named by its creation date and a random suffix
+func newLocalKey() (localKey, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return localKey{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return localKey{}, fmt.Errorf("failed to generate key ID: %w", err)
+	}
+	now := time.Now().UTC()
+	return localKey{
+		ID:        now.Format("20060102") + "-" + hex.EncodeToString(suffix),
+		Key:       key,
+		CreatedAt: now,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/Keba777/levpay-backend/internal/audit"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// impersonatedRequest serves a request made with an impersonation token. The
+// impersonator must still be allowed to impersonate, only reads go through so
+// no money can move, and every request is written to the audit log.
+func impersonatedRequest(c *fiber.Ctx, db *gorm.DB, decodedToken models.DecodedToken, user models.User) error {
+	var impersonator models.User
+	err := db.Preload("RoleAssignments").First(&impersonator, "id = ?", decodedToken.ImpersonatorID).Error
+	if err != nil || !impersonator.CanSignIn() || !impersonator.HasPermission(models.PermUsersImpersonate) {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+			Message: "Impersonation is no longer allowed",
+		})
+	}
+
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+	default:
+		auditImpersonatedRequest(c, decodedToken, user, fiber.StatusForbidden)
+		return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+			Message: "Read-only access while viewing as this user",
+		})
+	}
+
+	c.Locals("user", user)
+	c.Locals("decoded_token", decodedToken)
+
+	err = c.Next()
+
+	status := c.Response().StatusCode()
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status = fiberErr.Code
+	} else if err != nil {
+		status = fiber.StatusInternalServerError
+	}
+	auditImpersonatedRequest(c, decodedToken, user, status)
+
+	return err
+}
+
+// auditImpersonatedRequest records a request made while impersonating. The
+// impersonator is the actor and the user being viewed is the target.
+func auditImpersonatedRequest(c *fiber.Ctx, decodedToken models.DecodedToken, user models.User, status int) {
+	e := audit.Request(c, models.AuditActionImpersonatedCall)
+	e.ActorID = &decodedToken.ImpersonatorID
+	e.TargetType = models.AuditTargetUser
+	e.TargetID = &user.ID
+	e.Details = map[string]interface{}{
+		"method": c.Method(),
+		"path":   c.Path(),
+		"status": status,
+	}
+	audit.Record(e)
+}
@@ -3,10 +3,13 @@ package middleware
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/Keba777/levpay-backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -33,14 +36,33 @@ func JWTMiddleware(db *gorm.DB) fiber.Handler {
 			})
 		}
 
+		// Reject tokens whose session has been signed out
+		if !sessionActive(db, decodedToken) {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+				Message: "Session has been revoked",
+			})
+		}
+
 		// Load user from database
 		var user models.User
-		if err := db.Preload("Sessions").Preload("Wallet").First(&user, "id = ?", decodedToken.UserID).Error; err != nil {
+		if err := db.Preload("Sessions").Preload("Wallet").Preload("RoleAssignments").First(&user, "id = ?", decodedToken.UserID).Error; err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
 				Message: "User not found",
 			})
 		}
 
+		// Suspended and closed accounts lose access immediately
+		if !user.CanSignIn() {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "This account has been " + user.Status,
+			})
+		}
+
+		// Staff viewing as the user get read-only, audited access
+		if decodedToken.IsImpersonation() {
+			return impersonatedRequest(c, db, decodedToken, user)
+		}
+
 		// Store user and token in context
 		c.Locals("user", user)
 		c.Locals("decoded_token", decodedToken)
@@ -49,8 +71,9 @@ func JWTMiddleware(db *gorm.DB) fiber.Handler {
 	}
 }
 
-// RequireRole ensures the user has the specified role
-func RequireRole(allowedRoles ...string) fiber.Handler {
+// RequirePermission ensures the user's account role or a granted staff role
+// carries the platform permission
+func RequirePermission(permission string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user, ok := c.Locals("user").(models.User)
 		if !ok {
@@ -59,16 +82,70 @@ func RequireRole(allowedRoles ...string) fiber.Handler {
 			})
 		}
 
-		// Check if user's role is in allowed roles
-		for _, role := range allowedRoles {
-			if user.Role == role {
-				return c.Next()
-			}
+		if !user.HasPermission(permission) {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: fmt.Sprintf("Access denied. Required permission: %s", permission),
+			})
 		}
 
-		return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
-			Message: fmt.Sprintf("Access denied. Required roles: %v", allowedRoles),
-		})
+		return c.Next()
+	}
+}
+
+// RequireStepUp requires users with 2FA enabled to present a recent step-up
+// token in the X-Step-Up-Token header. Users without 2FA pass through. The
+// policy's window is checked against the issue time, so shortening it also
+// applies to tokens already issued.
+func RequireStepUp(policy *security.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(models.User)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+				Message: "Unauthorized",
+			})
+		}
+
+		if !user.TwoFAEnabled {
+			return c.Next()
+		}
+
+		token := c.Get(models.StepUpHeader)
+		if token == "" {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "Two-factor verification required",
+			})
+		}
+
+		decodedToken, err := utils.JWT.DecodeToken(token, models.JWTStepUp)
+		maxAge := time.Duration(policy.TwoFactorExpiry) * time.Second
+		if err != nil || decodedToken.UserID != user.ID || time.Since(time.Unix(int64(decodedToken.IssuedAt), 0)) > maxAge {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "Invalid or expired step-up token",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireVerifiedEmail blocks money movement for users who have not verified
+// their email address
+func RequireVerifiedEmail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(models.User)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+				Message: "Unauthorized",
+			})
+		}
+
+		if user.EmailVerifiedAt == nil {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "Please verify your email address to continue",
+			})
+		}
+
+		return c.Next()
 	}
 }
 
@@ -83,13 +160,14 @@ func OptionalJWT(db *gorm.DB) fiber.Handler {
 		authHeader = strings.TrimSpace(authHeader)
 		authHeader = strings.TrimPrefix(authHeader, "Bearer ")
 
+		// Impersonation tokens are only honoured where JWTMiddleware can audit them
 		decodedToken, err := utils.JWT.DecodeToken(authHeader, models.JWTAccess)
-		if err != nil {
+		if err != nil || decodedToken.IsImpersonation() || !sessionActive(db, decodedToken) {
 			return c.Next()
 		}
 
 		var user models.User
-		if err := db.Preload("Sessions").Preload("Wallet").First(&user, "id = ?", decodedToken.UserID).Error; err != nil {
+		if err := db.Preload("Sessions").Preload("Wallet").Preload("RoleAssignments").First(&user, "id = ?", decodedToken.UserID).Error; err != nil || !user.CanSignIn() {
 			return c.Next()
 		}
 
@@ -99,3 +177,33 @@ func OptionalJWT(db *gorm.DB) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// sessionActive reports whether the session an access token was issued for is
+// still signed in, and refreshes its last seen time at most once a minute.
+// Every access token is issued for a session, so one without a session cannot
+// be revoked and is refused.
+func sessionActive(db *gorm.DB, decodedToken models.DecodedToken) bool {
+	if decodedToken.SessionID == uuid.Nil {
+		return false
+	}
+
+	// Impersonation tokens ride on the impersonator's own session
+	owner := decodedToken.UserID
+	if decodedToken.IsImpersonation() {
+		owner = decodedToken.ImpersonatorID
+	}
+
+	var session models.Session
+	err := db.Where("family_id = ? AND user_id = ? AND active = ?", decodedToken.SessionID, owner, true).
+		Order("created_at desc").
+		First(&session).Error
+	if err != nil {
+		return false
+	}
+
+	now := time.Now()
+	if session.LastSeenAt == nil || now.Sub(*session.LastSeenAt) > time.Minute {
+		db.Model(&models.Session{}).Where("id = ?", session.ID).Update("last_seen_at", now)
+	}
+	return true
+}
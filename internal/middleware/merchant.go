@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MerchantHeader selects the merchant account a staff member acts on behalf of
+const MerchantHeader = "X-Merchant-ID"
+
+// MerchantContext resolves the merchant account for the request and stores it
+// with the caller's organization role. Without the header users act on their
+// own account as its owner. Must run after JWTMiddleware.
+func MerchantContext(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(models.User)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.InfoResponse{
+				Message: "Unauthorized",
+			})
+		}
+
+		header := c.Get(MerchantHeader)
+		if header == "" || header == user.ID.String() {
+			c.Locals("merchant_id", user.ID)
+			c.Locals("merchant_role", models.OrgRoleOwner)
+			return c.Next()
+		}
+
+		merchantID, err := uuid.Parse(header)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.InfoResponse{
+				Message: "Invalid merchant ID",
+			})
+		}
+
+		var member models.MerchantMember
+		if err := db.Where("merchant_id = ? AND user_id = ? AND status = ?", merchantID, user.ID, models.MemberStatusActive).
+			First(&member).Error; err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "You are not a member of this merchant",
+			})
+		}
+
+		c.Locals("merchant_id", merchantID)
+		c.Locals("merchant_role", member.Role)
+
+		return c.Next()
+	}
+}
+
+// RequireMerchantAccount ensures the merchant account resolved by
+// MerchantContext belongs to a merchant, so customers acting on their own
+// account are refused. Must run after MerchantContext.
+func RequireMerchantAccount(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		merchantID, ok := c.Locals("merchant_id").(uuid.UUID)
+		if !ok {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "Merchant context required",
+			})
+		}
+
+		isMerchant := false
+		if user, ok := c.Locals("user").(models.User); ok && user.ID == merchantID {
+			isMerchant = user.Role == models.RoleMerchant
+		} else {
+			var count int64
+			if err := db.Model(&models.User{}).Where("id = ? AND role = ?", merchantID, models.RoleMerchant).Count(&count).Error; err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(models.InfoResponse{
+					Message: "Failed to check merchant account",
+				})
+			}
+			isMerchant = count > 0
+		}
+		if !isMerchant {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "Access denied. Merchant account required",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireMerchantPermission ensures the caller's organization role grants the
+// permission. Must run after MerchantContext.
+func RequireMerchantPermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := c.Locals("merchant_role").(string)
+		if !ok {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: "Merchant context required",
+			})
+		}
+
+		if !models.OrgRoleHasPermission(role, permission) {
+			return c.Status(fiber.StatusForbidden).JSON(models.InfoResponse{
+				Message: fmt.Sprintf("Access denied. Required permission: %s", permission),
+			})
+		}
+
+		return c.Next()
+	}
+}
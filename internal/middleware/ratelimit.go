@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rate limit response headers
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimit limits requests under a configured policy, keyed by the signed-in
+// user for per-user policies and by client IP otherwise. Per-user policies
+// must run after JWTMiddleware. When several policies apply, the headers
+// describe the one with the fewest requests left.
+func RateLimit(name string) fiber.Handler {
+	if ratelimit.Default == nil {
+		panic("rate limiter not initialized")
+	}
+	policy, ok := ratelimit.Default.Policy(name)
+	if !ok {
+		panic(fmt.Sprintf("unknown rate limit policy: %s", name))
+	}
+	if !ratelimit.Default.Enabled() {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := "ip:" + c.IP()
+		if user, ok := c.Locals("user").(models.User); ok && policy.PerUser {
+			key = "user:" + user.ID.String()
+		}
+
+		result := ratelimit.Default.Allow(policy, key)
+		setRateLimitHeaders(c, policy, result)
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+			return c.Status(fiber.StatusTooManyRequests).JSON(models.InfoResponse{
+				Message: "Too many requests. Please try again later.",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// setRateLimitHeaders describes the policy unless an earlier one on the same
+// request has fewer requests left
+func setRateLimitHeaders(c *fiber.Ctx, policy ratelimit.Policy, result ratelimit.Result) {
+	if existing := c.GetRespHeader(HeaderRateLimitRemaining); existing != "" && result.Allowed {
+		if remaining, err := strconv.ParseInt(existing, 10, 64); err == nil && remaining <= result.Remaining {
+			return
+		}
+	}
+
+	c.Set(HeaderRateLimitLimit, strconv.FormatInt(result.Limit, 10))
+	c.Set(HeaderRateLimitRemaining, strconv.FormatInt(result.Remaining, 10))
+	c.Set(HeaderRateLimitReset, strconv.FormatInt(ceilSeconds(result.Reset), 10))
+	c.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Window)))
+}
+
+// ceilSeconds rounds a duration up to whole seconds, never below one
+func ceilSeconds(d time.Duration) int64 {
+	return max(int64(math.Ceil(d.Seconds())), 1)
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Account Status Constants
+const (
+	AccountStatusActive    = "active"
+	AccountStatusFrozen    = "frozen"    // Can sign in, but the wallet cannot move money
+	AccountStatusSuspended = "suspended" // Signed out everywhere and refused until reinstated
+	AccountStatusClosed    = "closed"    // Signed out everywhere and refused permanently
+)
+
+// IsAccountStatus reports whether s is a known account status
+func IsAccountStatus(s string) bool {
+	switch s {
+	case AccountStatusActive, AccountStatusFrozen, AccountStatusSuspended, AccountStatusClosed:
+		return true
+	}
+	return false
+}
+
+// AccountStatusAllowsSignIn reports whether accounts in the status may sign
+// in. Frozen accounts can; only their wallet is blocked.
+func AccountStatusAllowsSignIn(status string) bool {
+	return status != AccountStatusSuspended && status != AccountStatusClosed
+}
+
+// AccountStatusEvent records an account status change, who made it and why
+type AccountStatusEvent struct {
+	gorm.Model
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	PreviousStatus string     `gorm:"not null"`
+	Status         string     `gorm:"not null"`
+	Reason         string     `gorm:"not null"`
+	ChangedBy      *uuid.UUID `gorm:"type:uuid"` // Admin who made the change
+}
+
+// AccountStatusEventResponse describes one entry of an account's status history
+type AccountStatusEventResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	PreviousStatus string     `json:"previous_status"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason"`
+	ChangedBy      *uuid.UUID `json:"changed_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ToResponse converts an AccountStatusEvent to AccountStatusEventResponse
+func (e *AccountStatusEvent) ToResponse() AccountStatusEventResponse {
+	return AccountStatusEventResponse{
+		ID:             e.ID,
+		PreviousStatus: e.PreviousStatus,
+		Status:         e.Status,
+		Reason:         e.Reason,
+		ChangedBy:      e.ChangedBy,
+		CreatedAt:      e.CreatedAt,
+	}
+}
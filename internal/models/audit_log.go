@@ -1,18 +1,188 @@
 package models
 
 import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
-// AuditLog represents a system audit log
+// Audit Action Constants
+const (
+	AuditActionLogin            = "auth.login"
+	AuditActionLogout           = "auth.logout"
+	AuditActionLoginFailed      = "auth.login_failed"
+	AuditActionAccountLocked    = "auth.account_locked"
+	AuditActionAccountUnlocked  = "auth.account_unlocked"
+	AuditActionTokenReuse       = "auth.refresh_token_reuse"
+	AuditActionPasswordChanged  = "auth.password_changed"
+	AuditActionPasswordReset    = "auth.password_reset"
+	AuditActionIdentityLinked   = "auth.identity_linked"
+	AuditActionIdentityUnlinked = "auth.identity_unlinked"
+	AuditActionPINSet           = "auth.pin_set"
+	AuditActionPINChanged       = "auth.pin_changed"
+	AuditActionPINReset         = "auth.pin_reset"
+	AuditActionPINLocked        = "auth.pin_locked"
+	AuditActionKYCReviewed      = "kyc.reviewed"
+	AuditActionWalletLocked     = "wallet.locked"
+	AuditActionWalletUnlocked   = "wallet.unlocked"
+	AuditActionTopUp            = "transaction.top_up"
+	AuditActionWithdrawal       = "transaction.withdrawal"
+	AuditActionTransfer         = "transaction.transfer"
+	AuditActionPayment          = "transaction.payment"
+	AuditActionRefund           = "transaction.refund"
+	AuditActionStatusChanged    = "admin.account_status_changed"
+	AuditActionRoleGranted      = "admin.role_granted"
+	AuditActionRoleRevoked      = "admin.role_revoked"
+	AuditActionImpersonation    = "admin.impersonation_started"
+	AuditActionImpersonatedCall = "admin.impersonated_request"
+	AuditActionAuditExported    = "audit.exported"
+	AuditActionAuditPurged      = "audit.purged"
+)
+
+// Audit Target Type Constants
+const (
+	AuditTargetUser        = "user"
+	AuditTargetWallet      = "wallet"
+	AuditTargetTransaction = "transaction"
+	AuditTargetKYCDocument = "kyc_document"
+	AuditTargetDispute     = "dispute"
+)
+
+// AuditLog represents a system audit log entry. Entries form a hash chain:
+// each Hash covers the entry and the PrevHash of the one before it, so an
+// edited or deleted row breaks the chain from that point on.
 type AuditLog struct {
 	gorm.Model
-	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID    *uuid.UUID     `gorm:"type:uuid"` // Optional
-	Action    string         `gorm:"not null"`
-	IPAddress *string        // Optional
-	Details   datatypes.JSON // Flexible JSON data
-	User      User           `gorm:"foreignKey:UserID"`
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Sequence   int64          `gorm:"uniqueIndex"`     // Position in the hash chain; empty on entries older than the chain
+	UserID     *uuid.UUID     `gorm:"type:uuid;index"` // Actor; empty for anonymous and system events
+	Action     string         `gorm:"not null;index"`
+	TargetType string         `gorm:"index:idx_audit_log_target"`
+	TargetID   *uuid.UUID     `gorm:"type:uuid;index:idx_audit_log_target"`
+	IPAddress  *string        // Optional
+	UserAgent  *string        // Optional
+	Details    datatypes.JSON `gorm:"index:idx_audit_log_details_search,type:gin,expression:jsonb_to_tsvector('simple'\\, details\\, '[\"string\"\\, \"numeric\"]')"` // Flexible JSON data, indexed for full-text search
+	Changes    datatypes.JSON // Field-level before/after values
+	PrevHash   string         // Hash of the previous entry in the chain
+	Hash       string         // SHA-256 over this entry and PrevHash
+	User       User           `gorm:"foreignKey:UserID"`
+}
+
+// AuditChainVerification reports the result of checking the audit hash chain
+type AuditChainVerification struct {
+	Valid      bool      `json:"valid"`
+	Checked    int64     `json:"checked"`
+	BrokenAt   *int64    `json:"broken_at,omitempty"` // Sequence of the first entry that fails
+	Reason     string    `json:"reason,omitempty"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// AuditLogResponse describes an audit log entry
+type AuditLogResponse struct {
+	ID         uuid.UUID      `json:"id"`
+	Sequence   int64          `json:"sequence,omitempty"`
+	ActorID    *uuid.UUID     `json:"actor_id,omitempty"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type,omitempty"`
+	TargetID   *uuid.UUID     `json:"target_id,omitempty"`
+	IPAddress  *string        `json:"ip_address,omitempty"`
+	UserAgent  *string        `json:"user_agent,omitempty"`
+	Details    datatypes.JSON `json:"details,omitempty"`
+	Changes    datatypes.JSON `json:"changes,omitempty"`
+	Hash       string         `json:"hash,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// ToResponse converts an AuditLog to AuditLogResponse
+func (l *AuditLog) ToResponse() AuditLogResponse {
+	return AuditLogResponse{
+		ID:         l.ID,
+		Sequence:   l.Sequence,
+		ActorID:    l.UserID,
+		Action:     l.Action,
+		TargetType: l.TargetType,
+		TargetID:   l.TargetID,
+		IPAddress:  l.IPAddress,
+		UserAgent:  l.UserAgent,
+		Details:    l.Details,
+		Changes:    l.Changes,
+		Hash:       l.Hash,
+		CreatedAt:  l.CreatedAt,
+	}
+}
+
+// AuditLogFilter narrows the system audit log for search and export
+type AuditLogFilter struct {
+	ActorID      *uuid.UUID `json:"actor_id,omitempty"`
+	TargetUserID *uuid.UUID `json:"user_id,omitempty"`
+	Action       string     `json:"action,omitempty"` // Exact action, or a category such as "auth.*"
+	IPAddress    string     `json:"ip,omitempty"`
+	From         *time.Time `json:"from,omitempty"`
+	To           *time.Time `json:"to,omitempty"`
+	Search       string     `json:"q,omitempty"` // Full-text search over details
+}
+
+// FromContext parses audit log filters from query params. Dates are RFC 3339
+// timestamps or plain dates; a plain "to" date includes the whole day. The
+// strings are copied because exports read them after the handler returns.
+// Invalid filters are returned as 400 errors ready for the handler.
+func (f *AuditLogFilter) FromContext(c *fiber.Ctx) error {
+	var err error
+	if f.ActorID, err = queryUUID(c, "actor_id"); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid actor ID")
+	}
+	if f.TargetUserID, err = queryUUID(c, "user_id"); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+	if f.From, err = queryTime(c, "from", false); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid from date")
+	}
+	if f.To, err = queryTime(c, "to", true); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid to date")
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return fiber.NewError(fiber.StatusBadRequest, "The to date must not be before the from date")
+	}
+
+	f.Action = strings.Clone(strings.TrimSpace(c.Query("action")))
+	f.IPAddress = strings.Clone(strings.TrimSpace(c.Query("ip")))
+	f.Search = strings.Clone(strings.TrimSpace(c.Query("q")))
+	return nil
+}
+
+// queryUUID parses an optional UUID query param
+func queryUUID(c *fiber.Ctx, key string) (*uuid.UUID, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// queryTime parses an optional RFC 3339 timestamp or date query param. With
+// endOfDay a plain date means the end of that day.
+func queryTime(c *fiber.Ctx, key string, endOfDay bool) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
 }
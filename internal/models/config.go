@@ -17,6 +17,8 @@ type App struct {
 	Shutdown int
 	Service  string
 	Url      string
+	Env      string // development or production
+	TrustedProxies []string // Networks whose X-Real-IP header is trusted (the nginx gateway)
 }
 
 type Security struct {
@@ -25,15 +27,33 @@ type Security struct {
 	RefreshExpiries int
 	AccessExpiries int
 	ForgotExpiries int
+	VerifyExpiries int
+	UnlockExpiries int
+	TwoFactorExpiries int // Challenge and step-up tokens
+	ImpersonationExpiries int // Read-only tokens for staff viewing as a user
+	EncryptionKey  string // Key material for secrets encrypted at rest
+	PaymentKeyFile string // Local key file wrapping the keys that encrypt payment method details
+	KeyRotationDays int    // Days a JWT signing key signs before it is rotated
+	PasswordMinLength int
+	PasswordMinClasses int // Distinct character classes required, out of 4
+	PasswordHistory int    // Recent passwords that cannot be reused
+	BreachedPasswordsPath string // Breach hash list file or directory of range files
+	PINMaxAttempts int    // Wrong transaction PINs before the PIN locks
+	PINLockoutSeconds int // How long a locked PIN stays locked
+	PINThreshold int      // Payments above this amount need the PIN or a 2FA code
+	AuditRetentionDays int // Days audit log entries are kept; 0 keeps them forever
+	AuditSpoolDir string // Where audit batches the database refused wait to be written again
+	JWKSURL        string // Where verify-only services fetch the JWT verification keys
 }
 
 type RMQ struct {
-	Host     string
-	Port     int
-	User     string
-	Pass     string
-	Queue    string
-	Exchange string
+	Host              string
+	Port              int
+	User              string
+	Pass              string
+	Queue             string
+	Exchange          string
+	NotificationQueue string // queue consumed by the notification service
 }
 
 type SendGrid struct {
@@ -48,6 +68,21 @@ type MSG struct {
 	SendGrid SendGrid
 }
 
+type SMS struct {
+	Provider string // log or http
+	URL      string // HTTP gateway endpoint
+	APIKey   string
+	Sender   string
+	LogFile  string // Optional file the log provider appends messages to
+}
+
+type OAuth struct {
+	GoogleClientIDs    string // Comma separated; web, Android and iOS clients differ
+	AppleClientIDs     string // Comma separated Apple services and bundle IDs
+	MicrosoftClientIDs string // Comma separated
+	MicrosoftTenant    string // Tenant ID, or common for any Microsoft account
+}
+
 type Minio struct {
 	Host     string
 	Port     int
@@ -65,6 +100,13 @@ type Redis struct {
 	DB       int
 }
 
+type RateLimit struct {
+	Enabled bool
+	Auth    string // Requests per client IP to /api/auth, as limit/window, e.g. 60/1m
+	SignIn  string // Sign-in, registration and recovery requests per client IP
+	Money   string // Money movement requests per user
+}
+
 type Payments struct {
 	TelebirrKey string
 	ChapaKey    string
@@ -84,7 +126,10 @@ type Config struct {
 	Security Security
 	RMQ      RMQ
 	MSG      MSG
+	SMS      SMS
+	OAuth    OAuth
 	Minio    Minio
 	Redis    Redis
+	RateLimit RateLimit
 	Payments Payments // LevPay-specific payment integrations
 }
\ No newline at end of file
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Dispute Status Constants
+const (
+	DisputeStatusOpen        = "open"         // Awaiting merchant response
+	DisputeStatusUnderReview = "under_review" // Merchant contested, awaiting admin decision
+	DisputeStatusResolved    = "resolved"
+	DisputeStatusCancelled   = "cancelled" // Withdrawn by the payer
+)
+
+// Dispute Outcome Constants
+const (
+	DisputeOutcomePayer    = "payer"    // Payment refunded
+	DisputeOutcomeMerchant = "merchant" // Held funds released to merchant
+)
+
+// Dispute Reason Constants
+const (
+	DisputeReasonNotReceived    = "not_received"
+	DisputeReasonNotAsDescribed = "not_as_described"
+	DisputeReasonUnauthorized   = "unauthorized"
+	DisputeReasonDuplicate      = "duplicate"
+	DisputeReasonOther          = "other"
+)
+
+// Dispute Party Constants
+const (
+	DisputePartyPayer    = "payer"
+	DisputePartyMerchant = "merchant"
+)
+
+// Dispute deadlines
+const (
+	DisputeWindowDays   = 60 // Days after a payment during which it can be disputed
+	DisputeResponseDays = 7  // Days the merchant has to respond before the payer wins
+	DisputeReviewDays   = 14 // Target days for an admin decision after a response
+)
+
+// IsDisputeReason reports whether a dispute reason is supported
+func IsDisputeReason(reason string) bool {
+	switch reason {
+	case DisputeReasonNotReceived, DisputeReasonNotAsDescribed, DisputeReasonUnauthorized,
+		DisputeReasonDuplicate, DisputeReasonOther:
+		return true
+	}
+	return false
+}
+
+// Dispute represents a payer's challenge of a merchant payment
+type Dispute struct {
+	gorm.Model
+	ID                  uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TransactionID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	PayerID             uuid.UUID  `gorm:"type:uuid;not null;index"`
+	MerchantID          uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Amount              float64    `gorm:"not null"`
+	Currency            string     `gorm:"not null;default:'ETB'"`
+	HeldAmount          float64    `gorm:"default:0"` // Portion of Amount held from the merchant's wallet
+	Reason              string     `gorm:"not null"`
+	Description         string     `gorm:"type:text;not null"`
+	Status              string     `gorm:"default:'open';index"` // Enum: open, under_review, resolved, cancelled
+	MerchantResponse    *string    `gorm:"type:text"`
+	ResponseDueAt       time.Time  `gorm:"not null;index"`
+	ResponseReminderAt  *time.Time // When the merchant was reminded of the deadline
+	RespondedAt         *time.Time
+	ReviewDueAt         *time.Time
+	ReviewEscalatedAt   *time.Time // When staff were alerted that the review is overdue
+	Outcome             *string    // Enum: payer, merchant
+	ResolutionNotes     *string    `gorm:"type:text"`
+	ResolvedBy          *uuid.UUID `gorm:"type:uuid"` // Nil when resolved automatically
+	ResolvedAt          *time.Time
+	RefundTransactionID *uuid.UUID        `gorm:"type:uuid"`
+	Evidence            []DisputeEvidence `gorm:"foreignKey:DisputeID"`
+}
+
+// DisputeEvidence is a file submitted by either party to support their case
+type DisputeEvidence struct {
+	gorm.Model
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	DisputeID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	UploadedBy  uuid.UUID `gorm:"type:uuid;not null"`
+	Party       string    `gorm:"not null"` // payer, merchant
+	FileName    string    `gorm:"not null"`
+	FilePath    string    `gorm:"not null"` // MinIO path
+	ContentType string
+	FileSize    int64
+	Note        *string `gorm:"type:text"`
+}
+
+// IsActive reports whether the dispute still awaits a decision
+func (d *Dispute) IsActive() bool {
+	return d.Status == DisputeStatusOpen || d.Status == DisputeStatusUnderReview
+}
+
+// DisputeResponse for dispute data
+type DisputeResponse struct {
+	ID                  uuid.UUID                 `json:"id"`
+	TransactionID       uuid.UUID                 `json:"transaction_id"`
+	PayerID             uuid.UUID                 `json:"payer_id"`
+	MerchantID          uuid.UUID                 `json:"merchant_id"`
+	Amount              float64                   `json:"amount"`
+	Currency            string                    `json:"currency"`
+	HeldAmount          float64                   `json:"held_amount"`
+	Reason              string                    `json:"reason"`
+	Description         string                    `json:"description"`
+	Status              string                    `json:"status"`
+	MerchantResponse    *string                   `json:"merchant_response,omitempty"`
+	ResponseDueAt       time.Time                 `json:"response_due_at"`
+	RespondedAt         *time.Time                `json:"responded_at,omitempty"`
+	ReviewDueAt         *time.Time                `json:"review_due_at,omitempty"`
+	Outcome             *string                   `json:"outcome,omitempty"`
+	ResolutionNotes     *string                   `json:"resolution_notes,omitempty"`
+	ResolvedAt          *time.Time                `json:"resolved_at,omitempty"`
+	RefundTransactionID *uuid.UUID                `json:"refund_transaction_id,omitempty"`
+	Evidence            []DisputeEvidenceResponse `json:"evidence,omitempty"`
+	CreatedAt           time.Time                 `json:"created_at"`
+}
+
+// DisputeEvidenceResponse for dispute evidence data
+type DisputeEvidenceResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Party       string    `json:"party"`
+	FileName    string    `json:"file_name"`
+	FileURL     string    `json:"file_url"`
+	ContentType string    `json:"content_type"`
+	FileSize    int64     `json:"file_size"`
+	Note        *string   `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToResponse converts Dispute to DisputeResponse
+func (d *Dispute) ToResponse() DisputeResponse {
+	evidence := make([]DisputeEvidenceResponse, len(d.Evidence))
+	for i, e := range d.Evidence {
+		evidence[i] = e.ToResponse()
+	}
+
+	return DisputeResponse{
+		ID:                  d.ID,
+		TransactionID:       d.TransactionID,
+		PayerID:             d.PayerID,
+		MerchantID:          d.MerchantID,
+		Amount:              d.Amount,
+		Currency:            d.Currency,
+		HeldAmount:          d.HeldAmount,
+		Reason:              d.Reason,
+		Description:         d.Description,
+		Status:              d.Status,
+		MerchantResponse:    d.MerchantResponse,
+		ResponseDueAt:       d.ResponseDueAt,
+		RespondedAt:         d.RespondedAt,
+		ReviewDueAt:         d.ReviewDueAt,
+		Outcome:             d.Outcome,
+		ResolutionNotes:     d.ResolutionNotes,
+		ResolvedAt:          d.ResolvedAt,
+		RefundTransactionID: d.RefundTransactionID,
+		Evidence:            evidence,
+		CreatedAt:           d.CreatedAt,
+	}
+}
+
+// ToResponse converts DisputeEvidence to DisputeEvidenceResponse
+func (e *DisputeEvidence) ToResponse() DisputeEvidenceResponse {
+	return DisputeEvidenceResponse{
+		ID:          e.ID,
+		Party:       e.Party,
+		FileName:    e.FileName,
+		FileURL:     e.FilePath,
+		ContentType: e.ContentType,
+		FileSize:    e.FileSize,
+		Note:        e.Note,
+		CreatedAt:   e.CreatedAt,
+	}
+}
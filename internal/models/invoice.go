@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -68,3 +69,60 @@ func (i *Invoice) ToResponse() InvoiceResponse {
 		CreatedAt:     i.CreatedAt,
 	}
 }
+
+// Invoice Reminder Kind Constants
+const (
+	InvoiceReminderBeforeDue = "before_due"
+	InvoiceReminderAfterDue  = "after_due"
+	InvoiceReminderOverdue   = "overdue"
+)
+
+// DefaultReminderBeforeDays and DefaultReminderAfterDays apply to merchants
+// that have not configured their own reminder offsets
+var (
+	DefaultReminderBeforeDays = []int{3}
+	DefaultReminderAfterDays  = []int{1, 7}
+)
+
+// InvoiceReminderSetting holds a merchant's reminder offsets in days relative to the due date
+type InvoiceReminderSetting struct {
+	gorm.Model
+	ID            uuid.UUID                `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	MerchantID    uuid.UUID                `gorm:"unique;not null;type:uuid"`
+	BeforeDueDays datatypes.JSONSlice[int] `gorm:"type:jsonb"` // e.g. [7, 3, 1]
+	AfterDueDays  datatypes.JSONSlice[int] `gorm:"type:jsonb"` // e.g. [1, 7, 14]
+	Enabled       bool                     `gorm:"default:true"`
+}
+
+// InvoiceReminder records a reminder sent for an invoice so it is never sent twice
+type InvoiceReminder struct {
+	gorm.Model
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	InvoiceID  uuid.UUID `gorm:"not null;type:uuid;uniqueIndex:idx_invoice_reminder_stage"`
+	Kind       string    `gorm:"not null;uniqueIndex:idx_invoice_reminder_stage"` // before_due, after_due, overdue
+	OffsetDays int       `gorm:"not null;uniqueIndex:idx_invoice_reminder_stage"`
+	Recipient  string    `gorm:"not null"`
+	SentAt     time.Time
+}
+
+// InvoiceReminderResponse for API responses
+type InvoiceReminderResponse struct {
+	ID         uuid.UUID `json:"id"`
+	InvoiceID  uuid.UUID `json:"invoice_id"`
+	Kind       string    `json:"kind"`
+	OffsetDays int       `json:"offset_days"`
+	Recipient  string    `json:"recipient"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// ToResponse converts invoice reminder to API response format
+func (r *InvoiceReminder) ToResponse() InvoiceReminderResponse {
+	return InvoiceReminderResponse{
+		ID:         r.ID,
+		InvoiceID:  r.InvoiceID,
+		Kind:       r.Kind,
+		OffsetDays: r.OffsetDays,
+		Recipient:  r.Recipient,
+		SentAt:     r.SentAt,
+	}
+}
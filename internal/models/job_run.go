@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Cron Job Name Constants
+const (
+	JobMarkOverdueInvoices     = "mark_overdue_invoices"
+	JobCleanupExpiredSessions  = "cleanup_expired_sessions"
+	JobSendPaymentReminders    = "send_payment_reminders"
+	JobUpdateInvoiceStatuses   = "update_invoice_statuses"
+	JobSettleMerchants         = "settle_merchants"
+	JobEnforceDisputeDeadlines = "enforce_dispute_deadlines"
+	JobPurgeAuditLogs          = "purge_audit_logs"
+)
+
+// CronJobs lists every job the cron service can run
+var CronJobs = []string{
+	JobMarkOverdueInvoices,
+	JobCleanupExpiredSessions,
+	JobSendPaymentReminders,
+	JobUpdateInvoiceStatuses,
+	JobSettleMerchants,
+	JobEnforceDisputeDeadlines,
+	JobPurgeAuditLogs,
+}
+
+// Job Run Status Constants
+const (
+	JobRunStatusQueued    = "queued"
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+)
+
+// JobRunTimeout is how long a run may stay running before it is taken to
+// belong to a scheduler that crashed
+const JobRunTimeout = 6 * time.Hour
+
+// Job Run Trigger Constants
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// JobRun records a single execution of a cron job
+type JobRun struct {
+	gorm.Model
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	JobName      string     `gorm:"not null;index;uniqueIndex:idx_job_run_slot"`
+	Trigger      string     `gorm:"not null"`                        // schedule, manual
+	Status       string     `gorm:"not null;default:'queued';index"` // queued, running, succeeded, failed
+	ScheduledFor *time.Time `gorm:"uniqueIndex:idx_job_run_slot"`    // Cron slot, null for manual runs
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+	RowsAffected int64
+	Error        *string    `gorm:"type:text"`
+	Host         string     // Replica that executed the run
+	RequestedBy  *uuid.UUID `gorm:"type:uuid"` // Admin who triggered a manual run
+}
+
+// JobRunResponse for API responses
+type JobRunResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	JobName      string     `json:"job_name"`
+	Trigger      string     `json:"trigger"`
+	Status       string     `json:"status"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	DurationMs   int64      `json:"duration_ms"`
+	RowsAffected int64      `json:"rows_affected"`
+	Error        *string    `json:"error,omitempty"`
+	Host         string     `json:"host,omitempty"`
+	RequestedBy  *uuid.UUID `json:"requested_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ToResponse converts job run to API response format
+func (j *JobRun) ToResponse() JobRunResponse {
+	var duration int64
+	if j.StartedAt != nil && j.FinishedAt != nil {
+		duration = j.FinishedAt.Sub(*j.StartedAt).Milliseconds()
+	}
+	return JobRunResponse{
+		ID:           j.ID,
+		JobName:      j.JobName,
+		Trigger:      j.Trigger,
+		Status:       j.Status,
+		ScheduledFor: j.ScheduledFor,
+		StartedAt:    j.StartedAt,
+		FinishedAt:   j.FinishedAt,
+		DurationMs:   duration,
+		RowsAffected: j.RowsAffected,
+		Error:        j.Error,
+		Host:         j.Host,
+		RequestedBy:  j.RequestedBy,
+		CreatedAt:    j.CreatedAt,
+	}
+}
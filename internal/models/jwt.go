@@ -4,15 +4,29 @@ import "github.com/google/uuid"
 
 // JWT token type constants
 const (
-	JWTAccess  int8 = 0
-	JWTRefresh int8 = 1
-	JWTForgot  int8 = 2
+	JWTAccess      int8 = 0
+	JWTRefresh     int8 = 1
+	JWTForgot      int8 = 2
+	JWTChallenge   int8 = 3 // Issued after the password step when 2FA is enabled
+	JWTStepUp      int8 = 4 // Authorizes sensitive actions shortly after re-verifying 2FA
+	JWTVerifyEmail int8 = 5 // Confirms ownership of the account's email address
+	JWTUnlock      int8 = 6 // Lifts a brute-force lockout from the emailed unlock link
 )
 
 // DecodedToken represents the claims in a JWT token
 type DecodedToken struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Expiries int       `json:"exp"`
-	Type     int8      `json:"token_type"`
-	Role     string    `json:"role,omitempty"` // user, merchant, admin
+	UserID         uuid.UUID `json:"user_id"`
+	Expiries       int       `json:"exp"`
+	IssuedAt       int       `json:"iat,omitempty"`
+	Type           int8      `json:"token_type"`
+	Role           string    `json:"role,omitempty"` // user, merchant, admin
+	SessionID      uuid.UUID `json:"sid,omitempty"`  // Session family for tokens bound to a login; the challenge on challenge tokens
+	ImpersonatorID uuid.UUID `json:"imp,omitempty"`  // Staff viewing the account as the user; sid is their session
+	LockedUntil    int64     `json:"lck,omitempty"`  // End of the lockout an unlock token lifts, in Unix microseconds
+}
+
+// IsImpersonation reports whether the token was issued for staff viewing the
+// account as the user
+func (d *DecodedToken) IsImpersonation() bool {
+	return d.ImpersonatorID != uuid.Nil
 }
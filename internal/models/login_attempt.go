@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Login Attempt Reason Constants
+const (
+	LoginFailureBadCredentials = "bad_credentials"
+	LoginFailureLocked         = "locked"
+	LoginFailureAccountStatus  = "account_status"
+	LoginFailureTwoFactor      = "two_factor" // Wrong code for the second factor after the password checked out
+	LoginFailureLockout        = "lockout"    // The failure that locked the email out; starts the lockout
+)
+
+// Login throttling policy
+const (
+	LoginAttemptWindow    = 15 * time.Minute // Failures older than this are ignored
+	LoginCaptchaThreshold = 3                // Failures per email or IP before a CAPTCHA is required
+	LoginDelayThreshold   = 3                // Failures per email before attempts are spaced out
+	LoginMaxDelay         = time.Minute
+	LoginLockoutThreshold = 5 // Consecutive failures before the account is locked
+	LoginLockoutDuration  = 15 * time.Minute
+	LoginIPFailureLimit   = 20 // Failures per IP before the IP is blocked for the window
+)
+
+// LoginAttempt records a failed login used to throttle guessing by email and IP
+type LoginAttempt struct {
+	gorm.Model
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Email     string     `gorm:"not null;index"` // Normalized, recorded even for unknown accounts
+	IPAddress string     `gorm:"not null;index"`
+	UserID    *uuid.UUID `gorm:"type:uuid"`
+	Reason    string     `gorm:"not null"`
+}
+
+// LoginDelay returns how long an email must wait between attempts after the
+// given number of recent failures. The delay doubles with each failure.
+func LoginDelay(failures int64) time.Duration {
+	if failures < LoginDelayThreshold {
+		return 0
+	}
+	delay := time.Second << uint(failures-LoginDelayThreshold)
+	if delay <= 0 || delay > LoginMaxDelay {
+		return LoginMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Merchant Profile Status Constants
+const (
+	MerchantStatusDraft    = "draft"
+	MerchantStatusPending  = "pending"
+	MerchantStatusApproved = "approved"
+	MerchantStatusRejected = "rejected"
+)
+
+// KYB Document Type Constants
+const (
+	KYBDocTypeBusinessLicense  = "business_license"
+	KYBDocTypeRegistrationCert = "registration_certificate"
+	KYBDocTypeTINCertificate   = "tin_certificate"
+	KYBDocTypeMemorandum       = "memorandum_of_association"
+	KYBDocTypeBankConfirmation = "bank_confirmation"
+)
+
+// KYBDocTypes lists the document types accepted for merchant onboarding
+var KYBDocTypes = []string{
+	KYBDocTypeBusinessLicense,
+	KYBDocTypeRegistrationCert,
+	KYBDocTypeTINCertificate,
+	KYBDocTypeMemorandum,
+	KYBDocTypeBankConfirmation,
+}
+
+// RequiredKYBDocTypes must be uploaded before an application can be submitted
+var RequiredKYBDocTypes = []string{
+	KYBDocTypeBusinessLicense,
+	KYBDocTypeTINCertificate,
+}
+
+// IsKYBDocType reports whether a document type belongs to merchant onboarding
+func IsKYBDocType(docType string) bool {
+	for _, t := range KYBDocTypes {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+// MerchantProfile holds a merchant's business details and onboarding state
+type MerchantProfile struct {
+	gorm.Model
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	LegalName    string    `gorm:"not null"`
+	TradingName  *string   // Shown to customers when set
+	TIN          string    `gorm:"not null;index"` // Taxpayer identification number
+	CategoryCode string    `gorm:"not null"`       // ISO 18245 merchant category code
+	Description  *string   `gorm:"type:text"`
+	Website      *string
+	Email        *string
+	Phone        *string
+	AddressLine  string `gorm:"not null"`
+	City         string `gorm:"not null"`
+	Region       *string
+	Country      string `gorm:"not null;default:'ET'"`
+	LogoURL      *string
+	Status       string  `gorm:"default:'draft';index"` // Enum: draft, pending, approved, rejected
+	ReviewNotes  *string `gorm:"type:text"`
+	SubmittedAt  *time.Time
+	ReviewedAt   *time.Time
+	ReviewedBy   *uuid.UUID `gorm:"type:uuid"`
+	User         User       `gorm:"foreignKey:UserID"`
+}
+
+// DisplayName returns the name customers should see
+func (m *MerchantProfile) DisplayName() string {
+	if m.TradingName != nil && *m.TradingName != "" {
+		return *m.TradingName
+	}
+	return m.LegalName
+}
+
+// MerchantProfileResponse for the merchant's own and admin views
+type MerchantProfileResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	LegalName    string     `json:"legal_name"`
+	TradingName  *string    `json:"trading_name,omitempty"`
+	TIN          string     `json:"tin"`
+	CategoryCode string     `json:"category_code"`
+	Description  *string    `json:"description,omitempty"`
+	Website      *string    `json:"website,omitempty"`
+	Email        *string    `json:"email,omitempty"`
+	Phone        *string    `json:"phone,omitempty"`
+	AddressLine  string     `json:"address_line"`
+	City         string     `json:"city"`
+	Region       *string    `json:"region,omitempty"`
+	Country      string     `json:"country"`
+	LogoURL      *string    `json:"logo_url,omitempty"`
+	Status       string     `json:"status"`
+	ReviewNotes  *string    `json:"review_notes,omitempty"`
+	SubmittedAt  *time.Time `json:"submitted_at,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// PublicMerchantResponse exposes only what payment pages need
+type PublicMerchantResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	CategoryCode string    `json:"category_code"`
+	Description  *string   `json:"description,omitempty"`
+	Website      *string   `json:"website,omitempty"`
+	City         string    `json:"city"`
+	Country      string    `json:"country"`
+	LogoURL      *string   `json:"logo_url,omitempty"`
+	Verified     bool      `json:"verified"`
+}
+
+// ToResponse converts MerchantProfile to MerchantProfileResponse
+func (m *MerchantProfile) ToResponse() MerchantProfileResponse {
+	return MerchantProfileResponse{
+		ID:           m.ID,
+		UserID:       m.UserID,
+		LegalName:    m.LegalName,
+		TradingName:  m.TradingName,
+		TIN:          m.TIN,
+		CategoryCode: m.CategoryCode,
+		Description:  m.Description,
+		Website:      m.Website,
+		Email:        m.Email,
+		Phone:        m.Phone,
+		AddressLine:  m.AddressLine,
+		City:         m.City,
+		Region:       m.Region,
+		Country:      m.Country,
+		LogoURL:      m.LogoURL,
+		Status:       m.Status,
+		ReviewNotes:  m.ReviewNotes,
+		SubmittedAt:  m.SubmittedAt,
+		ReviewedAt:   m.ReviewedAt,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
+
+// ToPublicResponse converts MerchantProfile to PublicMerchantResponse. It is
+// keyed by the merchant's user ID, which is what invoices and payments carry.
+func (m *MerchantProfile) ToPublicResponse() PublicMerchantResponse {
+	return PublicMerchantResponse{
+		ID:           m.UserID,
+		Name:         m.DisplayName(),
+		CategoryCode: m.CategoryCode,
+		Description:  m.Description,
+		Website:      m.Website,
+		City:         m.City,
+		Country:      m.Country,
+		LogoURL:      m.LogoURL,
+		Verified:     m.Status == MerchantStatusApproved,
+	}
+}
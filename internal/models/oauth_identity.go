@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthIdentity links a user to an account at an OpenID Connect provider.
+// A user can link one account per provider.
+type OAuthIdentity struct {
+	gorm.Model
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_oauth_user_provider"`
+	Provider string    `gorm:"not null;uniqueIndex:idx_oauth_subject;uniqueIndex:idx_oauth_user_provider"` // google, apple, microsoft
+	Subject  string    `gorm:"not null;uniqueIndex:idx_oauth_subject"`                                     // Stable account ID at the provider
+	Email    string    // Email the provider reported when the account was linked
+}
+
+// OAuthIdentityResponse describes a linked sign-in provider
+type OAuthIdentityResponse struct {
+	Provider string    `json:"provider"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+// ToResponse converts a linked identity to its API response
+func (i *OAuthIdentity) ToResponse() OAuthIdentityResponse {
+	return OAuthIdentityResponse{
+		Provider: i.Provider,
+		Email:    i.Email,
+		LinkedAt: i.CreatedAt,
+	}
+}
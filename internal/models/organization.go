@@ -0,0 +1,165 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Organization Role Constants
+const (
+	OrgRoleOwner      = "owner"
+	OrgRoleAdmin      = "admin"
+	OrgRoleAccountant = "accountant"
+	OrgRoleCashier    = "cashier"
+)
+
+// Member Status Constants
+const (
+	MemberStatusInvited = "invited"
+	MemberStatusActive  = "active"
+	MemberStatusRevoked = "revoked"
+)
+
+// Merchant Permission Constants
+const (
+	PermInvoicesRead   = "invoices:read"
+	PermInvoicesWrite  = "invoices:write"
+	PermRefundsIssue   = "refunds:issue"
+	PermReportsRead    = "reports:read"
+	PermSettingsManage = "settings:manage"
+	PermTeamManage     = "team:manage"
+)
+
+// OrgRolePermissions maps each organization role to the permissions it grants
+var OrgRolePermissions = map[string][]string{
+	OrgRoleOwner: {
+		PermInvoicesRead, PermInvoicesWrite, PermRefundsIssue,
+		PermReportsRead, PermSettingsManage, PermTeamManage,
+	},
+	OrgRoleAdmin: {
+		PermInvoicesRead, PermInvoicesWrite, PermRefundsIssue,
+		PermReportsRead, PermSettingsManage, PermTeamManage,
+	},
+	OrgRoleAccountant: {
+		PermInvoicesRead, PermReportsRead,
+	},
+	OrgRoleCashier: {
+		PermInvoicesRead, PermInvoicesWrite,
+	},
+}
+
+// OrgRoleHasPermission reports whether an organization role grants a permission
+func OrgRoleHasPermission(role, permission string) bool {
+	for _, p := range OrgRolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAssignableOrgRole reports whether a role can be given to an invited member
+func IsAssignableOrgRole(role string) bool {
+	return role == OrgRoleAdmin || role == OrgRoleAccountant || role == OrgRoleCashier
+}
+
+// MerchantOrganization groups the staff working on a merchant account
+type MerchantOrganization struct {
+	gorm.Model
+	ID         uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	MerchantID uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex"` // The merchant account (User) that owns the funds
+	Name       string           `gorm:"not null"`
+	Members    []MerchantMember `gorm:"foreignKey:OrganizationID"`
+}
+
+// MerchantMember is a user's membership in a merchant organization
+type MerchantMember struct {
+	gorm.Model
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID  uuid.UUID  `gorm:"type:uuid;not null;index"`
+	MerchantID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_merchant_member_email"`
+	UserID          *uuid.UUID `gorm:"type:uuid;index"` // Set once the invitation is accepted
+	Email           string     `gorm:"not null;uniqueIndex:idx_merchant_member_email"`
+	Role            string     `gorm:"not null"`                // Enum: owner, admin, accountant, cashier
+	Status          string     `gorm:"default:'invited';index"` // Enum: invited, active, revoked
+	InvitedBy       *uuid.UUID `gorm:"type:uuid"`
+	InviteTokenHash *string    `gorm:"uniqueIndex"`
+	InviteExpiresAt *time.Time
+	JoinedAt        *time.Time
+	User            *User                 `gorm:"foreignKey:UserID"`
+	Organization    *MerchantOrganization `gorm:"foreignKey:OrganizationID"`
+}
+
+// MerchantAuditLog records an action taken on behalf of a merchant
+type MerchantAuditLog struct {
+	gorm.Model
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	MerchantID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	ActorID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	ActorRole    string    `gorm:"not null"`
+	Action       string    `gorm:"not null;index"` // e.g., invoice.created, member.invited
+	ResourceType string    `gorm:"not null"`
+	ResourceID   *string
+	Details      datatypes.JSON `gorm:"type:jsonb"`
+	IPAddress    string
+}
+
+// MerchantMemberResponse for team member data
+type MerchantMemberResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Email     string     `json:"email"`
+	Name      string     `json:"name,omitempty"`
+	Role      string     `json:"role"`
+	Status    string     `json:"status"`
+	InvitedAt time.Time  `json:"invited_at"`
+	JoinedAt  *time.Time `json:"joined_at,omitempty"`
+}
+
+// MerchantAuditLogResponse for merchant audit entries
+type MerchantAuditLogResponse struct {
+	ID           uuid.UUID      `json:"id"`
+	ActorID      uuid.UUID      `json:"actor_id"`
+	ActorRole    string         `json:"actor_role"`
+	Action       string         `json:"action"`
+	ResourceType string         `json:"resource_type"`
+	ResourceID   *string        `json:"resource_id,omitempty"`
+	Details      datatypes.JSON `json:"details,omitempty"`
+	IPAddress    string         `json:"ip_address"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// ToResponse converts MerchantMember to MerchantMemberResponse
+func (m *MerchantMember) ToResponse() MerchantMemberResponse {
+	resp := MerchantMemberResponse{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Email:     m.Email,
+		Role:      m.Role,
+		Status:    m.Status,
+		InvitedAt: m.CreatedAt,
+		JoinedAt:  m.JoinedAt,
+	}
+	if m.User != nil {
+		resp.Name = m.User.FirstName + " " + m.User.LastName
+	}
+	return resp
+}
+
+// ToResponse converts MerchantAuditLog to MerchantAuditLogResponse
+func (l *MerchantAuditLog) ToResponse() MerchantAuditLogResponse {
+	return MerchantAuditLogResponse{
+		ID:           l.ID,
+		ActorID:      l.ActorID,
+		ActorRole:    l.ActorRole,
+		Action:       l.Action,
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		Details:      l.Details,
+		IPAddress:    l.IPAddress,
+		CreatedAt:    l.CreatedAt,
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken tracks an emailed reset link so it can be used once. It
+// is bound to the password version it was issued for and dies as soon as the
+// password changes by any means.
+type PasswordResetToken struct {
+	gorm.Model
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index"`
+	TokenHash       string     `gorm:"not null;uniqueIndex"` // SHA-256 hash of the token, never the raw value
+	PasswordVersion int        `gorm:"not null"`             // User.PasswordVersion when the link was issued
+	ExpiresAt       time.Time  `gorm:"not null"`
+	UsedAt          *time.Time // Set when the token resets the password
+	RevokedAt       *time.Time // Set when superseded by a newer request or a completed reset
+}
@@ -6,13 +6,35 @@ import (
 	"gorm.io/gorm"
 )
 
-// PaymentMethod represents a user's linked payment method
+// Payment Method Type Constants
+const (
+	PaymentMethodBank         = "bank"
+	PaymentMethodCard         = "card"
+	PaymentMethodMobileWallet = "mobile_wallet"
+)
+
+// PaymentMethodNumberFields lists, per type, the detail fields that may hold
+// the number identifying the payment method, in order of preference
+var PaymentMethodNumberFields = map[string][]string{
+	PaymentMethodBank:         {"account_number", "iban"},
+	PaymentMethodCard:         {"card_number", "number"},
+	PaymentMethodMobileWallet: {"phone_number", "phone"},
+}
+
+// PaymentMethod represents a user's linked payment method. Its details are
+// sealed with a data key of their own, stored wrapped by the KeyID key, so
+// only the fingerprint and last four digits can be queried.
 type PaymentMethod struct {
 	gorm.Model
-	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID    uuid.UUID      `gorm:"not null;type:uuid"`
-	Type      string         `gorm:"not null"` // Enum: bank, card, mobile_wallet
-	Details   datatypes.JSON `gorm:"not null"` // Encrypted JSON
-	IsDefault bool           `gorm:"default:false"`
-	Verified  bool           `gorm:"default:false"`
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID           uuid.UUID `gorm:"not null;type:uuid;uniqueIndex:idx_payment_method_user_fingerprint,where:deleted_at IS NULL AND fingerprint <> ''"`
+	Type             string    `gorm:"not null"` // Enum: bank, card, mobile_wallet
+	EncryptedDetails []byte    `gorm:"type:bytea"`
+	EncryptedKey     []byte    `gorm:"type:bytea"` // Data key wrapped by the KeyID key
+	KeyID            string    `gorm:"index"`
+	Fingerprint      string    `gorm:"uniqueIndex:idx_payment_method_user_fingerprint"` // Keyed hash of the account, card or phone number; linked once per user
+	LastFour         string
+	Details          datatypes.JSON `json:"-"` // Legacy plaintext details, encrypted and cleared by the payment_keys tool
+	IsDefault        bool           `gorm:"default:false"`
+	Verified         bool           `gorm:"default:false"`
 }
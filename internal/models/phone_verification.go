@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Phone Verification Purpose Constants
+const (
+	PhoneVerificationPurposeVerify = "verify" // Confirm the number already on the account
+	PhoneVerificationPurposeChange = "change" // Confirm a new number before it replaces the old one
+)
+
+// Phone OTP policy
+const (
+	PhoneOTPLength         = 6
+	PhoneOTPExpiry         = 10 * time.Minute
+	PhoneOTPMaxAttempts    = 5
+	PhoneOTPResendCooldown = time.Minute
+)
+
+// PhoneVerification is a one-time code sent by SMS to confirm a phone number
+type PhoneVerification struct {
+	gorm.Model
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Phone      string    `gorm:"not null"`
+	Purpose    string    `gorm:"not null"`
+	CodeHash   string    `gorm:"not null"` // HMAC of the code, never the code itself
+	Attempts   int       `gorm:"default:0"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	VerifiedAt *time.Time
+	User       User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// PhoneVerificationResponse describes a code that was just sent
+type PhoneVerificationResponse struct {
+	Phone             string    `json:"phone"` // Masked
+	Purpose           string    `json:"purpose"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	ResendAvailableAt time.Time `json:"resend_available_at"`
+}
+
+// ToResponse converts a PhoneVerification to its response, masking the number
+func (v *PhoneVerification) ToResponse() PhoneVerificationResponse {
+	return PhoneVerificationResponse{
+		Phone:             maskPhone(v.Phone),
+		Purpose:           v.Purpose,
+		ExpiresAt:         v.ExpiresAt,
+		ResendAvailableAt: v.CreatedAt.Add(PhoneOTPResendCooldown),
+	}
+}
+
+// maskPhone hides all but the country prefix and last four digits
+func maskPhone(phone string) string {
+	switch {
+	case len(phone) > 8:
+		return phone[:4] + strings.Repeat("*", len(phone)-8) + phone[len(phone)-4:]
+	case len(phone) > 4:
+		return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+	default:
+		return phone
+	}
+}
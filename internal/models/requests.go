@@ -60,6 +60,61 @@ type ChangePasswordRequest struct {
 	RefreshToken    string `json:"refresh_token" binding:"required"`
 }
 
+// VerifyEmailRequest for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// UnlockAccountRequest for lifting a login lockout
+type UnlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ChangePhoneRequest for starting a phone number change
+type ChangePhoneRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// VerifyPhoneRequest for confirming a phone number with an SMS code
+type VerifyPhoneRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ==================== Two-Factor Requests ====================
+
+// TwoFactorCodeRequest confirms an action with a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TwoFactorVerifyRequest completes a login that requires a second factor
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	Fingerprint    string `json:"fingerprint" binding:"required"`
+}
+
+// ==================== OAuth Requests ====================
+
+// OAuthSignInRequest signs in with an OpenID Connect provider's ID token
+type OAuthSignInRequest struct {
+	Token       string `json:"token" binding:"required"`
+	Fingerprint string `json:"fingerprint"` // Falls back to the X-Fingerprint header
+}
+
+// OAuthLinkRequest links a provider account to the signed in user
+type OAuthLinkRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// OAuthUnlinkRequest removes a linked provider account
+type OAuthUnlinkRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
 // ==================== User Requests ====================
 
 // UpdateUserRequest for updating user profile
@@ -94,6 +149,34 @@ type WithdrawRequest struct {
 	Amount        float64 `json:"amount" binding:"required"`
 	Currency      string  `json:"currency"`
 	PaymentMethod string  `json:"payment_method" binding:"required"`
+	PaymentAuthorization
+}
+
+// ==================== Transaction PIN Requests ====================
+
+// PaymentAuthorization authorizes a payment above the PIN threshold with the
+// transaction PIN or, for users with 2FA, a TOTP code
+type PaymentAuthorization struct {
+	PIN           string `json:"pin,omitempty"`
+	TwoFactorCode string `json:"two_factor_code,omitempty"`
+}
+
+// SetPINRequest sets the first transaction PIN
+type SetPINRequest struct {
+	PIN      string `json:"pin" binding:"required"`
+	Password string `json:"password"`
+}
+
+// ChangePINRequest replaces the PIN using the current one
+type ChangePINRequest struct {
+	CurrentPIN string `json:"current_pin" binding:"required"`
+	NewPIN     string `json:"new_pin" binding:"required"`
+}
+
+// ResetPINRequest replaces a forgotten or locked PIN using the account password
+type ResetPINRequest struct {
+	Password string `json:"password"`
+	NewPIN   string `json:"new_pin" binding:"required"`
 }
 
 // ==================== Payment Method Requests ====================
@@ -118,6 +201,7 @@ type TransferRequest struct {
 	Amount      float64   `json:"amount" binding:"required"`
 	Currency    string    `json:"currency"`
 	Description *string   `json:"description,omitempty"`
+	PaymentAuthorization
 }
 
 // PaymentRequest for merchant payments
@@ -127,6 +211,7 @@ type PaymentRequest struct {
 	Amount      float64    `json:"amount" binding:"required"`
 	Currency    string     `json:"currency"`
 	Description *string    `json:"description,omitempty"`
+	PaymentAuthorization
 }
 
 // ==================== Payment Method Requests ====================
@@ -147,6 +232,113 @@ type CreateInvoiceRequest struct {
 	DueDate  *string `json:"due_date,omitempty"` // ISO 8601 format
 }
 
+// PayInvoiceRequest authorizes paying an invoice above the PIN threshold
+type PayInvoiceRequest struct {
+	PaymentAuthorization
+}
+
+// UpdateReminderSettingsRequest for merchants to configure invoice reminders
+type UpdateReminderSettingsRequest struct {
+	BeforeDueDays []int `json:"before_due_days"`
+	AfterDueDays  []int `json:"after_due_days"`
+	Enabled       *bool `json:"enabled,omitempty"`
+}
+
+// ==================== Settlement Requests ====================
+
+// UpdateSettlementSettingsRequest for merchants to choose a payout schedule
+type UpdateSettlementSettingsRequest struct {
+	Schedule  string `json:"schedule" binding:"required"` // daily, weekly
+	WeeklyDay *int   `json:"weekly_day,omitempty"`        // 0 = Sunday
+}
+
+// UpdateReserveRequest for admins to set a merchant's rolling reserve
+type UpdateReserveRequest struct {
+	ReservePercent  *float64 `json:"reserve_percent,omitempty"`
+	ReserveHoldDays *int     `json:"reserve_hold_days,omitempty"`
+}
+
+// ==================== Merchant Onboarding Requests ====================
+
+// MerchantProfileRequest for creating or updating a business profile
+type MerchantProfileRequest struct {
+	LegalName    string  `json:"legal_name" binding:"required"`
+	TradingName  *string `json:"trading_name,omitempty"`
+	TIN          string  `json:"tin" binding:"required"`
+	CategoryCode string  `json:"category_code" binding:"required"`
+	Description  *string `json:"description,omitempty"`
+	Website      *string `json:"website,omitempty"`
+	Email        *string `json:"email,omitempty"`
+	Phone        *string `json:"phone,omitempty"`
+	AddressLine  string  `json:"address_line" binding:"required"`
+	City         string  `json:"city" binding:"required"`
+	Region       *string `json:"region,omitempty"`
+	Country      string  `json:"country,omitempty"`
+}
+
+// ReviewMerchantRequest for admin approval of a merchant application
+type ReviewMerchantRequest struct {
+	Status string `json:"status" binding:"required"` // approved, rejected
+	Notes  string `json:"notes"`
+}
+
+// ==================== Merchant Team Requests ====================
+
+// InviteMemberRequest for inviting staff to a merchant organization
+type InviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"` // admin, accountant, cashier
+}
+
+// UpdateMemberRoleRequest for changing a team member's role
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AcceptInvitationRequest for joining a merchant organization
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ==================== Dispute Requests ====================
+
+// OpenDisputeRequest for payers challenging a merchant payment
+type OpenDisputeRequest struct {
+	TransactionID uuid.UUID `json:"transaction_id" binding:"required"`
+	Reason        string    `json:"reason" binding:"required"` // not_received, not_as_described, unauthorized, duplicate, other
+	Description   string    `json:"description" binding:"required"`
+}
+
+// RespondDisputeRequest for merchants accepting or contesting a dispute
+type RespondDisputeRequest struct {
+	Accept   bool   `json:"accept"` // Refund the payer without contesting
+	Response string `json:"response"`
+}
+
+// DecideDisputeRequest for admin decisions on contested disputes
+type DecideDisputeRequest struct {
+	Outcome string `json:"outcome" binding:"required"` // payer, merchant
+	Notes   string `json:"notes"`
+}
+
+// ==================== Admin Requests ====================
+
+// UpdateUserStatusRequest moves an account to a new status
+type UpdateUserStatusRequest struct {
+	Status string `json:"status" binding:"required"` // active, frozen, suspended, closed
+	Reason string `json:"reason"`                    // Required unless reinstating
+}
+
+// ImpersonateRequest starts a read-only view of a user's account
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required"` // Support ticket or other justification
+}
+
+// GrantRoleRequest grants a staff role to a user
+type GrantRoleRequest struct {
+	Role string `json:"role" binding:"required"` // admin, support_agent, compliance_officer
+}
+
 // ==================== Pagination and Listing ====================
 
 // ListedRequest is a helper for paginated list requests
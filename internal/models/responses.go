@@ -30,20 +30,40 @@ type LoggedInUserResponse struct {
 	User         UserResponse `json:"user"`
 }
 
+// LoginFailedResponse returned when a login attempt is rejected
+type LoginFailedResponse struct {
+	Message         string `json:"message"`
+	CaptchaRequired bool   `json:"captcha_required"`
+	RetryAfter      int    `json:"retry_after,omitempty"` // Seconds until another attempt is allowed
+}
+
+// ImpersonationResponse returns a read-only token for viewing as a user
+type ImpersonationResponse struct {
+	AccessToken string       `json:"access_token"`
+	ExpiresIn   int          `json:"expires_in"`
+	User        UserResponse `json:"user"`
+}
+
 // UserResponse sanitized user data for API responses
 type UserResponse struct {
-	ID           uuid.UUID `json:"id"`
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	Username     string    `json:"username"`
-	AvatarURL    string    `json:"avatar_url"`
-	Email        string    `json:"email"`
-	Phone        string    `json:"phone"`
-	Preferences  string    `json:"preferences"` // JSON string
-	KYCStatus    string    `json:"kyc_status"`
-	Role         string    `json:"role"`
-	Is2FAEnabled bool      `json:"is_2fa_enabled"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID             uuid.UUID  `json:"id"`
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	Username       string     `json:"username"`
+	AvatarURL      string     `json:"avatar_url"`
+	Email          string     `json:"email"`
+	EmailVerified  bool       `json:"email_verified"`
+	Phone          string     `json:"phone"`
+	PhoneVerified  bool       `json:"phone_verified"`
+	Preferences    string     `json:"preferences"` // JSON string
+	KYCStatus      string     `json:"kyc_status"`
+	Role           string     `json:"role"`
+	Status         string     `json:"status"`
+	Is2FAEnabled   bool       `json:"is_2fa_enabled"`
+	HasPIN         bool       `json:"has_pin"`
+	Impersonated   bool       `json:"impersonated"`              // Staff are viewing the account as this user
+	ImpersonatedBy *uuid.UUID `json:"impersonated_by,omitempty"` // The staff member viewing it
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // ==================== Wallet Responses ====================
@@ -53,8 +73,11 @@ type WalletResponse struct {
 	ID          uuid.UUID `json:"id"`
 	UserID      uuid.UUID `json:"user_id"`
 	Balance     float64   `json:"balance"`
+	HeldBalance float64   `json:"held_balance"`
+	Available   float64   `json:"available_balance"`
 	Currency    string    `json:"currency"`
 	Locked      bool      `json:"locked"`
+	Frozen      bool      `json:"frozen"`
 	LastUpdated time.Time `json:"last_updated"`
 }
 
@@ -64,6 +87,14 @@ type BalanceResponse struct {
 	Currency string  `json:"currency"`
 }
 
+// PINStatusResponse reports the state of the user's transaction PIN
+type PINStatusResponse struct {
+	HasPIN      bool       `json:"has_pin"`
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	Threshold   float64    `json:"threshold"` // Payments above this amount need the PIN
+}
+
 // ==================== Transaction Responses ====================
 
 // TransactionResponse for transaction data
@@ -99,5 +130,5 @@ type PaymentMethodResponse struct {
 	IsDefault bool      `json:"is_default"`
 	Verified  bool      `json:"verified"`
 	// Details are intentionally omitted for security
-	LastFourDigits string `json:"last_four_digits,omitempty"` // For cards
+	LastFourDigits string `json:"last_four_digits,omitempty"` // Of the card, account or phone number
 }
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Platform Role Constants
+const (
+	RoleUser              = "user"
+	RoleMerchant          = "merchant"
+	RoleAdmin             = "admin"
+	RoleSupportAgent      = "support_agent"
+	RoleComplianceOfficer = "compliance_officer"
+)
+
+// Platform Permission Constants. These govern staff access to the platform;
+// merchant team access uses the Perm*:* organization permissions.
+const (
+	PermUsersView           = "users.view"
+	PermUsersSuspend        = "users.suspend"
+	PermUsersImpersonate    = "users.impersonate"
+	PermKYCReview           = "kyc.review"
+	PermTransactionsReverse = "transactions.reverse"
+	PermReportsView         = "reports.view"
+	PermAuditView           = "audit.view"
+	PermSettlementsManage   = "settlements.manage"
+	PermJobsManage          = "jobs.manage"
+	PermRolesManage         = "roles.manage"
+)
+
+// RolePermissions maps each platform role to the permissions it grants.
+// Customer and merchant roles grant no platform permissions.
+var RolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermUsersView, PermUsersSuspend, PermUsersImpersonate, PermKYCReview,
+		PermTransactionsReverse, PermReportsView, PermAuditView,
+		PermSettlementsManage, PermJobsManage, PermRolesManage,
+	},
+	RoleComplianceOfficer: {
+		PermUsersView, PermUsersSuspend, PermKYCReview, PermTransactionsReverse,
+		PermReportsView, PermAuditView,
+	},
+	RoleSupportAgent: {
+		PermUsersView, PermUsersImpersonate, PermReportsView,
+	},
+}
+
+// IsStaffRole reports whether a role can be granted through a role assignment
+func IsStaffRole(role string) bool {
+	return role == RoleAdmin || role == RoleSupportAgent || role == RoleComplianceOfficer
+}
+
+// RoleHasPermission reports whether a platform role grants a permission
+func RoleHasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesWithPermission lists the platform roles that grant a permission
+func RolesWithPermission(permission string) []string {
+	var roles []string
+	for role := range RolePermissions {
+		if RoleHasPermission(role, permission) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// RoleAssignment grants a staff role to a user on top of their account role
+type RoleAssignment struct {
+	gorm.Model
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_role_assignment_user_role"`
+	Role      string     `gorm:"not null;uniqueIndex:idx_role_assignment_user_role"`
+	GrantedBy *uuid.UUID `gorm:"type:uuid"` // Admin who granted the role
+}
+
+// RoleAssignmentResponse describes a granted staff role
+type RoleAssignmentResponse struct {
+	Role      string     `json:"role"`
+	GrantedBy *uuid.UUID `json:"granted_by,omitempty"`
+	GrantedAt time.Time  `json:"granted_at"`
+}
+
+// ToResponse converts a RoleAssignment to RoleAssignmentResponse
+func (a *RoleAssignment) ToResponse() RoleAssignmentResponse {
+	return RoleAssignmentResponse{
+		Role:      a.Role,
+		GrantedBy: a.GrantedBy,
+		GrantedAt: a.CreatedAt,
+	}
+}
+
+// UserRolesResponse describes a user's account role, granted roles and the
+// permissions they add up to
+type UserRolesResponse struct {
+	UserID      uuid.UUID                `json:"user_id"`
+	Role        string                   `json:"role"`
+	Assignments []RoleAssignmentResponse `json:"assignments"`
+	Permissions []string                 `json:"permissions"`
+}
+
+// RoleResponse describes a platform role and its permissions
+type RoleResponse struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// HasPermission reports whether the user's account role or any granted role
+// grants a platform permission. RoleAssignments must be loaded.
+func (u *User) HasPermission(permission string) bool {
+	if RoleHasPermission(u.Role, permission) {
+		return true
+	}
+	for _, a := range u.RoleAssignments {
+		if RoleHasPermission(a.Role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions lists every platform permission the user holds, sorted
+func (u *User) Permissions() []string {
+	seen := map[string]bool{}
+	roles := []string{u.Role}
+	for _, a := range u.RoleAssignments {
+		roles = append(roles, a.Role)
+	}
+	permissions := []string{}
+	for _, role := range roles {
+		for _, p := range RolePermissions[role] {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// CanManage reports whether staff may change another user's status or roles.
+// Users holding any permission the staff member lacks are out of reach, so
+// staff cannot act against those above them, while an admin can still contain
+// another admin. RoleAssignments must be loaded on both users.
+func (u *User) CanManage(target *User) bool {
+	for _, p := range target.Permissions() {
+		if !u.HasPermission(p) {
+			return false
+		}
+	}
+	return true
+}
@@ -7,14 +7,59 @@ import (
 	"gorm.io/gorm"
 )
 
-// Session represents a user session with refresh token
+// Session Revocation Reason Constants
+const (
+	SessionRevokedTokenReuse = "refresh_token_reuse"
+	SessionRevokedByUser     = "revoked_by_user"
+	SessionRevokedByAdmin    = "account_status_changed"
+)
+
+// Session represents a user session with refresh token. Each refresh rotates
+// the session into a new one in the same family, so a rotated token that is
+// presented again reveals theft and revokes the whole family.
 type Session struct {
 	gorm.Model
-	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID       uuid.UUID `gorm:"type:uuid;not null"`
-	RefreshToken string    `gorm:"not null"`
-	Fingerprint  string    `gorm:"not null"` // Device fingerprint for security
-	Active       bool      `gorm:"type:bool;default:true"`
-	Terminated   *time.Time
-	User         User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null"`
+	FamilyID      uuid.UUID `gorm:"type:uuid;index"` // Shared by all sessions rotated from one login
+	RefreshToken  string    `gorm:"not null;index"`  // SHA-256 hash of the refresh token, never the raw value
+	Fingerprint   string    `gorm:"not null"`        // Device fingerprint for security
+	DeviceName    string    // Client supplied or derived from the user agent
+	UserAgent     string
+	IPAddress     string
+	Location      *string    // Geolocation label from the edge proxy, e.g. "Addis Ababa, ET"
+	SignedInAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP"` // When the family's login happened; carried across rotations
+	LastSeenAt    *time.Time // Last authenticated request, updated at most once a minute
+	Active        bool       `gorm:"type:bool;default:true"`
+	Terminated    *time.Time
+	RotatedAt     *time.Time // Set when the session was replaced by a refresh
+	ReplacedByID  *uuid.UUID `gorm:"type:uuid"`
+	RevokedReason *string
+	User          User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// SessionResponse describes a signed-in device
+type SessionResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	Location   *string    `json:"location,omitempty"`
+	SignedInAt time.Time  `json:"signed_in_at"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+// ToResponse converts a Session to SessionResponse, flagging the caller's own session
+func (s *Session) ToResponse(currentFamilyID uuid.UUID) SessionResponse {
+	return SessionResponse{
+		ID:         s.ID,
+		DeviceName: s.DeviceName,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		Location:   s.Location,
+		SignedInAt: s.SignedInAt,
+		LastSeenAt: s.LastSeenAt,
+		Current:    s.FamilyID == currentFamilyID,
+	}
 }
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Settlement Schedule Constants
+const (
+	SettlementScheduleDaily  = "daily"
+	SettlementScheduleWeekly = "weekly"
+)
+
+// Settlement Batch Status Constants
+const (
+	SettlementStatusPaid   = "paid"
+	SettlementStatusFailed = "failed"
+)
+
+// Default settlement policy for merchants without their own settings
+const (
+	DefaultReservePercent  = 0.0
+	DefaultReserveHoldDays = 7
+)
+
+// SettlementSetting holds a merchant's payout schedule and risk reserve policy
+type SettlementSetting struct {
+	gorm.Model
+	ID              uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	MerchantID      uuid.UUID `gorm:"unique;not null;type:uuid"`
+	Schedule        string    `gorm:"not null;default:'daily'"` // daily, weekly
+	WeeklyDay       int       `gorm:"default:1"`                // 0 = Sunday, used by weekly schedules
+	ReservePercent  float64   `gorm:"default:0"`                // Share of each batch held back for risk, set by admins
+	ReserveHoldDays int       `gorm:"default:7"`                // Days before a reserve is released
+}
+
+// SettlementBatch groups a merchant's settled payments into a single payout
+type SettlementBatch struct {
+	gorm.Model
+	ID                  uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	MerchantID          uuid.UUID  `gorm:"not null;type:uuid;index"`
+	PeriodStart         time.Time  `gorm:"not null"`
+	PeriodEnd           time.Time  `gorm:"not null"`
+	Currency            string     `gorm:"not null;default:'ETB'"`
+	TransactionCount    int        `gorm:"default:0"`
+	GrossAmount         float64    `gorm:"default:0"` // Sum of merchant payments in the period
+	RefundAmount        float64    `gorm:"default:0"` // Payments refunded before settlement
+	FeeAmount           float64    `gorm:"default:0"`
+	NetAmount           float64    `gorm:"default:0"` // Gross - refunds - fees
+	ReserveAmount       float64    `gorm:"default:0"` // Part of net held back by this batch
+	ReserveReleased     float64    `gorm:"default:0"` // Earlier reserves paid out by this batch
+	PayoutAmount        float64    `gorm:"default:0"` // Net - reserve + released reserves
+	ReserveReleaseAt    *time.Time // When this batch's reserve becomes payable
+	ReserveReleasedAt   *time.Time
+	Status              string `gorm:"not null;index"` // paid, failed
+	FailureReason       *string
+	PaymentMethodID     *uuid.UUID `gorm:"type:uuid"`
+	PayoutTransactionID *uuid.UUID `gorm:"type:uuid"`
+}
+
+// SettlementBatchResponse for API responses
+type SettlementBatchResponse struct {
+	ID                  uuid.UUID  `json:"id"`
+	MerchantID          uuid.UUID  `json:"merchant_id"`
+	PeriodStart         time.Time  `json:"period_start"`
+	PeriodEnd           time.Time  `json:"period_end"`
+	Currency            string     `json:"currency"`
+	TransactionCount    int        `json:"transaction_count"`
+	GrossAmount         float64    `json:"gross_amount"`
+	RefundAmount        float64    `json:"refund_amount"`
+	FeeAmount           float64    `json:"fee_amount"`
+	NetAmount           float64    `json:"net_amount"`
+	ReserveAmount       float64    `json:"reserve_amount"`
+	ReserveReleased     float64    `json:"reserve_released"`
+	PayoutAmount        float64    `json:"payout_amount"`
+	ReserveReleaseAt    *time.Time `json:"reserve_release_at,omitempty"`
+	ReserveReleasedAt   *time.Time `json:"reserve_released_at,omitempty"`
+	Status              string     `json:"status"`
+	FailureReason       *string    `json:"failure_reason,omitempty"`
+	PaymentMethodID     *uuid.UUID `json:"payment_method_id,omitempty"`
+	PayoutTransactionID *uuid.UUID `json:"payout_transaction_id,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// ToResponse converts settlement batch to API response format
+func (b *SettlementBatch) ToResponse() SettlementBatchResponse {
+	return SettlementBatchResponse{
+		ID:                  b.ID,
+		MerchantID:          b.MerchantID,
+		PeriodStart:         b.PeriodStart,
+		PeriodEnd:           b.PeriodEnd,
+		Currency:            b.Currency,
+		TransactionCount:    b.TransactionCount,
+		GrossAmount:         b.GrossAmount,
+		RefundAmount:        b.RefundAmount,
+		FeeAmount:           b.FeeAmount,
+		NetAmount:           b.NetAmount,
+		ReserveAmount:       b.ReserveAmount,
+		ReserveReleased:     b.ReserveReleased,
+		PayoutAmount:        b.PayoutAmount,
+		ReserveReleaseAt:    b.ReserveReleaseAt,
+		ReserveReleasedAt:   b.ReserveReleasedAt,
+		Status:              b.Status,
+		FailureReason:       b.FailureReason,
+		PaymentMethodID:     b.PaymentMethodID,
+		PayoutTransactionID: b.PayoutTransactionID,
+		CreatedAt:           b.CreatedAt,
+	}
+}
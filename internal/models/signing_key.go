@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Signing Key Constants
+const (
+	SigningKeyAlgorithm   = "EdDSA"
+	SigningKeyCurve       = "Ed25519"
+	SigningKeyPublishLead = time.Hour // New keys are published this long before they sign, so verifier caches already hold them
+)
+
+// SigningKey is an Ed25519 key pair that signs JWTs. The newest key whose
+// NotBefore has passed signs new tokens; older keys stay in the JWKS until
+// every token they signed has expired.
+type SigningKey struct {
+	gorm.Model
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"` // Used as the kid header
+	Algorithm  string     `gorm:"not null"`
+	PublicKey  string     `gorm:"not null"`       // Raw public key, base64url encoded
+	PrivateKey string     `gorm:"not null"`       // Private key seed encrypted with utils.CIPHER
+	NotBefore  time.Time  `gorm:"not null;index"` // Signing starts at this time
+	ExpiresAt  *time.Time `gorm:"index"`          // Set once superseded; verification stops after this time
+}
+
+// JWK is a public signing key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -13,6 +13,8 @@ const (
 	TransactionTypeTransfer = "transfer"
 	TransactionTypePayment  = "payment"
 	TransactionTypeTopUp    = "topup"
+	TransactionTypePayout   = "payout" // Merchant settlement payout
+	TransactionTypeRefund   = "refund" // Dispute refund from merchant to payer
 )
 
 // Transaction Status Constants
@@ -26,18 +28,19 @@ const (
 // Transaction represents a financial transaction
 type Transaction struct {
 	gorm.Model
-	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	FromUserID  uuid.UUID      `gorm:"not null;type:uuid"`
-	ToUserID    *uuid.UUID     `gorm:"type:uuid"` // Optional (null for topup/withdraw)
-	Amount      float64        `gorm:"not null"`
-	Currency    string         `gorm:"not null;default:'ETB'"`
-	Type        string         `gorm:"not null"`          // deposit, withdraw, transfer, payment, topup
-	Status      string         `gorm:"default:'pending'"` // pending, completed, failed, refunded
-	Description *string        // Optional
-	Metadata    datatypes.JSON `gorm:"type:jsonb"` // JSONB for additional data
-	Fee         float64        `gorm:"default:0"`
-	FromWallet  Wallet         `gorm:"foreignKey:FromUserID;references:UserID"`
-	ToWallet    Wallet         `gorm:"foreignKey:ToUserID;references:UserID"`
+	ID                uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	FromUserID        uuid.UUID      `gorm:"not null;type:uuid"`
+	ToUserID          *uuid.UUID     `gorm:"type:uuid"` // Optional (null for topup/withdraw)
+	Amount            float64        `gorm:"not null"`
+	Currency          string         `gorm:"not null;default:'ETB'"`
+	Type              string         `gorm:"not null"`          // deposit, withdraw, transfer, payment, topup
+	Status            string         `gorm:"default:'pending'"` // pending, completed, failed, refunded
+	Description       *string        // Optional
+	Metadata          datatypes.JSON `gorm:"type:jsonb"` // JSONB for additional data
+	Fee               float64        `gorm:"default:0"`
+	SettlementBatchID *uuid.UUID     `gorm:"type:uuid;index"` // Batch that paid this payment out to the merchant
+	FromWallet        Wallet         `gorm:"foreignKey:FromUserID;references:UserID"`
+	ToWallet          Wallet         `gorm:"foreignKey:ToUserID;references:UserID"`
 }
 
 // ToResponse converts transaction to API response format
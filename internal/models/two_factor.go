@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Two-factor authentication constants
+const (
+	TOTPIssuer                    = "LevPay"
+	RecoveryCodeCount             = 10
+	StepUpHeader                  = "X-Step-Up-Token"
+	TwoFactorMethodTOTP           = "totp"
+	TwoFactorMethodRecovery       = "recovery_code"
+	TwoFactorChallengeMaxAttempts = 5 // Wrong codes before a login challenge is spent
+)
+
+// RecoveryCode is a single-use backup code for a user with 2FA enabled
+type RecoveryCode struct {
+	gorm.Model
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash string    `gorm:"not null"` // SHA-256 of the normalized code
+	UsedAt   *time.Time
+	User     User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TwoFactorChallenge is a login waiting for its second factor. The challenge
+// token names it, so wrong codes can be counted against it.
+type TwoFactorChallenge struct {
+	gorm.Model
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	Attempts    int       `gorm:"default:0"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	CompletedAt *time.Time
+	User        User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TwoFactorSetupResponse is returned when a user starts TOTP enrollment
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"` // Rendered as a QR code by the client
+}
+
+// RecoveryCodesResponse lists freshly generated recovery codes. They are only
+// shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallengeResponse is returned by login when a second factor is required
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool     `json:"two_factor_required"`
+	ChallengeToken    string   `json:"challenge_token"`
+	Methods           []string `json:"methods"`
+	ExpiresIn         int      `json:"expires_in"`
+}
+
+// StepUpResponse carries a short-lived token that authorizes a sensitive action
+type StepUpResponse struct {
+	StepUpToken string `json:"step_up_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// TwoFactorStatusResponse reports a user's 2FA state
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
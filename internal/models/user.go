@@ -10,26 +10,56 @@ import (
 // User represents a user in the system
 type User struct {
 	gorm.Model
-	ID             uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey"` // Use UUID as primary key
-	FirstName      string          `gorm:"not null"`
-	LastName       string          `gorm:"not null"`
-	Username       *string         `gorm:"unique;index"` // Unique handle (e.g. @kaybee)
-	AvatarURL      *string         // Profile picture URL
-	Email          string          `gorm:"unique;not null"`
-	Phone          *string         `gorm:"unique"` // Nullable for OAuth users
-	PasswordHash   *string         // Nullable to support OAuth-only accounts
-	GoogleID       *string         `gorm:"unique"` // For tracking Google accounts
-	DOB            *time.Time      // Optional
-	Address        *string         // Optional
-	Preferences    *string         `gorm:"type:jsonb"`        // JSON string for user settings {currency, language, notifications}
-	KYCStatus      string          `gorm:"default:'pending'"` // Enum: pending, verified, rejected
-	Role           string          `gorm:"default:'user'"`    // Enum: user, merchant, admin
-	TwoFAEnabled   bool            `gorm:"default:false"`
-	Wallet         Wallet          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Transactions   []Transaction   `gorm:"foreignKey:FromUserID"`
-	KYCDocuments   []KYCDocument   `gorm:"foreignKey:UserID"`
-	PaymentMethods []PaymentMethod `gorm:"foreignKey:UserID"`
-	Sessions       []Session       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ID                      uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey"` // Use UUID as primary key
+	FirstName               string           `gorm:"not null"`
+	LastName                string           `gorm:"not null"`
+	Username                *string          `gorm:"unique;index"` // Unique handle (e.g. @kaybee)
+	AvatarURL               *string          // Profile picture URL
+	Email                   string           `gorm:"unique;not null"`
+	EmailVerifiedAt         *time.Time       // Set once the user confirms their email address
+	EmailVerificationSentAt *time.Time       // Last verification email, used for resend cooldown
+	Phone                   *string          `gorm:"unique"` // Nullable for OAuth users
+	PhoneVerifiedAt         *time.Time       // Set once the current phone number is confirmed by SMS
+	PasswordHash            *string          // Nullable to support OAuth-only accounts
+	PasswordVersion         int              `gorm:"default:0"` // Incremented on every password change, invalidating reset links
+	GoogleID                *string          `gorm:"unique"`    // Legacy Google link; OAuthIdentity holds provider links
+	DOB                     *time.Time       // Optional
+	Address                 *string          // Optional
+	Preferences             *string          `gorm:"type:jsonb"`             // JSON string for user settings {currency, language, notifications}
+	KYCStatus               string           `gorm:"default:'pending'"`      // Enum: pending, verified, rejected
+	Role                    string           `gorm:"default:'user'"`         // Account role: user, merchant or admin; staff roles come from RoleAssignments
+	Status                  string           `gorm:"default:'active';index"` // Enum: active, frozen, suspended, closed
+	StatusReason            *string          // Why the account was last moved out of active
+	StatusChangedAt         *time.Time       // When Status last changed
+	TwoFAEnabled            bool             `gorm:"default:false"`
+	TOTPSecret              *string          // Encrypted TOTP secret; set during enrollment
+	TOTPLastStep            int64            `gorm:"default:0"` // Last accepted TOTP time step, prevents code replay
+	FailedLoginCount        int              `gorm:"default:0"` // Consecutive failed logins, reset on success
+	LockedUntil             *time.Time       // Login is refused until this time after too many failures
+	PINHash                 *string          // Hashed transaction PIN authorizing payments
+	PINFailedCount          int              `gorm:"default:0"` // Consecutive wrong PINs, reset on success
+	PINLockedUntil          *time.Time       // Payments needing the PIN are refused until this time
+	Wallet                  Wallet           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Transactions            []Transaction    `gorm:"foreignKey:FromUserID"`
+	KYCDocuments            []KYCDocument    `gorm:"foreignKey:UserID"`
+	PaymentMethods          []PaymentMethod  `gorm:"foreignKey:UserID"`
+	Sessions                []Session        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	RoleAssignments         []RoleAssignment `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// IsLocked reports whether logins are refused because of a brute-force lockout
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// CanSignIn reports whether the account status allows signing in
+func (u *User) CanSignIn() bool {
+	return AccountStatusAllowsSignIn(u.Status)
+}
+
+// IsPINLocked reports whether the transaction PIN is locked after too many wrong attempts
+func (u *User) IsPINLocked(now time.Time) bool {
+	return u.PINLockedUntil != nil && now.Before(*u.PINLockedUntil)
 }
 
 // PrepareResponse sanitizes user data for API responses
@@ -53,17 +83,21 @@ func (u *User) PrepareResponse() UserResponse {
 	}
 
 	return UserResponse{
-		ID:           u.ID,
-		FirstName:    u.FirstName,
-		LastName:     u.LastName,
-		Username:     username,
-		AvatarURL:    avatar,
-		Email:        u.Email,
-		Phone:        phone,
-		Preferences:  prefs,
-		KYCStatus:    u.KYCStatus,
-		Role:         u.Role,
-		Is2FAEnabled: u.TwoFAEnabled,
-		CreatedAt:    u.CreatedAt,
+		ID:            u.ID,
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		Username:      username,
+		AvatarURL:     avatar,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		Phone:         phone,
+		PhoneVerified: u.PhoneVerifiedAt != nil,
+		Preferences:   prefs,
+		KYCStatus:     u.KYCStatus,
+		Role:          u.Role,
+		Status:        u.Status,
+		Is2FAEnabled:  u.TwoFAEnabled,
+		HasPIN:        u.PINHash != nil,
+		CreatedAt:     u.CreatedAt,
 	}
 }
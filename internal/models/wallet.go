@@ -13,8 +13,10 @@ type Wallet struct {
 	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	UserID      uuid.UUID `gorm:"unique;not null;type:uuid"`
 	Balance     float64   `gorm:"default:0"`
+	HeldBalance float64   `gorm:"default:0"` // Part of the balance that cannot be spent (reserves, holds)
 	Currency    string    `gorm:"default:'ETB'"`
 	Locked      bool      `gorm:"default:false"`
+	Frozen      bool      `gorm:"default:false"` // Set while the owner's account is not active; only an admin can lift it
 	LastUpdated time.Time
 }
 
@@ -24,8 +26,11 @@ func (w *Wallet) ToResponse() WalletResponse {
 		ID:          w.ID,
 		UserID:      w.UserID,
 		Balance:     w.Balance,
+		HeldBalance: w.HeldBalance,
+		Available:   w.Balance - w.HeldBalance,
 		Currency:    w.Currency,
 		Locked:      w.Locked,
+		Frozen:      w.Frozen,
 		LastUpdated: w.LastUpdated,
 	}
 }
@@ -0,0 +1,32 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/idtoken"
+)
+
+// GoogleProvider verifies Google ID tokens
+type GoogleProvider struct {
+	clientIDs []string
+}
+
+// NewGoogleProvider creates a Google provider accepting tokens for the client IDs
+func NewGoogleProvider(clientIDs []string) *GoogleProvider {
+	return &GoogleProvider{clientIDs: clientIDs}
+}
+
+// Verify checks the token signature, expiry and issuer, then the audience
+// against every configured client ID (web, Android and iOS apps differ)
+func (p *GoogleProvider) Verify(ctx context.Context, idToken string) (*Identity, error) {
+	payload, err := idtoken.Validate(ctx, idToken, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !containsAudience(p.clientIDs, payload.Audience) {
+		return nil, fmt.Errorf("%w: unexpected audience %s", ErrInvalidToken, payload.Audience)
+	}
+
+	return identityFromClaims(ProviderGoogle, payload.Subject, payload.Claims)
+}
@@ -0,0 +1,120 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Keba777/levpay-backend/internal/config"
+)
+
+// Provider name constants
+const (
+	ProviderGoogle    = "google"
+	ProviderApple     = "apple"
+	ProviderMicrosoft = "microsoft"
+)
+
+// ErrInvalidToken is returned when an ID token fails verification
+var ErrInvalidToken = errors.New("invalid id token")
+
+// Identity is the account an OpenID Connect provider vouches for
+type Identity struct {
+	Provider      string
+	Subject       string // Stable account ID at the provider
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+// Provider verifies ID tokens issued by an OpenID Connect provider for one
+// of our client IDs
+type Provider interface {
+	Verify(ctx context.Context, idToken string) (*Identity, error)
+}
+
+// Providers holds the sign-in providers that have client IDs configured
+var Providers = map[string]Provider{}
+
+// InitOAuth registers every provider that has client IDs configured
+func InitOAuth() {
+	cfg := config.CFG.OAuth
+	Providers = map[string]Provider{}
+
+	if ids := splitList(cfg.GoogleClientIDs); len(ids) > 0 {
+		Providers[ProviderGoogle] = NewGoogleProvider(ids)
+	}
+	if ids := splitList(cfg.AppleClientIDs); len(ids) > 0 {
+		Providers[ProviderApple] = NewOIDCProvider(ProviderApple, "https://appleid.apple.com", "https://appleid.apple.com/auth/keys", ids)
+	}
+	if ids := splitList(cfg.MicrosoftClientIDs); len(ids) > 0 {
+		tenant := cfg.MicrosoftTenant
+		// Multi-tenant apps receive tokens whose issuer names the user's tenant
+		issuer := "https://login.microsoftonline.com/" + tenant + "/v2.0"
+		if tenant == "common" || tenant == "organizations" || tenant == "consumers" {
+			issuer = "https://login.microsoftonline.com/{tenantid}/v2.0"
+		}
+		Providers[ProviderMicrosoft] = NewOIDCProvider(ProviderMicrosoft, issuer, "https://login.microsoftonline.com/"+tenant+"/discovery/v2.0/keys", ids)
+	}
+}
+
+// splitList parses a comma separated configuration value
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// identityFromClaims reads the standard OIDC claims without trusting their types
+func identityFromClaims(provider, subject string, claims map[string]interface{}) (*Identity, error) {
+	if subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	identity := &Identity{
+		Provider:      provider,
+		Subject:       subject,
+		Email:         strings.ToLower(stringClaim(claims, "email")),
+		EmailVerified: boolClaim(claims, "email_verified"),
+		FirstName:     stringClaim(claims, "given_name"),
+		LastName:      stringClaim(claims, "family_name"),
+	}
+	if identity.FirstName == "" && identity.LastName == "" {
+		identity.FirstName, identity.LastName, _ = strings.Cut(stringClaim(claims, "name"), " ")
+	}
+	return identity, nil
+}
+
+// stringClaim returns a string claim, or empty if missing or of another type
+func stringClaim(claims map[string]interface{}, key string) string {
+	value, _ := claims[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// boolClaim returns a boolean claim. Some providers send "true" as a string.
+func boolClaim(claims map[string]interface{}, key string) bool {
+	switch value := claims[key].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// containsAudience reports whether any token audience is one of our client IDs
+func containsAudience(clientIDs []string, audiences ...string) bool {
+	for _, aud := range audiences {
+		for _, id := range clientIDs {
+			if aud == id {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKS cache policy
+const (
+	jwksRefreshInterval = time.Hour        // Keys are refetched at least this often
+	jwksMinRefresh      = 30 * time.Second // Unknown kids trigger a refetch at most this often
+)
+
+// OIDCProvider verifies RS256 ID tokens from any OpenID Connect provider that
+// publishes its keys as a JWKS. The issuer may contain {tenantid}, which is
+// filled from the token's tid claim for multi-tenant providers.
+type OIDCProvider struct {
+	name      string
+	issuer    string
+	jwksURL   string
+	clientIDs []string
+	client    *http.Client
+
+	mu      sync.RWMutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// NewOIDCProvider creates a provider accepting tokens for the client IDs
+func NewOIDCProvider(name, issuer, jwksURL string, clientIDs []string) *OIDCProvider {
+	return &OIDCProvider{
+		name:      name,
+		issuer:    issuer,
+		jwksURL:   jwksURL,
+		clientIDs: clientIDs,
+		client:    &http.Client{Timeout: 5 * time.Second},
+		keys:      map[string]*rsa.PublicKey{},
+	}
+}
+
+// Verify checks the token signature, expiry, issuer and audience
+func (p *OIDCProvider) Verify(ctx context.Context, idToken string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	issuer := strings.ReplaceAll(p.issuer, "{tenantid}", stringClaim(claims, "tid"))
+	if stringClaim(claims, "iss") != issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	audiences, err := claims.GetAudience()
+	if err != nil || !containsAudience(p.clientIDs, audiences...) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return identityFromClaims(p.name, stringClaim(claims, "sub"), claims)
+}
+
+// key returns the verification key for a kid, refetching the JWKS when it
+// is stale or the kid is unknown
+func (p *OIDCProvider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	age := time.Since(p.fetched)
+	p.mu.RUnlock()
+
+	if age > jwksRefreshInterval || (!ok && age > jwksMinRefresh) {
+		if err := p.refresh(ctx); err != nil && !ok {
+			return nil, err
+		}
+		p.mu.RLock()
+		key, ok = p.keys[kid]
+		p.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown %s signing key %s", p.name, kid)
+	}
+	return key, nil
+}
+
+// refresh downloads the provider's signing keys
+func (p *OIDCProvider) refresh(ctx context.Context) error {
+	p.mu.Lock()
+	p.fetched = time.Now()
+	p.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s keys: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s keys: status %d", p.name, resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode %s keys: %w", p.name, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,226 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// Policy names
+const (
+	PolicyAuth   = "auth"    // Everything under /api/auth, per client IP
+	PolicySignIn = "sign_in" // Sign-in, registration and recovery, per client IP
+	PolicyMoney  = "money"   // Money movement, per user
+)
+
+// redisRetryDelay is how long the limiter counts in memory after Redis fails
+// before trying it again, so requests do not each wait on a dead server
+const redisRetryDelay = 10 * time.Second
+
+// redisTimeout bounds each Redis call so a slow server cannot stall requests
+const redisTimeout = 500 * time.Millisecond
+
+// Policy is a request limit over a sliding window
+type Policy struct {
+	Name    string
+	Limit   int64
+	Window  time.Duration
+	PerUser bool // Key on the signed-in user instead of the client IP
+}
+
+// Result is the outcome of counting a request against a policy
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration // Until the current window ends, or until a request fits again once limited
+	RetryAfter time.Duration // Zero unless limited
+}
+
+// ParsePolicy reads a limit/window spec such as 60/1m. A window without a
+// unit is in seconds.
+func ParsePolicy(name, spec string, perUser bool) (Policy, error) {
+	limitPart, windowPart, ok := strings.Cut(strings.TrimSpace(spec), "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %s: expected limit/window, got %q", name, spec)
+	}
+
+	limit, err := strconv.ParseInt(strings.TrimSpace(limitPart), 10, 64)
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %s: invalid limit %q", name, limitPart)
+	}
+
+	windowPart = strings.TrimSpace(windowPart)
+	window, err := time.ParseDuration(windowPart)
+	if seconds, convErr := strconv.Atoi(windowPart); convErr == nil {
+		window, err = time.Duration(seconds)*time.Second, nil
+	}
+	if err != nil || window < time.Second {
+		return Policy{}, fmt.Errorf("rate limit %s: invalid window %q", name, windowPart)
+	}
+
+	return Policy{Name: name, Limit: limit, Window: window, PerUser: perUser}, nil
+}
+
+// Limiter counts requests in Redis, falling back to memory while Redis is
+// unreachable so a single instance still enforces its limits
+type Limiter struct {
+	enabled  bool
+	policies map[string]Policy
+	redis    Store
+	memory   *MemoryStore
+	client   *redis.Client
+
+	mu         sync.Mutex
+	redisRetry time.Time // Zero while Redis is healthy
+}
+
+// Default is the process-wide rate limiter
+var Default *Limiter
+
+// InitRateLimit loads the policies from configuration and connects the
+// limiter to Redis. Without a Redis host it counts in memory.
+func InitRateLimit() {
+	cfg := config.CFG
+	logger := utils.GetLogger("ratelimit")
+
+	specs := []struct {
+		name    string
+		spec    string
+		perUser bool
+	}{
+		{PolicyAuth, cfg.RateLimit.Auth, false},
+		{PolicySignIn, cfg.RateLimit.SignIn, false},
+		{PolicyMoney, cfg.RateLimit.Money, true},
+	}
+
+	l := &Limiter{
+		enabled:  cfg.RateLimit.Enabled,
+		policies: map[string]Policy{},
+		memory:   NewMemoryStore(),
+	}
+	for _, s := range specs {
+		policy, err := ParsePolicy(s.name, s.spec, s.perUser)
+		if err != nil {
+			panic(err)
+		}
+		l.policies[s.name] = policy
+	}
+
+	if cfg.Redis.Host != "" {
+		l.client = redis.NewClient(&redis.Options{
+			Addr:         net.JoinHostPort(cfg.Redis.Host, strconv.Itoa(cfg.Redis.Port)),
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			DialTimeout:  redisTimeout,
+			ReadTimeout:  redisTimeout,
+			WriteTimeout: redisTimeout,
+		})
+		l.redis = NewRedisStore(l.client)
+		logger.Info("Rate limiting with Redis", utils.Field{Key: "addr", Value: l.client.Options().Addr})
+	} else {
+		logger.Info("Rate limiting in memory, no Redis host configured")
+	}
+	Default = l
+}
+
+// Close releases the process-wide limiter's Redis connections
+func Close() {
+	if Default != nil && Default.client != nil {
+		Default.client.Close()
+	}
+}
+
+// Enabled reports whether limits are enforced
+func (l *Limiter) Enabled() bool {
+	return l.enabled
+}
+
+// Policy returns a configured policy by name
+func (l *Limiter) Policy(name string) (Policy, bool) {
+	policy, ok := l.policies[name]
+	return policy, ok
+}
+
+// Allow counts a request by key against a policy
+func (l *Limiter) Allow(policy Policy, key string) Result {
+	now := time.Now()
+	counts := l.hit(policy.Name+":"+key, policy, now)
+
+	size := policy.Window.Milliseconds()
+	_, elapsed := windowPosition(policy.Window, now)
+	used := weighted(counts.Current, counts.Previous, size, elapsed)
+
+	result := Result{
+		Allowed:   counts.Allowed,
+		Limit:     policy.Limit,
+		Remaining: max(policy.Limit-used, 0),
+		Reset:     time.Duration(size-elapsed) * time.Millisecond,
+	}
+	if !counts.Allowed {
+		result.RetryAfter = retryAfter(counts, policy.Limit, size, elapsed)
+		result.Reset = result.RetryAfter
+	}
+	return result
+}
+
+// hit counts the request in Redis if it is available, otherwise in memory
+func (l *Limiter) hit(key string, policy Policy, now time.Time) Counts {
+	if l.redis != nil && l.useRedis(now) {
+		counts, err := l.redis.Hit(key, policy.Limit, policy.Window, now)
+		if err == nil {
+			l.redisRecovered()
+			return counts
+		}
+		l.redisFailed(now, err)
+	}
+	// Counting in memory cannot fail
+	counts, _ := l.memory.Hit(key, policy.Limit, policy.Window, now)
+	return counts
+}
+
+// useRedis reports whether Redis is healthy or due another try
+func (l *Limiter) useRedis(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.redisRetry.IsZero() || !now.Before(l.redisRetry)
+}
+
+func (l *Limiter) redisFailed(now time.Time, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.redisRetry.IsZero() {
+		utils.GetLogger("ratelimit").ErrorWithErr("Redis unavailable, rate limiting in memory", err)
+	}
+	l.redisRetry = now.Add(redisRetryDelay)
+}
+
+func (l *Limiter) redisRecovered() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.redisRetry.IsZero() {
+		l.redisRetry = time.Time{}
+		utils.GetLogger("ratelimit").Info("Redis available again, rate limiting with Redis")
+	}
+}
+
+// retryAfter works out when the sliding window will next have room for a
+// request, given counts that are at the limit
+func retryAfter(counts Counts, limit, size, elapsed int64) time.Duration {
+	var wait int64
+	if counts.Current < limit && counts.Previous > 0 {
+		// Room opens up within this window as the previous one slides out
+		wait = size - (limit-counts.Current)*size/counts.Previous - elapsed + 1
+	} else {
+		// This window is full by itself, so it has to start sliding out
+		wait = size - elapsed + size - limit*size/counts.Current + 1
+	}
+	return time.Duration(max(wait, 1)) * time.Millisecond
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Counts are the requests a key made in the current and previous fixed
+// windows, after the hit was counted if it was allowed
+type Counts struct {
+	Allowed  bool
+	Current  int64
+	Previous int64
+}
+
+// Store counts hits per key in fixed windows. The check and the increment
+// must be atomic so concurrent requests cannot both take the last slot.
+type Store interface {
+	Hit(key string, limit int64, window time.Duration, now time.Time) (Counts, error)
+}
+
+// windowPosition splits a time into the index of its fixed window and how
+// far into that window it is
+func windowPosition(window time.Duration, now time.Time) (index, elapsed int64) {
+	size := window.Milliseconds()
+	ms := now.UnixMilli()
+	return ms / size, ms % size
+}
+
+// weighted estimates the requests in the sliding window ending now by counting
+// the overlapping part of the previous window pro rata
+func weighted(current, previous, size, elapsed int64) int64 {
+	return previous*(size-elapsed)/size + current
+}
+
+// slidingWindowScript applies the same check as weighted and only counts the
+// hit when it fits. Keys expire once they can no longer be the previous window.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local size = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+if math.floor(previous * (size - elapsed) / size) + current >= limit then
+	return {0, current, previous}
+end
+current = redis.call('INCR', KEYS[1])
+if current == 1 then
+	redis.call('PEXPIRE', KEYS[1], size * 2)
+end
+return {1, current, previous}
+`)
+
+// RedisStore keeps the counters in Redis so every instance shares them
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store on a Redis client
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Hit counts a request for the key
+func (s *RedisStore) Hit(key string, limit int64, window time.Duration, now time.Time) (Counts, error) {
+	index, elapsed := windowPosition(window, now)
+	// The hash tag keeps both windows of a key on one cluster slot
+	prefix := "ratelimit:{" + key + "}:"
+	keys := []string{prefix + fmt.Sprint(index), prefix + fmt.Sprint(index-1)}
+
+	// Run loads the script on a NOSCRIPT reply
+	values, err := slidingWindowScript.Run(context.Background(), s.client, keys, limit, window.Milliseconds(), elapsed).Int64Slice()
+	if err != nil {
+		return Counts{}, err
+	}
+	if len(values) != 3 {
+		return Counts{}, fmt.Errorf("unexpected rate limit reply: %v", values)
+	}
+	return Counts{Allowed: values[0] == 1, Current: values[1], Previous: values[2]}, nil
+}
+
+// memorySweepInterval is how often stale keys are dropped from memory
+const memorySweepInterval = time.Minute
+
+// MemoryStore keeps the counters in process. Limits only hold for a single
+// instance, so it backs Redis rather than replacing it.
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	window   time.Duration
+	index    int64
+	current  int64
+	previous int64
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: map[string]*memoryWindow{}}
+}
+
+// Hit counts a request for the key
+func (s *MemoryStore) Hit(key string, limit int64, window time.Duration, now time.Time) (Counts, error) {
+	index, elapsed := windowPosition(window, now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	w, ok := s.windows[key]
+	if !ok {
+		w = &memoryWindow{window: window, index: index}
+		s.windows[key] = w
+	}
+	switch {
+	case w.index == index:
+	case w.index == index-1:
+		w.previous, w.current = w.current, 0
+	default:
+		w.previous, w.current = 0, 0
+	}
+	w.index = index
+
+	if weighted(w.current, w.previous, window.Milliseconds(), elapsed) >= limit {
+		return Counts{Current: w.current, Previous: w.previous}, nil
+	}
+	w.current++
+	return Counts{Allowed: true, Current: w.current, Previous: w.previous}, nil
+}
+
+// sweep drops keys whose counts can no longer affect a limit
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, w := range s.windows {
+		if index, _ := windowPosition(w.window, now); w.index < index-1 {
+			delete(s.windows, key)
+		}
+	}
+}
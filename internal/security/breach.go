@@ -0,0 +1,113 @@
+package security
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// breachPrefixLen is the length of the hash prefix that selects a range,
+// matching the Have I Been Pwned range format
+const breachPrefixLen = 5
+
+// BreachedPasswords checks passwords against a breach corpus using
+// k-anonymity ranges: a password's SHA-1 hash is split into a prefix, which
+// selects a range of candidate hashes, and a suffix matched within it. The
+// corpus is either a single file of "HASH:COUNT" lines loaded into memory, or
+// a directory of range files named by prefix holding "SUFFIX:COUNT" lines,
+// read on demand.
+type BreachedPasswords struct {
+	dir    string
+	ranges map[string]map[string]struct{}
+}
+
+// LoadBreachedPasswords opens the breach corpus at path
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords: %w", err)
+	}
+	if info.IsDir() {
+		return &BreachedPasswords{dir: path}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords: %w", err)
+	}
+	defer file.Close()
+
+	ranges := make(map[string]map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash := strings.ToUpper(hashField(scanner.Text()))
+		if len(hash) != sha1.Size*2 {
+			continue
+		}
+		prefix, suffix := hash[:breachPrefixLen], hash[breachPrefixLen:]
+		if ranges[prefix] == nil {
+			ranges[prefix] = make(map[string]struct{})
+		}
+		ranges[prefix][suffix] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached passwords: %w", err)
+	}
+
+	return &BreachedPasswords{ranges: ranges}, nil
+}
+
+// Contains reports whether the password appears in the corpus
+func (b *BreachedPasswords) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:breachPrefixLen], hash[breachPrefixLen:]
+
+	if b.ranges != nil {
+		_, found := b.ranges[prefix][suffix]
+		return found, nil
+	}
+	return b.searchRange(prefix, suffix)
+}
+
+// searchRange scans the range file for a prefix, named either PREFIX or PREFIX.txt
+func (b *BreachedPasswords) searchRange(prefix, suffix string) (bool, error) {
+	var file *os.File
+	var err error
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		file, err = os.Open(filepath.Join(b.dir, name))
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open breach range %s: %w", prefix, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.EqualFold(hashField(scanner.Text()), suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read breach range %s: %w", prefix, err)
+	}
+	return false, nil
+}
+
+// hashField strips the optional ":COUNT" from a corpus line
+func hashField(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return hash
+}
@@ -0,0 +1,108 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Keba777/levpay-backend/internal/utils"
+)
+
+// Password limits that are not configurable
+const (
+	PasswordMaxLength    = 72 // bcrypt ignores anything beyond 72 bytes
+	minPersonalInfoLen   = 3  // Shorter names are too common to reject on
+	passwordClassesTotal = 4  // Lowercase, uppercase, digits and symbols
+)
+
+// PasswordError lists the rules a rejected password breaks
+type PasswordError struct {
+	Violations []string
+}
+
+// Error joins the violations into a message suitable for the client
+func (e *PasswordError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// ValidatePassword checks a password against the length, character class,
+// personal information and breach rules. Personal holds values such as the
+// email and names that the password must not contain. It returns a
+// *PasswordError when the password is rejected.
+func (p *Policy) ValidatePassword(password string, personal ...string) error {
+	var violations []string
+
+	if len(password) < p.PasswordMinLength {
+		violations = append(violations, fmt.Sprintf("Password must be at least %d characters", p.PasswordMinLength))
+	}
+	if len(password) > PasswordMaxLength {
+		violations = append(violations, fmt.Sprintf("Password must be at most %d bytes", PasswordMaxLength))
+	}
+	if characterClasses(password) < p.PasswordMinClasses {
+		violations = append(violations, fmt.Sprintf("Password must mix at least %d of lowercase letters, uppercase letters, digits and symbols", p.PasswordMinClasses))
+	}
+
+	lowered := strings.ToLower(password)
+	for _, value := range personalTerms(personal) {
+		if strings.Contains(lowered, value) {
+			violations = append(violations, "Password must not contain your name or email")
+			break
+		}
+	}
+
+	if len(violations) == 0 && p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			// A broken corpus must not lock users out of changing passwords
+			utils.GetLogger("security").ErrorWithErr("Breached password check failed", err)
+		} else if breached {
+			violations = append(violations, "Password has appeared in a data breach. Choose a different password")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordError{Violations: violations}
+	}
+	return nil
+}
+
+// characterClasses counts the character classes present in a password
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// personalTerms lowercases the personal values, splitting emails into the
+// local part, and drops values too short to be meaningful
+func personalTerms(values []string) []string {
+	var terms []string
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if local, _, found := strings.Cut(value, "@"); found {
+			value = local
+		}
+		if len(value) >= minPersonalInfoLen {
+			terms = append(terms, value)
+		}
+	}
+	return terms
+}
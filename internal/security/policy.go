@@ -0,0 +1,149 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/config"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/sms"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Production hardening thresholds
+const (
+	minProductionBcryptCost = 12
+	minProductionSecretLen  = 32
+)
+
+// Policy holds the validated security settings shared by auth, middleware
+// and tools. Token lifetimes are in seconds.
+type Policy struct {
+	BcryptCost          int
+	AccessExpiry        int
+	RefreshExpiry       int
+	ForgotExpiry        int
+	VerifyExpiry        int
+	UnlockExpiry        int
+	TwoFactorExpiry     int // Challenge and step-up tokens
+	ImpersonationExpiry int // Read-only tokens for staff viewing as a user
+	KeyRotation         time.Duration
+	Secret              string
+	EncryptionKey       string
+
+	PasswordMinLength  int
+	PasswordMinClasses int                // Distinct character classes required, out of 4
+	PasswordHistory    int                // Recent passwords that cannot be reused
+	Breached           *BreachedPasswords // Nil when no breach corpus is configured
+
+	PINMaxAttempts int           // Wrong transaction PINs before the PIN locks
+	PINLockout     time.Duration // How long a locked PIN stays locked
+	PINThreshold   float64       // Payments above this amount need the PIN or a 2FA code
+}
+
+// NewPolicy builds the security policy from configuration and validates it.
+// Placeholder secrets are refused when running in production.
+func NewPolicy(cfg *models.Config) (*Policy, error) {
+	sec := cfg.Security
+	p := &Policy{
+		BcryptCost:          sec.Complecity,
+		AccessExpiry:        sec.AccessExpiries,
+		RefreshExpiry:       sec.RefreshExpiries,
+		ForgotExpiry:        sec.ForgotExpiries,
+		VerifyExpiry:        sec.VerifyExpiries,
+		UnlockExpiry:        sec.UnlockExpiries,
+		TwoFactorExpiry:     sec.TwoFactorExpiries,
+		ImpersonationExpiry: sec.ImpersonationExpiries,
+		KeyRotation:         time.Duration(sec.KeyRotationDays) * 24 * time.Hour,
+		Secret:              sec.Secret,
+		EncryptionKey:       sec.EncryptionKey,
+
+		PasswordMinLength:  sec.PasswordMinLength,
+		PasswordMinClasses: sec.PasswordMinClasses,
+		PasswordHistory:    sec.PasswordHistory,
+
+		PINMaxAttempts: sec.PINMaxAttempts,
+		PINLockout:     time.Duration(sec.PINLockoutSeconds) * time.Second,
+		PINThreshold:   float64(sec.PINThreshold),
+	}
+
+	var errs []error
+	if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("SECURITY_COMPLECITY must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+
+	expiries := []struct {
+		name  string
+		value int
+	}{
+		{"SECURITY_ACCESS_EXPIRIES", p.AccessExpiry},
+		{"SECURITY_REFRESH_EXPIRIES", p.RefreshExpiry},
+		{"SECURITY_FORGOT_EXPIRIES", p.ForgotExpiry},
+		{"SECURITY_VERIFY_EXPIRIES", p.VerifyExpiry},
+		{"SECURITY_UNLOCK_EXPIRIES", p.UnlockExpiry},
+		{"SECURITY_TWO_FACTOR_EXPIRIES", p.TwoFactorExpiry},
+		{"SECURITY_IMPERSONATION_EXPIRIES", p.ImpersonationExpiry},
+	}
+	for _, e := range expiries {
+		if e.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", e.name))
+		}
+	}
+	if p.AccessExpiry > p.RefreshExpiry {
+		errs = append(errs, errors.New("SECURITY_ACCESS_EXPIRIES must not exceed SECURITY_REFRESH_EXPIRIES"))
+	}
+	if p.ImpersonationExpiry > p.AccessExpiry {
+		errs = append(errs, errors.New("SECURITY_IMPERSONATION_EXPIRIES must not exceed SECURITY_ACCESS_EXPIRIES"))
+	}
+	if p.KeyRotation <= models.SigningKeyPublishLead {
+		errs = append(errs, errors.New("SECURITY_KEY_ROTATION_DAYS must be at least 1"))
+	}
+
+	if p.PasswordMinLength < 8 || p.PasswordMinLength > PasswordMaxLength {
+		errs = append(errs, fmt.Errorf("SECURITY_PASSWORD_MIN_LENGTH must be between 8 and %d", PasswordMaxLength))
+	}
+	if p.PasswordMinClasses < 1 || p.PasswordMinClasses > passwordClassesTotal {
+		errs = append(errs, fmt.Errorf("SECURITY_PASSWORD_MIN_CLASSES must be between 1 and %d", passwordClassesTotal))
+	}
+	if p.PasswordHistory < 0 {
+		errs = append(errs, errors.New("SECURITY_PASSWORD_HISTORY must not be negative"))
+	}
+	if p.PINMaxAttempts < 1 {
+		errs = append(errs, errors.New("SECURITY_PIN_MAX_ATTEMPTS must be at least 1"))
+	}
+	if p.PINLockout <= 0 {
+		errs = append(errs, errors.New("SECURITY_PIN_LOCKOUT_SECONDS must be positive"))
+	}
+	if p.PINThreshold < 0 {
+		errs = append(errs, errors.New("SECURITY_PIN_THRESHOLD must not be negative"))
+	}
+	if sec.BreachedPasswordsPath != "" {
+		breached, err := LoadBreachedPasswords(sec.BreachedPasswordsPath)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		p.Breached = breached
+	}
+
+	if cfg.App.Env == config.EnvProduction {
+		if p.BcryptCost < minProductionBcryptCost {
+			errs = append(errs, fmt.Errorf("SECURITY_COMPLECITY must be at least %d in production", minProductionBcryptCost))
+		}
+		if p.Secret == config.DefaultSecuritySecret || len(p.Secret) < minProductionSecretLen {
+			errs = append(errs, fmt.Errorf("SECURITY_SECRET must be set to at least %d characters in production", minProductionSecretLen))
+		}
+		if p.EncryptionKey == config.DefaultEncryptionKey || len(p.EncryptionKey) < minProductionSecretLen {
+			errs = append(errs, fmt.Errorf("SECURITY_ENCRYPTION_KEY must be set to at least %d characters in production", minProductionSecretLen))
+		}
+		// The log provider writes one-time codes to the logs
+		if cfg.SMS.Provider == "" || cfg.SMS.Provider == sms.ProviderLog {
+			errs = append(errs, errors.New("SMS_PROVIDER must be a real SMS gateway in production"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid security configuration: %w", errors.Join(errs...))
+	}
+	return p, nil
+}
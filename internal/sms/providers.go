@@ -0,0 +1,100 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/utils"
+)
+
+// LogProvider writes messages to the log, and optionally a file, instead of
+// sending them. It is meant for local development.
+type LogProvider struct {
+	path   string
+	mu     sync.Mutex
+	logger *utils.Logger
+}
+
+// NewLogProvider creates a provider that records messages locally
+func NewLogProvider(path string) *LogProvider {
+	return &LogProvider{
+		path:   path,
+		logger: utils.GetLogger("sms"),
+	}
+}
+
+// Send records the message
+func (p *LogProvider) Send(to, message string) error {
+	p.logger.Info("SMS message", utils.Field{Key: "to", Value: to}, utils.Field{Key: "message", Value: message})
+	if p.path == "" {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, message)
+	return err
+}
+
+// HTTPProvider sends messages through an HTTP SMS gateway that accepts a JSON
+// body of {from, to, message} authorized with a bearer API key
+type HTTPProvider struct {
+	url    string
+	apiKey string
+	sender string
+	client *http.Client
+}
+
+// NewHTTPProvider creates a provider for an HTTP SMS gateway
+func NewHTTPProvider(url, apiKey, sender string) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		apiKey: apiKey,
+		sender: sender,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts the message to the gateway
+func (p *HTTPProvider) Send(to, message string) error {
+	payload, err := json.Marshal(map[string]string{
+		"from":    p.sender,
+		"to":      to,
+		"message": message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode sms: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build sms request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package sms
+
+import (
+	"fmt"
+
+	"github.com/Keba777/levpay-backend/internal/config"
+)
+
+// Provider constants
+const (
+	ProviderLog  = "log"
+	ProviderHTTP = "http"
+)
+
+// Provider delivers text messages to phone numbers
+type Provider interface {
+	Send(to, message string) error
+}
+
+// SMS is the configured provider used by the services
+var SMS Provider
+
+// InitSMS selects the SMS provider from configuration
+func InitSMS() {
+	cfg := config.CFG.SMS
+
+	switch cfg.Provider {
+	case ProviderHTTP:
+		if cfg.URL == "" {
+			panic(fmt.Sprintf("SMS provider %q requires SMS_GATEWAY_URL", cfg.Provider))
+		}
+		SMS = NewHTTPProvider(cfg.URL, cfg.APIKey, cfg.Sender)
+	case ProviderLog, "":
+		SMS = NewLogProvider(cfg.LogFile)
+	default:
+		panic(fmt.Sprintf("unknown SMS provider: %s", cfg.Provider))
+	}
+}
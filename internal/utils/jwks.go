@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Keba777/levpay-backend/internal/models"
+)
+
+// JWKS cache policy for services that only verify tokens
+const (
+	jwksRefreshInterval = 10 * time.Minute // Keys are refetched at least this often
+	jwksMinRefresh      = 30 * time.Second // Unknown kids trigger a refetch at most this often
+)
+
+// EncodeJWK describes an Ed25519 public key as a JWK
+func EncodeJWK(kid string, key ed25519.PublicKey) models.JWK {
+	return models.JWK{
+		Kty: "OKP",
+		Crv: models.SigningKeyCurve,
+		X:   base64.RawURLEncoding.EncodeToString(key),
+		Kid: kid,
+		Alg: models.SigningKeyAlgorithm,
+		Use: "sig",
+	}
+}
+
+// DecodeJWK extracts the Ed25519 public key from a JWK
+func DecodeJWK(jwk models.JWK) (ed25519.PublicKey, error) {
+	if jwk.Kty != "OKP" || jwk.Crv != models.SigningKeyCurve {
+		return nil, fmt.Errorf("unsupported key type %s/%s", jwk.Kty, jwk.Crv)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid key size %d", len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// fetchJWKS downloads the verification keys published by the auth service
+func fetchJWKS(client *http.Client, url string) (map[string]ed25519.PublicKey, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch jwks: status %d", resp.StatusCode)
+	}
+
+	var set models.JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := DecodeJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
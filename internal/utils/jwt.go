@@ -1,7 +1,13 @@
 package utils
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/http"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/Keba777/levpay-backend/internal/models"
@@ -9,22 +15,84 @@ import (
 	"github.com/google/uuid"
 )
 
-// JWTUtils handles JWT token generation and validation
+// HashToken returns the SHA-256 hex digest of a token for storage
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// JWTUtils handles JWT token generation and validation. Tokens are signed
+// with Ed25519 keys named by the kid header. The auth service holds the
+// signing key; other services only hold public keys fetched from its JWKS.
 type JWTUtils struct {
-	secret string
+	mu        sync.RWMutex
+	signingID string
+	signer    ed25519.PrivateKey
+	keys      map[string]ed25519.PublicKey
+
+	// JWKS source for verify-only services, empty when keys are set locally
+	jwksURL     string
+	jwksClient  *http.Client
+	jwksFetched time.Time
 }
 
 // GenerateToken creates a new JWT token
 func (j *JWTUtils) GenerateToken(userID uuid.UUID, role string, expirySeconds int, tokenType int8) (string, error) {
-	claims := jwt.MapClaims{
+	return j.sign(j.baseClaims(userID, role, expirySeconds, tokenType))
+}
+
+// GenerateSessionToken creates a JWT token bound to a login session, so it
+// stops working as soon as the session is revoked
+func (j *JWTUtils) GenerateSessionToken(userID uuid.UUID, role string, sessionID uuid.UUID, expirySeconds int, tokenType int8) (string, error) {
+	claims := j.baseClaims(userID, role, expirySeconds, tokenType)
+	claims["sid"] = sessionID.String()
+	return j.sign(claims)
+}
+
+// GenerateImpersonationToken creates an access token for staff viewing the
+// account as the user. It is bound to the impersonator's own session, so
+// signing them out also ends the impersonation.
+func (j *JWTUtils) GenerateImpersonationToken(userID uuid.UUID, role string, impersonatorID, sessionID uuid.UUID, expirySeconds int) (string, error) {
+	claims := j.baseClaims(userID, role, expirySeconds, models.JWTAccess)
+	claims["sid"] = sessionID.String()
+	claims["imp"] = impersonatorID.String()
+	return j.sign(claims)
+}
+
+// GenerateUnlockToken creates an unlock token bound to one lockout. Unlocking
+// clears the lockout, so the token works once.
+func (j *JWTUtils) GenerateUnlockToken(userID uuid.UUID, role string, lockedUntil time.Time, expirySeconds int) (string, error) {
+	claims := j.baseClaims(userID, role, expirySeconds, models.JWTUnlock)
+	claims["lck"] = lockedUntil.UnixMicro()
+	return j.sign(claims)
+}
+
+// baseClaims builds the claims shared by every token
+func (j *JWTUtils) baseClaims(userID uuid.UUID, role string, expirySeconds int, tokenType int8) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
 		"token_type": tokenType,
-		"exp":        time.Now().Add(time.Duration(expirySeconds) * time.Second).Unix(),
+		"iat":        now.Unix(),
+		"exp":        now.Add(time.Duration(expirySeconds) * time.Second).Unix(),
 		"user_id":    userID.String(),
 		"role":       role,
+		"jti":        uuid.NewString(), // Keeps tokens issued in the same second distinct
+	}
+}
+
+// sign signs the claims with the current signing key
+func (j *JWTUtils) sign(claims jwt.MapClaims) (string, error) {
+	j.mu.RLock()
+	signingID, signer := j.signingID, j.signer
+	j.mu.RUnlock()
+
+	if signer == nil {
+		return "", fmt.Errorf("no signing key loaded")
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(j.secret))
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = signingID
+	signed, err := token.SignedString(signer)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -37,10 +105,14 @@ func (j *JWTUtils) DecodeToken(tokenString string, expectedType int8) (models.De
 	var decodedToken models.DecodedToken
 
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(j.secret), nil
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("kid header missing")
+		}
+		return j.verificationKey(kid)
 	})
 
 	if err != nil {
@@ -70,6 +142,11 @@ func (j *JWTUtils) DecodeToken(tokenString string, expectedType int8) (models.De
 		return decodedToken, fmt.Errorf("exp missing or invalid")
 	}
 
+	// Extract issue time, absent on tokens issued before it was recorded
+	if iat, ok := claims["iat"].(float64); ok {
+		decodedToken.IssuedAt = int(iat)
+	}
+
 	// Extract and validate token_type
 	claimedToken, ok := claims["token_type"].(float64)
 	if !ok {
@@ -88,15 +165,115 @@ func (j *JWTUtils) DecodeToken(tokenString string, expectedType int8) (models.De
 		decodedToken.Role = role
 	}
 
+	// Extract session, present on tokens bound to a login session
+	if sid, ok := claims["sid"].(string); ok {
+		parsedSessionID, err := uuid.Parse(sid)
+		if err != nil {
+			return decodedToken, fmt.Errorf("invalid sid format: %w", err)
+		}
+		decodedToken.SessionID = parsedSessionID
+	}
+
+	// Extract impersonator, present on tokens issued to staff viewing as the user
+	if imp, ok := claims["imp"].(string); ok {
+		parsedImpersonatorID, err := uuid.Parse(imp)
+		if err != nil {
+			return decodedToken, fmt.Errorf("invalid imp format: %w", err)
+		}
+		decodedToken.ImpersonatorID = parsedImpersonatorID
+	}
+
+	// Extract lockout, present on unlock tokens
+	if lck, ok := claims["lck"].(float64); ok {
+		decodedToken.LockedUntil = int64(lck)
+	}
+
 	return decodedToken, nil
 }
 
+// SetKeys replaces the signing key and the set of keys accepted for verification
+func (j *JWTUtils) SetKeys(signingID string, signer ed25519.PrivateKey, keys map[string]ed25519.PublicKey) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.signingID = signingID
+	j.signer = signer
+	j.keys = keys
+}
+
+// JWKS returns the verification keys as a JSON Web Key Set
+func (j *JWTUtils) JWKS() models.JWKSet {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	set := models.JWKSet{Keys: make([]models.JWK, 0, len(j.keys))}
+	for kid, key := range j.keys {
+		set.Keys = append(set.Keys, EncodeJWK(kid, key))
+	}
+	sort.Slice(set.Keys, func(a, b int) bool { return set.Keys[a].Kid < set.Keys[b].Kid })
+	return set
+}
+
+// verificationKey looks up the public key for a kid. Verify-only instances
+// refetch the JWKS when their copy is stale or the kid is unknown.
+func (j *JWTUtils) verificationKey(kid string) (ed25519.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	fetched := j.jwksFetched
+	j.mu.RUnlock()
+
+	if j.jwksURL != "" {
+		age := time.Since(fetched)
+		if age > jwksRefreshInterval || (!ok && age > jwksMinRefresh) {
+			if err := j.refreshJWKS(); err != nil {
+				if !ok {
+					return nil, err
+				}
+			} else {
+				j.mu.RLock()
+				key, ok = j.keys[kid]
+				j.mu.RUnlock()
+			}
+		}
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %s", kid)
+	}
+	return key, nil
+}
+
+// refreshJWKS reloads the verification keys from the JWKS endpoint
+func (j *JWTUtils) refreshJWKS() error {
+	keys, err := fetchJWKS(j.jwksClient, j.jwksURL)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	// Record the attempt either way so a failing endpoint is not hammered
+	j.jwksFetched = time.Now()
+	if err != nil {
+		return err
+	}
+	j.keys = keys
+	return nil
+}
+
 // JWT is the global JWT utility instance
 var JWT *JWTUtils
 
-// InitJWT initializes the JWT utilities with a secret
-func InitJWT(secret string) {
+// InitJWT initializes the JWT utilities for a service that issues tokens.
+// Keys are loaded afterwards with SetKeys.
+func InitJWT() {
+	JWT = &JWTUtils{
+		keys: map[string]ed25519.PublicKey{},
+	}
+}
+
+// InitJWTVerifier initializes the JWT utilities for a service that only
+// verifies tokens, using the keys published at jwksURL
+func InitJWTVerifier(jwksURL string) {
 	JWT = &JWTUtils{
-		secret: secret,
+		keys:       map[string]ed25519.PublicKey{},
+		jwksURL:    jwksURL,
+		jwksClient: &http.Client{Timeout: 5 * time.Second},
 	}
 }
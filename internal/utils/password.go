@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"math/big"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -12,6 +13,8 @@ import (
 // BcryptHasher handles password hashing and verification
 type BcryptHasher struct {
 	complexity int
+	dummyOnce  sync.Once
+	dummyHash  []byte
 }
 
 // NewBcryptHasher creates a new bcrypt hasher with specified complexity
@@ -42,12 +45,25 @@ func (b *BcryptHasher) CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
+// CheckDummyPassword spends as long as checking a real password and always
+// fails. Logins for unknown accounts run it so their response time does not
+// reveal which emails have accounts.
+func (b *BcryptHasher) CheckDummyPassword(password string) bool {
+	b.dummyOnce.Do(func() {
+		b.dummyHash, _ = bcrypt.GenerateFromPassword([]byte(GeneratePassword(32)), b.complexity)
+	})
+	_ = bcrypt.CompareHashAndPassword(b.dummyHash, []byte(password))
+	return false
+}
+
 // PWD is the global password hasher instance
 var PWD *BcryptHasher
 
 // InitPasswordUtils initializes the password utilities with complexity
 func InitPasswordUtils(complexity int) {
 	PWD = NewBcryptHasher(complexity)
+	// Hash the dummy password now so the first unknown login is not slower
+	go PWD.CheckDummyPassword("")
 }
 
 // GeneratePassword generates a random alphanumeric password
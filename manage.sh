@@ -83,6 +83,7 @@ show_usage() {
     echo ""
     echo -e "${GREEN}${BOLD}👤 IDENTITY & ACCESS${NC}"
     echo "  register        Interactively create a new user/merchant"
+    echo "  payment-keys    Manage payment method encryption keys (generate, rotate, reencrypt, status)"
     echo ""
     echo -e "${BLUE}${BOLD}❓ HELP${NC}"
     echo "  help            Display this luxury menu"
@@ -294,6 +295,7 @@ main() {
         health)         print_header; check_health ;;
         shell)          docker compose -f "$COMPOSE_FILE" exec "$1" sh ;;
         register)       print_header; register_user ;;
+        payment-keys)   go run ./cmd/tools/payment_keys "$@" ;;
         help|--help|-h) show_usage ;;
         *)              print_error "Unknown directive: $cmd"; show_usage; exit 1 ;;
     esac
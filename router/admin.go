@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/Keba777/levpay-backend/feature/admin"
 	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -14,17 +15,30 @@ func SetupAdminRoutes(api fiber.Router, db *gorm.DB) {
 
 	adminGroup := api.Group("/admin")
 
-	// Apply JWT and Admin Role Middleware
+	// Apply JWT Middleware; each endpoint requires its own permission
 	adminGroup.Use(middleware.JWTMiddleware(db))
-	adminGroup.Use(middleware.RequireRole("admin"))
 
 	// Dashboard
-	adminGroup.Get("/dashboard", handler.GetDashboard)
+	adminGroup.Get("/dashboard", middleware.RequirePermission(models.PermReportsView), handler.GetDashboard)
 
 	// User Management
-	adminGroup.Get("/users", handler.ListUsers)
-	adminGroup.Patch("/users/:id/status", handler.UpdateUserStatus)
+	adminGroup.Get("/users", middleware.RequirePermission(models.PermUsersView), handler.ListUsers)
+	adminGroup.Patch("/users/:id/status", middleware.RequirePermission(models.PermUsersSuspend), handler.UpdateUserStatus)
+	adminGroup.Get("/users/:id/status-history", middleware.RequirePermission(models.PermUsersView), handler.GetUserStatusHistory)
+
+	// Role Management
+	adminGroup.Get("/roles", middleware.RequirePermission(models.PermRolesManage), handler.ListRoles)
+	adminGroup.Get("/users/:id/roles", middleware.RequirePermission(models.PermRolesManage), handler.GetUserRoles)
+	adminGroup.Post("/users/:id/roles", middleware.RequirePermission(models.PermRolesManage), handler.GrantRole)
+	adminGroup.Delete("/users/:id/roles/:role", middleware.RequirePermission(models.PermRolesManage), handler.RevokeRole)
 
 	// Audit Logs
-	adminGroup.Get("/audit-logs", handler.GetAuditLogs)
+	adminGroup.Get("/audit-logs", middleware.RequirePermission(models.PermAuditView), handler.GetAuditLogs)
+	adminGroup.Get("/audit-logs/verify", middleware.RequirePermission(models.PermAuditView), handler.VerifyAuditLogs)
+	adminGroup.Get("/audit-logs/export", middleware.RequirePermission(models.PermAuditView), handler.ExportAuditLogs)
+
+	// Cron Jobs
+	adminGroup.Get("/jobs", middleware.RequirePermission(models.PermJobsManage), handler.ListJobs)
+	adminGroup.Get("/jobs/runs", middleware.RequirePermission(models.PermJobsManage), handler.ListJobRuns)
+	adminGroup.Post("/jobs/:name/run", middleware.RequirePermission(models.PermJobsManage), handler.TriggerJob)
 }
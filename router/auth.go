@@ -3,27 +3,67 @@ package router
 import (
 	"github.com/Keba777/levpay-backend/feature/auth"
 	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 // SetupAuthRoutes configures all authentication routes
-func SetupAuthRoutes(app *fiber.App, db *gorm.DB) {
-	authHandler := auth.NewHandler(db)
+func SetupAuthRoutes(app *fiber.App, db *gorm.DB, policy *security.Policy) {
+	authHandler := auth.NewHandler(db, policy)
 
-	// Auth routes group
-	authRoutes := app.Group("/api/auth")
+	// Auth routes group, rate limited per client IP
+	authRoutes := app.Group("/api/auth", middleware.RateLimit(ratelimit.PolicyAuth))
 
-	// Public routes (no authentication required)
-	authRoutes.Post("/register", authHandler.Register)
-	authRoutes.Post("/login", authHandler.Login)
+	// Public routes (no authentication required). Those that check
+	// credentials or send codes have a stricter limit against guessing.
+	signInLimit := middleware.RateLimit(ratelimit.PolicySignIn)
+	authRoutes.Post("/register", signInLimit, authHandler.Register)
+	authRoutes.Post("/login", signInLimit, authHandler.Login)
 	authRoutes.Post("/refresh", authHandler.Refresh)
 	authRoutes.Post("/logout", authHandler.Logout)
-	authRoutes.Post("/forgot-password", authHandler.ForgotPassword)
-	authRoutes.Post("/reset-password", authHandler.ResetPassword)
-	authRoutes.Post("/google", authHandler.GoogleAuth)
+	authRoutes.Post("/forgot-password", signInLimit, authHandler.ForgotPassword)
+	authRoutes.Post("/reset-password", signInLimit, authHandler.ResetPassword)
+	authRoutes.Post("/google", signInLimit, authHandler.GoogleAuth)
+	authRoutes.Post("/oauth/:provider", signInLimit, authHandler.OAuthSignIn)
+	authRoutes.Post("/2fa/verify", signInLimit, authHandler.VerifyTwoFactor)
+	authRoutes.Post("/verify-email", signInLimit, authHandler.VerifyEmail)
+	authRoutes.Post("/unlock", signInLimit, authHandler.UnlockAccount)
 
 	// Protected routes (require authentication)
 	authRoutes.Get("/me", middleware.JWTMiddleware(db), authHandler.GetMe)
-	authRoutes.Post("/change-password", middleware.JWTMiddleware(db), authHandler.ChangePassword)
+	authRoutes.Post("/resend-verification", middleware.JWTMiddleware(db), authHandler.ResendVerification)
+	authRoutes.Post("/change-password", middleware.JWTMiddleware(db), middleware.RequireStepUp(policy), authHandler.ChangePassword)
+
+	// Phone verification routes
+	phone := authRoutes.Group("/phone", middleware.JWTMiddleware(db))
+	phone.Post("/send-code", authHandler.SendPhoneCode)
+	phone.Post("/change", authHandler.ChangePhone)
+	phone.Post("/verify", authHandler.VerifyPhone)
+
+	// Two-factor management routes
+	twoFactor := authRoutes.Group("/2fa", middleware.JWTMiddleware(db))
+	twoFactor.Get("/", authHandler.GetTwoFactorStatus)
+	twoFactor.Post("/setup", authHandler.SetupTwoFactor)
+	twoFactor.Post("/enable", authHandler.EnableTwoFactor)
+	twoFactor.Post("/disable", authHandler.DisableTwoFactor)
+	twoFactor.Post("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+	twoFactor.Post("/step-up", authHandler.StepUp)
+
+	// Linked sign-in provider routes
+	identities := authRoutes.Group("/identities", middleware.JWTMiddleware(db))
+	identities.Get("/", authHandler.ListIdentities)
+	identities.Post("/:provider", middleware.RequireStepUp(policy), authHandler.LinkIdentity)
+	identities.Delete("/:provider", middleware.RequireStepUp(policy), authHandler.UnlinkIdentity)
+
+	// Device session routes
+	sessions := authRoutes.Group("/sessions", middleware.JWTMiddleware(db))
+	sessions.Get("/", authHandler.ListSessions)
+	sessions.Post("/revoke-others", authHandler.RevokeOtherSessions)
+	sessions.Delete("/:id", authHandler.RevokeSession)
+
+	// Support staff view an account as its user (read-only, audited)
+	authRoutes.Post("/impersonate/:id", middleware.JWTMiddleware(db), middleware.RequirePermission(models.PermUsersImpersonate), middleware.RequireStepUp(policy), authHandler.Impersonate)
 }
@@ -2,30 +2,42 @@ package router
 
 import (
 	"github.com/Keba777/levpay-backend/feature/billing"
+	"github.com/Keba777/levpay-backend/feature/pin"
 	"github.com/Keba777/levpay-backend/feature/transaction"
 	"github.com/Keba777/levpay-backend/feature/wallet"
 	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 // SetupBillingRoutes sets up routes for Billing service
-func SetupBillingRoutes(api fiber.Router, db *gorm.DB) {
+func SetupBillingRoutes(api fiber.Router, db *gorm.DB, policy *security.Policy) {
 	billingRepo := billing.NewRepository(db)
 	txRepo := transaction.NewRepository(db)
 	walletRepo := wallet.NewRepository(db)
-	handler := billing.NewHandler(billingRepo, txRepo, walletRepo, db)
+	handler := billing.NewHandler(billingRepo, txRepo, walletRepo, pin.NewService(db, policy), db)
 
 	billingGroup := api.Group("/billing")
 
 	// Apply JWT Middleware to all billing routes
 	billingGroup.Use(middleware.JWTMiddleware(db))
 
+	// Resolve the merchant account staff are acting for
+	billingGroup.Use(middleware.MerchantContext(db))
+
 	// Invoice Endpoints
-	billingGroup.Post("/invoices", handler.CreateInvoice)
-	billingGroup.Get("/invoices", handler.ListInvoices)
-	billingGroup.Get("/invoices/:id", handler.GetInvoice)
-	billingGroup.Post("/invoices/:id/pay", handler.PayInvoice)
-	billingGroup.Put("/invoices/:id/cancel", handler.CancelInvoice)
-	billingGroup.Get("/stats", handler.GetInvoiceStats)
+	billingGroup.Post("/invoices", middleware.RequireMerchantPermission(models.PermInvoicesWrite), handler.CreateInvoice)
+	billingGroup.Get("/invoices", middleware.RequireMerchantPermission(models.PermInvoicesRead), handler.ListInvoices)
+	billingGroup.Get("/invoices/:id", middleware.RequireMerchantPermission(models.PermInvoicesRead), handler.GetInvoice)
+	billingGroup.Post("/invoices/:id/pay", middleware.RateLimit(ratelimit.PolicyMoney), middleware.RequireVerifiedEmail(), handler.PayInvoice)
+	billingGroup.Put("/invoices/:id/cancel", middleware.RequireMerchantPermission(models.PermInvoicesWrite), handler.CancelInvoice)
+	billingGroup.Get("/invoices/:id/reminders", middleware.RequireMerchantPermission(models.PermInvoicesRead), handler.GetInvoiceReminders)
+	billingGroup.Get("/stats", middleware.RequireMerchantPermission(models.PermReportsRead), handler.GetInvoiceStats)
+
+	// Reminder Settings
+	billingGroup.Get("/reminders/settings", middleware.RequireMerchantPermission(models.PermInvoicesRead), handler.GetReminderSettings)
+	billingGroup.Put("/reminders/settings", middleware.RequireMerchantPermission(models.PermSettingsManage), handler.UpdateReminderSettings)
 }
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"github.com/Keba777/levpay-backend/feature/dispute"
+	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupDisputeRoutes sets up routes for payment disputes
+func SetupDisputeRoutes(api fiber.Router, db *gorm.DB) {
+	repo := dispute.NewRepository(db)
+	service := dispute.NewService(db)
+	handler := dispute.NewHandler(repo, service)
+
+	disputeGroup := api.Group("/disputes")
+
+	// Apply JWT Middleware to all dispute routes
+	disputeGroup.Use(middleware.JWTMiddleware(db))
+
+	// Admin Endpoints
+	admin := disputeGroup.Group("/admin")
+	admin.Get("/", middleware.RequirePermission(models.PermReportsView), handler.ListAllDisputes)
+	admin.Post("/:id/decide", middleware.RequirePermission(models.PermTransactionsReverse), handler.DecideDispute)
+
+	// Resolve the merchant account staff are acting for
+	disputeGroup.Use(middleware.MerchantContext(db))
+
+	// Payer and Merchant Endpoints
+	disputeGroup.Post("/", handler.OpenDispute)
+	disputeGroup.Get("/", handler.ListDisputes)
+	disputeGroup.Get("/:id", handler.GetDispute)
+	disputeGroup.Post("/:id/evidence", handler.UploadEvidence)
+	disputeGroup.Post("/:id/respond", middleware.RequireMerchantPermission(models.PermRefundsIssue), handler.RespondDispute)
+	disputeGroup.Post("/:id/cancel", handler.CancelDispute)
+}
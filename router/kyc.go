@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/Keba777/levpay-backend/feature/kyc"
 	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -23,7 +24,7 @@ func SetupKYCRoutes(api fiber.Router, db *gorm.DB) {
 
 	// Admin Endpoints
 	admin := kycGroup.Group("/admin")
-	admin.Use(middleware.RequireRole("admin"))
+	admin.Use(middleware.RequirePermission(models.PermKYCReview))
 
 	admin.Get("/pending", handler.ListPending)
 	admin.Post("/review/:id", handler.ReviewDocument)
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"github.com/Keba777/levpay-backend/feature/kyc"
+	"github.com/Keba777/levpay-backend/feature/merchant"
+	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupMerchantRoutes sets up routes for merchant onboarding
+func SetupMerchantRoutes(api fiber.Router, db *gorm.DB) {
+	repo := merchant.NewRepository(db)
+	kycRepo := kyc.NewRepository(db)
+	handler := merchant.NewHandler(repo, kycRepo)
+
+	// Public routes (used by payment pages)
+	api.Get("/merchants/:id/public", handler.GetPublicProfile)
+
+	merchantGroup := api.Group("/merchants")
+
+	// Apply JWT Middleware to all other merchant routes
+	merchantGroup.Use(middleware.JWTMiddleware(db))
+
+	// Onboarding Endpoints
+	merchantGroup.Get("/profile", handler.GetProfile)
+	merchantGroup.Put("/profile", handler.SaveProfile)
+	merchantGroup.Post("/profile/logo", handler.UploadLogo)
+	merchantGroup.Post("/documents", handler.UploadDocument)
+	merchantGroup.Post("/submit", handler.SubmitApplication)
+
+	// Admin Endpoints
+	admin := merchantGroup.Group("/admin")
+	admin.Use(middleware.RequirePermission(models.PermKYCReview))
+
+	admin.Get("/applications", handler.ListApplications)
+	admin.Get("/applications/:id", handler.GetApplication)
+	admin.Post("/applications/:id/review", handler.ReviewApplication)
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"github.com/Keba777/levpay-backend/feature/organization"
+	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupOrganizationRoutes sets up routes for merchant teams
+func SetupOrganizationRoutes(api fiber.Router, db *gorm.DB) {
+	repo := organization.NewRepository(db)
+	handler := organization.NewHandler(repo, db)
+
+	teamGroup := api.Group("/team")
+
+	// Apply JWT Middleware to all team routes
+	teamGroup.Use(middleware.JWTMiddleware(db))
+
+	// Member Endpoints (no merchant context needed)
+	teamGroup.Get("/memberships", handler.ListMemberships)
+	teamGroup.Post("/invitations/accept", handler.AcceptInvitation)
+
+	// Team Management Endpoints
+	manage := teamGroup.Group("/")
+	manage.Use(middleware.MerchantContext(db))
+
+	manage.Get("/", middleware.RequireMerchantPermission(models.PermTeamManage), handler.GetTeam)
+	manage.Post("/invitations", middleware.RequireMerchantPermission(models.PermTeamManage), handler.InviteMember)
+	manage.Put("/members/:id", middleware.RequireMerchantPermission(models.PermTeamManage), handler.UpdateMemberRole)
+	manage.Delete("/members/:id", middleware.RequireMerchantPermission(models.PermTeamManage), handler.RemoveMember)
+	manage.Get("/audit", middleware.RequireMerchantPermission(models.PermTeamManage), handler.GetAuditLog)
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"github.com/Keba777/levpay-backend/feature/payment_method"
+	"github.com/Keba777/levpay-backend/internal/kms"
 	"github.com/Keba777/levpay-backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -9,7 +10,7 @@ import (
 
 func SetupPaymentMethodRoutes(api fiber.Router, db *gorm.DB) {
 	repo := payment_method.NewRepository(db)
-	handler := payment_method.NewHandler(repo)
+	handler := payment_method.NewHandler(repo, kms.KMS)
 
 	pm := api.Group("/payment-methods")
 	pm.Use(middleware.JWTMiddleware(db))
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"github.com/Keba777/levpay-backend/feature/settlement"
+	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupSettlementRoutes sets up routes for merchant settlements
+func SetupSettlementRoutes(api fiber.Router, db *gorm.DB) {
+	repo := settlement.NewRepository(db)
+	handler := settlement.NewHandler(repo, db)
+
+	settlementGroup := api.Group("/settlements")
+
+	// Apply JWT Middleware to all settlement routes
+	settlementGroup.Use(middleware.JWTMiddleware(db))
+
+	// Admin Endpoints
+	admin := settlementGroup.Group("/admin")
+	admin.Use(middleware.RequirePermission(models.PermSettlementsManage))
+
+	admin.Put("/merchants/:id/reserve", handler.UpdateReserve)
+
+	// Merchant Endpoints (owner or team members acting for the merchant)
+	merchant := settlementGroup.Group("/")
+	merchant.Use(middleware.MerchantContext(db))
+	merchant.Use(middleware.RequireMerchantAccount(db))
+
+	merchant.Get("/settings", middleware.RequireMerchantPermission(models.PermReportsRead), handler.GetSettings)
+	merchant.Put("/settings", middleware.RequireMerchantPermission(models.PermSettingsManage), handler.UpdateSettings)
+	merchant.Get("/batches", middleware.RequireMerchantPermission(models.PermReportsRead), handler.ListBatches)
+	merchant.Get("/batches/:id", middleware.RequireMerchantPermission(models.PermReportsRead), handler.GetBatchReport)
+}
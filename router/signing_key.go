@@ -0,0 +1,13 @@
+package router
+
+import (
+	"github.com/Keba777/levpay-backend/feature/signing_key"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupJWKSRoutes publishes the JWT verification keys for other services
+func SetupJWKSRoutes(app *fiber.App) {
+	handler := signing_key.NewHandler()
+
+	app.Get("/.well-known/jwks.json", handler.GetJWKS)
+}
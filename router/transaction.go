@@ -1,27 +1,31 @@
 package router
 
 import (
+	"github.com/Keba777/levpay-backend/feature/pin"
 	"github.com/Keba777/levpay-backend/feature/transaction"
 	"github.com/Keba777/levpay-backend/feature/wallet"
 	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 // SetupTransactionRoutes sets up routes for Transaction service
-func SetupTransactionRoutes(api fiber.Router, db *gorm.DB) {
+func SetupTransactionRoutes(api fiber.Router, db *gorm.DB, policy *security.Policy) {
 	txRepo := transaction.NewRepository(db)
 	walletRepo := wallet.NewRepository(db)
-	handler := transaction.NewHandler(txRepo, walletRepo, db)
+	handler := transaction.NewHandler(txRepo, walletRepo, pin.NewService(db, policy), db)
 
 	txGroup := api.Group("/transaction")
 
 	// Apply JWT Middleware to all transaction routes
 	txGroup.Use(middleware.JWTMiddleware(db))
 
-	// User Endpoints
-	txGroup.Post("/transfer", handler.Transfer)
-	txGroup.Post("/payment", handler.Payment)
+	// User Endpoints; money movement is rate limited per user
+	moneyLimit := middleware.RateLimit(ratelimit.PolicyMoney)
+	txGroup.Post("/transfer", moneyLimit, middleware.RequireVerifiedEmail(), handler.Transfer)
+	txGroup.Post("/payment", moneyLimit, middleware.RequireVerifiedEmail(), handler.Payment)
 	txGroup.Get("/history", handler.GetHistory)
 	txGroup.Get("/:id", handler.GetTransactionDetails)
 }
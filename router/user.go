@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/Keba777/levpay-backend/feature/user"
 	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -26,10 +27,7 @@ func SetupUserRoutes(api fiber.Router, db *gorm.DB) {
 	users.Get("/settings", handler.GetSettings)
 	users.Put("/settings", handler.UpdateSettings)
 
-	// Admin only routes
-	admin := users.Group("/")
-	admin.Use(middleware.RequireRole("admin"))
-
-	admin.Get("/", handler.ListUsers)
-	admin.Patch("/:id/kyc", handler.UpdateKYC)
+	// Staff routes
+	users.Get("/", middleware.RequirePermission(models.PermUsersView), handler.ListUsers)
+	users.Patch("/:id/kyc", middleware.RequirePermission(models.PermKYCReview), handler.UpdateKYC)
 }
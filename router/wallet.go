@@ -1,26 +1,38 @@
 package router
 
 import (
+	"github.com/Keba777/levpay-backend/feature/pin"
 	"github.com/Keba777/levpay-backend/feature/wallet"
 	"github.com/Keba777/levpay-backend/internal/middleware"
+	"github.com/Keba777/levpay-backend/internal/ratelimit"
+	"github.com/Keba777/levpay-backend/internal/security"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 // SetupWalletRoutes sets up routes for Wallet service
-func SetupWalletRoutes(api fiber.Router, db *gorm.DB) {
+func SetupWalletRoutes(api fiber.Router, db *gorm.DB, policy *security.Policy) {
 	repo := wallet.NewRepository(db)
-	handler := wallet.NewHandler(repo)
+	pins := pin.NewService(db, policy)
+	handler := wallet.NewHandler(repo, pins)
+	pinHandler := pin.NewHandler(pins)
 
 	walletGroup := api.Group("/wallet")
 
 	// Apply JWT Middleware to all wallet routes
 	walletGroup.Use(middleware.JWTMiddleware(db))
 
-	// User Endpoints
+	// User Endpoints; money movement is rate limited per user
+	moneyLimit := middleware.RateLimit(ratelimit.PolicyMoney)
 	walletGroup.Get("/balance", handler.GetBalance)
-	walletGroup.Post("/topup", handler.TopUp)
-	walletGroup.Post("/withdraw", handler.Withdraw)
+	walletGroup.Post("/topup", moneyLimit, middleware.RequireVerifiedEmail(), handler.TopUp)
+	walletGroup.Post("/withdraw", moneyLimit, middleware.RequireVerifiedEmail(), middleware.RequireStepUp(policy), handler.Withdraw)
 	walletGroup.Post("/lock", handler.LockWallet)
 	walletGroup.Post("/unlock", handler.UnlockWallet)
+
+	// Transaction PIN Endpoints
+	walletGroup.Get("/pin", pinHandler.GetStatus)
+	walletGroup.Post("/pin", middleware.RequireStepUp(policy), pinHandler.SetPIN)
+	walletGroup.Put("/pin", pinHandler.ChangePIN)
+	walletGroup.Post("/pin/reset", middleware.RequireStepUp(policy), pinHandler.ResetPIN)
 }